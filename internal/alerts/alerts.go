@@ -0,0 +1,81 @@
+package alerts
+
+import (
+	"context"
+	"time"
+
+	"wooper-bot/internal/logger"
+	"wooper-bot/internal/outbound"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// queueSize bounds the alerts waiting to be posted; more are only logged.
+const queueSize = 50
+
+// Sender is the part of the Discord session alerts are posted through.
+type Sender interface {
+	ChannelMessageSend(channelID string, content string, options ...discordgo.RequestOption) (*discordgo.Message, error)
+}
+
+// Notifier posts alerts for the bot admins, like images taken out of rotation, to a channel.
+type Notifier struct {
+	channelID  string
+	dispatcher *outbound.Dispatcher
+	queue      chan string
+}
+
+// New creates a notifier posting to channelID. With an empty channelID alerts are only logged.
+func New(channelID string, dispatcher *outbound.Dispatcher) *Notifier {
+	return &Notifier{
+		channelID:  channelID,
+		dispatcher: dispatcher,
+		queue:      make(chan string, queueSize),
+	}
+}
+
+// Notify logs an alert and queues it for the admin channel. It never blocks.
+func (n *Notifier) Notify(message string) {
+	logger.Logger.Warn("Admin alert", zap.String("alert", message))
+	if n.channelID == "" {
+		return
+	}
+
+	select {
+	case n.queue <- message:
+	default:
+		logger.Logger.Warn("Admin alert queue full, alert not posted", zap.String("alert", message))
+	}
+}
+
+// Run posts queued alerts until ctx is cancelled.
+func (n *Notifier) Run(ctx context.Context, s *discordgo.Session) error {
+	return n.run(ctx, s)
+}
+
+func (n *Notifier) run(ctx context.Context, sender Sender) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case message := <-n.queue:
+			n.post(ctx, sender, message)
+		}
+	}
+}
+
+func (n *Notifier) post(ctx context.Context, sender Sender, message string) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	err := n.dispatcher.Do(ctx, outbound.ChannelRoute(n.channelID), func(options ...discordgo.RequestOption) error {
+		_, err := sender.ChannelMessageSend(n.channelID, message, options...)
+		return err
+	})
+	if err != nil {
+		logger.Logger.Error("Failed to post admin alert",
+			zap.String("channel_id", n.channelID),
+			zap.Error(err))
+	}
+}
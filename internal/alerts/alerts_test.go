@@ -0,0 +1,80 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"wooper-bot/internal/logger"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type fakeSender struct {
+	sent chan string
+	err  error
+}
+
+func (f *fakeSender) ChannelMessageSend(channelID string, content string, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.sent <- channelID + ": " + content
+	return &discordgo.Message{}, f.err
+}
+
+func setupTestLogger(t *testing.T) {
+	t.Helper()
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to init logger: %v", err)
+	}
+	t.Cleanup(logger.Close)
+}
+
+// TestNotifier tests that queued alerts are posted to the admin channel.
+func TestNotifier(t *testing.T) {
+	setupTestLogger(t)
+
+	n := New("admins", nil)
+	sender := &fakeSender{sent: make(chan string, 2), err: errors.New("missing access")}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- n.run(ctx, sender) }()
+
+	n.Notify("first")
+	n.Notify("second")
+	for _, expected := range []string{"admins: first", "admins: second"} {
+		select {
+		case got := <-sender.sent:
+			if got != expected {
+				t.Errorf("Expected %q, got %q", expected, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Timed out waiting for %q", expected)
+		}
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+// TestNotifier_NoChannel tests that alerts are only logged without an admin channel.
+func TestNotifier_NoChannel(t *testing.T) {
+	setupTestLogger(t)
+
+	n := New("", nil)
+	n.Notify("alert")
+	if len(n.queue) != 0 {
+		t.Errorf("Expected nothing queued, got %d alerts", len(n.queue))
+	}
+
+	// A full queue drops alerts instead of blocking
+	n = New("admins", nil)
+	for i := 0; i < queueSize+1; i++ {
+		n.Notify("alert")
+	}
+	if len(n.queue) != queueSize {
+		t.Errorf("Expected %d queued alerts, got %d", queueSize, len(n.queue))
+	}
+}
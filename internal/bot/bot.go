@@ -4,12 +4,20 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sync"
 
 	"github.com/bwmarrin/discordgo"
 )
 
+// Service is a background task that runs while the Discord session is open,
+// such as the image scheduler. Run must return once ctx is cancelled.
+type Service interface {
+	Run(ctx context.Context, s *discordgo.Session) error
+}
+
 type Bot struct {
-	session *discordgo.Session
+	session  *discordgo.Session
+	services []Service
 }
 
 func New(token string) (*Bot, error) {
@@ -17,7 +25,8 @@ func New(token string) (*Bot, error) {
 	if err != nil {
 		return nil, fmt.Errorf("create discord session: %w", err)
 	}
-	dg.Identify.Intents = discordgo.IntentsGuildMessages | discordgo.IntentMessageContent
+	dg.Identify.Intents = discordgo.IntentsGuilds | discordgo.IntentsGuildMessages | discordgo.IntentsDirectMessages | discordgo.IntentMessageContent |
+		discordgo.IntentsGuildMessageReactions | discordgo.IntentsDirectMessageReactions
 	return &Bot{session: dg}, nil
 }
 
@@ -25,6 +34,11 @@ func (b *Bot) AddHandler(handler interface{}) func() {
 	return b.session.AddHandler(handler)
 }
 
+// AddService registers a background service started once the session is open.
+func (b *Bot) AddService(svc Service) {
+	b.services = append(b.services, svc)
+}
+
 func (b *Bot) RegisterSlashCommands(commands []*discordgo.ApplicationCommand) error {
 	// Wait for the session to be ready
 	if b.session.State.User == nil {
@@ -45,7 +59,7 @@ func (b *Bot) Start(ctx context.Context) error {
 	if err := b.session.Open(); err != nil {
 		return fmt.Errorf("open discord session: %w", err)
 	}
-	<-ctx.Done()
+	b.runServices(ctx)
 	return b.session.Close()
 }
 
@@ -56,9 +70,27 @@ func (b *Bot) StartWithCommands(ctx context.Context, commands []*discordgo.Appli
 
 	// Register slash commands after session is open
 	if err := b.RegisterSlashCommands(commands); err != nil {
+		b.session.Close()
 		return fmt.Errorf("register slash commands: %w", err)
 	}
 
-	<-ctx.Done()
+	b.runServices(ctx)
 	return b.session.Close()
 }
+
+// runServices runs every registered service until ctx is cancelled and waits for them to stop.
+func (b *Bot) runServices(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, svc := range b.services {
+		wg.Add(1)
+		go func(svc Service) {
+			defer wg.Done()
+			if err := svc.Run(ctx, b.session); err != nil {
+				log.Printf("Service stopped with error: %v", err)
+			}
+		}(svc)
+	}
+
+	<-ctx.Done()
+	wg.Wait()
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"testing"
 	"time"
+
+	"github.com/bwmarrin/discordgo"
 )
 
 // TestNew tests the New function for creating Discord bot instances.
@@ -56,6 +58,28 @@ func TestNew(t *testing.T) {
 	}
 }
 
+// TestNew_Intents tests that the bot receives guild and direct messages and their reactions.
+func TestNew_Intents(t *testing.T) {
+	bot, err := New("token")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	intents := bot.session.Identify.Intents
+	for _, required := range []discordgo.Intent{
+		discordgo.IntentsGuilds,
+		discordgo.IntentsGuildMessages,
+		discordgo.IntentsDirectMessages,
+		discordgo.IntentMessageContent,
+		discordgo.IntentsGuildMessageReactions,
+		discordgo.IntentsDirectMessageReactions,
+	} {
+		if intents&required != required {
+			t.Errorf("Expected intent %d in %d", required, intents)
+		}
+	}
+}
+
 // TestBot_AddHandler tests the AddHandler method of the Bot struct.
 func TestBot_AddHandler(t *testing.T) {
 	bot, err := New("test-token")
@@ -94,3 +118,49 @@ func TestBot_Start(t *testing.T) {
 		t.Log("Note: Start() succeeded unexpectedly (this might be due to test environment)")
 	}
 }
+
+type stubService struct{}
+
+func (stubService) Run(ctx context.Context, s *discordgo.Session) error {
+	<-ctx.Done()
+	return nil
+}
+
+// TestBot_AddService tests that services are registered on the bot.
+func TestBot_AddService(t *testing.T) {
+	bot, err := New("test-token")
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+
+	bot.AddService(stubService{})
+	bot.AddService(stubService{})
+
+	if len(bot.services) != 2 {
+		t.Errorf("Expected 2 services but got %d", len(bot.services))
+	}
+}
+
+// TestBot_runServices tests that runServices waits for services to stop after cancellation.
+func TestBot_runServices(t *testing.T) {
+	bot, err := New("test-token")
+	if err != nil {
+		t.Fatalf("Failed to create bot: %v", err)
+	}
+	bot.AddService(stubService{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		bot.runServices(ctx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Errorf("runServices did not return after context cancellation")
+	}
+}
@@ -0,0 +1,190 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"wooper-bot/internal/i18n"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	channelMention = regexp.MustCompile(`^<#(\d+)>$`)
+	userMention    = regexp.MustCompile(`^<@!?(\d+)>$`)
+	roleMention    = regexp.MustCompile(`^<@&(\d+)>$`)
+	snowflake      = regexp.MustCompile(`^\d+$`)
+)
+
+// argError is a problem with the arguments of a text command. The router shows it in the
+// user's language; Error returns the default language text.
+type argError struct {
+	key  string
+	args []any
+}
+
+func newArgError(key string, args ...any) error {
+	return &argError{key: key, args: args}
+}
+
+func (e *argError) Error() string {
+	return i18n.T(i18n.DefaultLanguage, e.key, e.args...)
+}
+
+// argumentError renders an error of bindText in locale.
+func argumentError(err error, locale string) string {
+	var argErr *argError
+	if errors.As(err, &argErr) {
+		return i18n.T(locale, argErr.key, argErr.args...)
+	}
+	return err.Error()
+}
+
+// bindText maps text arguments onto options. Arguments may be given as name:value in any
+// order; the rest fill the remaining options positionally.
+func bindText(options []*Option, args []string) (map[string]any, error) {
+	values := make(map[string]any)
+	var positional []string
+
+	for _, arg := range args {
+		if name, value, ok := strings.Cut(arg, ":"); ok {
+			if opt := findOption(options, strings.ToLower(name)); opt != nil {
+				v, err := convertText(opt, value)
+				if err != nil {
+					return nil, err
+				}
+				values[opt.Name] = v
+				continue
+			}
+		}
+		positional = append(positional, arg)
+	}
+
+	for _, opt := range options {
+		if _, ok := values[opt.Name]; ok {
+			continue
+		}
+		if len(positional) == 0 {
+			break
+		}
+		v, err := convertText(opt, positional[0])
+		if err != nil {
+			return nil, err
+		}
+		values[opt.Name] = v
+		positional = positional[1:]
+	}
+	if len(positional) > 0 {
+		return nil, newArgError("args.too_many", strings.Join(positional, " "))
+	}
+
+	for _, opt := range options {
+		if _, ok := values[opt.Name]; !ok && opt.Required {
+			return nil, newArgError("args.missing", opt.Name)
+		}
+	}
+	return values, nil
+}
+
+// convertText parses and validates one text argument for an option.
+func convertText(opt *Option, raw string) (any, error) {
+	switch opt.Type {
+	case discordgo.ApplicationCommandOptionInteger:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, newArgError("args.not_integer", opt.Name)
+		}
+		if opt.MinValue != nil && float64(n) < *opt.MinValue {
+			return nil, newArgError("args.too_small", opt.Name, *opt.MinValue)
+		}
+		if opt.MaxValue != 0 && float64(n) > opt.MaxValue {
+			return nil, newArgError("args.too_large", opt.Name, opt.MaxValue)
+		}
+		return n, nil
+
+	case discordgo.ApplicationCommandOptionBoolean:
+		switch strings.ToLower(raw) {
+		case "true", "yes", "on", "1":
+			return true, nil
+		case "false", "no", "off", "0":
+			return false, nil
+		}
+		return nil, newArgError("args.not_boolean", opt.Name)
+
+	case discordgo.ApplicationCommandOptionChannel:
+		return parseID(opt, raw, channelMention)
+	case discordgo.ApplicationCommandOptionUser:
+		return parseID(opt, raw, userMention)
+	case discordgo.ApplicationCommandOptionRole:
+		return parseID(opt, raw, roleMention)
+	}
+
+	if opt.MaxLength != 0 && len(raw) > opt.MaxLength {
+		return nil, newArgError("args.too_long", opt.Name, opt.MaxLength)
+	}
+	if len(opt.Choices) > 0 {
+		for _, choice := range opt.Choices {
+			if fmt.Sprint(choice.Value) == raw {
+				return raw, nil
+			}
+		}
+		return nil, newArgError("args.not_choice", opt.Name, choiceList(opt.Choices))
+	}
+	return raw, nil
+}
+
+func parseID(opt *Option, raw string, mention *regexp.Regexp) (any, error) {
+	if m := mention.FindStringSubmatch(raw); m != nil {
+		return m[1], nil
+	}
+	if snowflake.MatchString(raw) {
+		return raw, nil
+	}
+	return nil, newArgError("args.not_id", opt.Name)
+}
+
+func choiceList(choices []*discordgo.ApplicationCommandOptionChoice) string {
+	values := make([]string, len(choices))
+	for i, choice := range choices {
+		values[i] = fmt.Sprint(choice.Value)
+	}
+	return strings.Join(values, ", ")
+}
+
+func findOption(options []*Option, name string) *Option {
+	for _, opt := range options {
+		if opt.Name == name {
+			return opt
+		}
+	}
+	return nil
+}
+
+// bindSlash converts interaction options to the same values bindText produces.
+func bindSlash(data []*discordgo.ApplicationCommandInteractionDataOption) map[string]any {
+	values := make(map[string]any)
+	for _, opt := range data {
+		switch opt.Type {
+		case discordgo.ApplicationCommandOptionInteger:
+			values[opt.Name] = opt.IntValue()
+		case discordgo.ApplicationCommandOptionBoolean:
+			values[opt.Name] = opt.BoolValue()
+		case discordgo.ApplicationCommandOptionString:
+			values[opt.Name] = opt.StringValue()
+		case discordgo.ApplicationCommandOptionChannel,
+			discordgo.ApplicationCommandOptionUser,
+			discordgo.ApplicationCommandOptionRole,
+			discordgo.ApplicationCommandOptionMentionable:
+			// Snowflake options carry the ID as a string
+			if id, ok := opt.Value.(string); ok {
+				values[opt.Name] = id
+			}
+		default:
+			values[opt.Name] = opt.Value
+		}
+	}
+	return values
+}
@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func testOptions() []*Option {
+	minWindow := 0.0
+	return []*Option{
+		{Name: "channel", Type: discordgo.ApplicationCommandOptionChannel, Required: true},
+		{Name: "time", Type: discordgo.ApplicationCommandOptionString, Required: true},
+		{Name: "window", Type: discordgo.ApplicationCommandOptionInteger, MinValue: &minWindow, MaxValue: 365},
+		{Name: "style", Type: discordgo.ApplicationCommandOptionString, Choices: []*discordgo.ApplicationCommandOptionChoice{
+			{Name: "plain", Value: "plain"},
+			{Name: "reply", Value: "reply"},
+		}},
+		{Name: "enabled", Type: discordgo.ApplicationCommandOptionBoolean},
+	}
+}
+
+// TestBindText tests positional and named text arguments.
+func TestBindText(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		expected    map[string]any
+		expectError bool
+	}{
+		{
+			name:     "positional",
+			args:     []string{"<#123>", "09:00", "3"},
+			expected: map[string]any{"channel": "123", "time": "09:00", "window": int64(3)},
+		},
+		{
+			name:     "named in any order",
+			args:     []string{"enabled:yes", "time:09:00", "<#123>"},
+			expected: map[string]any{"channel": "123", "time": "09:00", "enabled": true},
+		},
+		{
+			name:     "raw channel id",
+			args:     []string{"123", "09:00"},
+			expected: map[string]any{"channel": "123", "time": "09:00"},
+		},
+		{
+			name:     "choice",
+			args:     []string{"<#1>", "10:00", "style:reply"},
+			expected: map[string]any{"channel": "1", "time": "10:00", "style": "reply"},
+		},
+		{name: "missing required", args: []string{"<#123>"}, expectError: true},
+		{name: "bad channel", args: []string{"general", "09:00"}, expectError: true},
+		{name: "not a number", args: []string{"<#1>", "09:00", "many"}, expectError: true},
+		{name: "above max", args: []string{"<#1>", "09:00", "400"}, expectError: true},
+		{name: "below min", args: []string{"<#1>", "09:00", "-1"}, expectError: true},
+		{name: "invalid choice", args: []string{"<#1>", "09:00", "style:loud"}, expectError: true},
+		{name: "invalid bool", args: []string{"<#1>", "09:00", "enabled:maybe"}, expectError: true},
+		{name: "too many arguments", args: []string{"<#1>", "09:00", "1", "plain", "true", "extra"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := bindText(testOptions(), tt.args)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error but got %v", values)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(values) != len(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, values)
+			}
+			for name, expected := range tt.expected {
+				if values[name] != expected {
+					t.Errorf("Expected %s=%v, got %v", name, expected, values[name])
+				}
+			}
+		})
+	}
+}
+
+// TestBindText_MaxLength tests the string length limit.
+func TestBindText_MaxLength(t *testing.T) {
+	options := []*Option{{Name: "value", Type: discordgo.ApplicationCommandOptionString, MaxLength: 3}}
+
+	if _, err := bindText(options, []string{"abc"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err := bindText(options, []string{"abcd"}); err == nil {
+		t.Errorf("Expected error for a value over the limit")
+	}
+}
+
+// TestBindSlash tests that interaction options produce the same values as text arguments.
+func TestBindSlash(t *testing.T) {
+	values := bindSlash([]*discordgo.ApplicationCommandInteractionDataOption{
+		{Name: "channel", Type: discordgo.ApplicationCommandOptionChannel, Value: "123"},
+		{Name: "time", Type: discordgo.ApplicationCommandOptionString, Value: "09:00"},
+		{Name: "window", Type: discordgo.ApplicationCommandOptionInteger, Value: float64(3)},
+		{Name: "enabled", Type: discordgo.ApplicationCommandOptionBoolean, Value: true},
+	})
+
+	expected := map[string]any{"channel": "123", "time": "09:00", "window": int64(3), "enabled": true}
+	for name, value := range expected {
+		if values[name] != value {
+			t.Errorf("Expected %s=%v, got %v", name, value, values[name])
+		}
+	}
+}
+
+// TestContextAccessors tests typed option lookups and their defaults.
+func TestContextAccessors(t *testing.T) {
+	c := &Context{args: map[string]any{"name": "wooper", "count": int64(2), "on": true}}
+
+	if !c.Has("name") || c.Has("missing") {
+		t.Errorf("Unexpected Has results")
+	}
+	if c.String("name") != "wooper" || c.String("missing") != "" {
+		t.Errorf("Unexpected String results")
+	}
+	if c.Int("count", 7) != 2 || c.Int("missing", 7) != 7 {
+		t.Errorf("Unexpected Int results")
+	}
+	if !c.Bool("on") || c.Bool("missing") {
+		t.Errorf("Unexpected Bool results")
+	}
+}
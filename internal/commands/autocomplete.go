@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"wooper-bot/internal/logger"
+	"wooper-bot/internal/outbound"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// maxAutocompleteChoices is the most suggestions Discord accepts for an option.
+const maxAutocompleteChoices = 25
+
+// AutocompleteFunc suggests values for an option from what the user has typed so far. c
+// carries the guild's settings, with the categories the member may not use as
+// Settings.DeniedCategories; it has no Responder.
+type AutocompleteFunc func(c *Context, value string) []*discordgo.ApplicationCommandOptionChoice
+
+// HandleAutocomplete answers an autocomplete request for one of our commands' options.
+// Suggestions skip middleware: they are answered while the user types and run no command.
+func (r *Router) HandleAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	choices, ok := r.Autocomplete(i)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), interactionTimeout)
+	defer cancel()
+
+	response := &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{Choices: choices},
+	}
+	err := r.dispatcher.Do(ctx, outbound.InteractionRoute(i.Interaction), func(options ...discordgo.RequestOption) error {
+		return s.InteractionRespond(i.Interaction, response, options...)
+	})
+	if err != nil {
+		logger.Logger.Warn("Failed to send autocomplete choices",
+			zap.String("command", i.ApplicationCommandData().Name),
+			zap.Error(err))
+	}
+}
+
+// Autocomplete returns the suggestions for the focused option of i, and false when the
+// option isn't one of ours or has no autocompletion. Members get no suggestions for commands
+// the permission rules given to SetPermissionRules don't let them run.
+func (r *Router) Autocomplete(i *discordgo.InteractionCreate) ([]*discordgo.ApplicationCommandOptionChoice, bool) {
+	data := i.ApplicationCommandData()
+	cmd, ok := r.commands[data.Name]
+	if !ok {
+		return nil, false
+	}
+	root := cmd
+	options := data.Options
+	if len(cmd.Subcommands) > 0 && len(options) > 0 && options[0].Type == discordgo.ApplicationCommandOptionSubCommand {
+		if cmd = cmd.Subcommand(options[0].Name); cmd == nil {
+			return nil, false
+		}
+		options = options[0].Options
+	}
+
+	for _, focused := range options {
+		if !focused.Focused {
+			continue
+		}
+		for _, opt := range cmd.Options {
+			if opt.Name != focused.Name || opt.Autocomplete == nil {
+				continue
+			}
+			c := &Context{
+				Context:     context.Background(),
+				Root:        root,
+				Command:     cmd,
+				Transport:   TransportSlash,
+				Prefix:      "/",
+				GuildID:     i.GuildID,
+				ChannelID:   i.ChannelID,
+				User:        interactionUser(i.Interaction),
+				Member:      i.Member,
+				Settings:    r.Settings(i.GuildID),
+				Interaction: i,
+			}
+			var choices []*discordgo.ApplicationCommandOptionChoice
+			if r.allows(c) {
+				// Values are strings while being typed, even for number options
+				choices = opt.Autocomplete(c, fmt.Sprint(focused.Value))
+			}
+			if len(choices) > maxAutocompleteChoices {
+				choices = choices[:maxAutocompleteChoices]
+			}
+			if choices == nil {
+				// Discord expects a list, an empty one shows that nothing matches
+				choices = []*discordgo.ApplicationCommandOptionChoice{}
+			}
+			return choices, true
+		}
+	}
+	return nil, false
+}
+
+// allows applies the permission rules to an autocomplete request as RequireAccess does to
+// commands. Rules that can't be loaded allow nothing, the command would be refused anyway.
+func (r *Router) allows(c *Context) bool {
+	if r.rules == nil {
+		return true
+	}
+	allowed, err := access(c, r.rules)
+	if err != nil {
+		logger.Logger.Warn("Failed to load permission rules for autocompletion",
+			zap.String("command", c.Path()),
+			zap.String("guild_id", c.GuildID),
+			zap.Error(err))
+		return false
+	}
+	return allowed
+}
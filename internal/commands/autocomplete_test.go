@@ -0,0 +1,190 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"wooper-bot/internal/services"
+	"wooper-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// TestRouter_Autocomplete tests that the focused option's suggestions are returned, capped
+// at what Discord accepts.
+func TestRouter_Autocomplete(t *testing.T) {
+	r := setupTestRouter(t)
+
+	names := func(c *Context, value string) []*discordgo.ApplicationCommandOptionChoice {
+		var choices []*discordgo.ApplicationCommandOptionChoice
+		for i := 0; i < 30; i++ {
+			name := fmt.Sprintf("%s%02d", value, i)
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: name, Value: name})
+		}
+		return choices
+	}
+	none := func(c *Context, value string) []*discordgo.ApplicationCommandOptionChoice { return nil }
+	r.Register(&Command{
+		Name: "dex",
+		Options: []*Option{
+			{Name: "pokemon", Type: discordgo.ApplicationCommandOptionString, Autocomplete: names},
+			{Name: "form", Type: discordgo.ApplicationCommandOptionString, Autocomplete: none},
+			{Name: "shiny", Type: discordgo.ApplicationCommandOptionBoolean},
+		},
+	}, &Command{
+		Name: "schedule",
+		Subcommands: []*Command{{
+			Name:    "add",
+			Options: []*Option{{Name: "category", Type: discordgo.ApplicationCommandOptionString, Autocomplete: names}},
+		}},
+	})
+
+	focused := func(name, value string) *discordgo.ApplicationCommandInteractionDataOption {
+		return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionString, Value: value, Focused: true}
+	}
+	tests := []struct {
+		name     string
+		data     discordgo.ApplicationCommandInteractionData
+		expected int
+		first    string
+	}{
+		{
+			name: "focused option",
+			data: discordgo.ApplicationCommandInteractionData{Name: "dex", Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: "shiny", Type: discordgo.ApplicationCommandOptionBoolean, Value: true},
+				focused("pokemon", "woo"),
+			}},
+			expected: maxAutocompleteChoices,
+			first:    "woo00",
+		},
+		{
+			name: "subcommand option",
+			data: discordgo.ApplicationCommandInteractionData{Name: "schedule", Options: []*discordgo.ApplicationCommandInteractionDataOption{{
+				Name:    "add",
+				Type:    discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandInteractionDataOption{focused("category", "ca")},
+			}}},
+			expected: maxAutocompleteChoices,
+			first:    "ca00",
+		},
+		{
+			name: "no matches",
+			data: discordgo.ApplicationCommandInteractionData{Name: "dex", Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				focused("form", "x"),
+			}},
+		},
+		{
+			name:     "option without autocompletion",
+			data:     discordgo.ApplicationCommandInteractionData{Name: "dex", Options: []*discordgo.ApplicationCommandInteractionDataOption{focused("shiny", "")}},
+			expected: -1,
+		},
+		{
+			name:     "unknown command",
+			data:     discordgo.ApplicationCommandInteractionData{Name: "other", Options: []*discordgo.ApplicationCommandInteractionDataOption{focused("pokemon", "")}},
+			expected: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			choices, ok := r.Autocomplete(autocompleteInteraction("g1", nil, tt.data))
+			if tt.expected < 0 {
+				if ok {
+					t.Errorf("Expected no suggestions, got %v", choices)
+				}
+				return
+			}
+			if !ok || choices == nil || len(choices) != tt.expected {
+				t.Fatalf("Expected %d suggestions, got %v (%v)", tt.expected, len(choices), ok)
+			}
+			if tt.first != "" && choices[0].Name != tt.first {
+				t.Errorf("Expected %q first, got %q", tt.first, choices[0].Name)
+			}
+		})
+	}
+}
+
+// TestRouter_AutocompleteRules tests that suggestions follow the permission rules: none for
+// a command the member may not run, and the categories they may not use are denied.
+func TestRouter_AutocompleteRules(t *testing.T) {
+	r := setupTestRouter(t)
+	r.SetPermissionRules(func(ctx context.Context, guildID string) (services.PermissionRules, error) {
+		if guildID == "broken" {
+			return nil, errors.New("database is locked")
+		}
+		return services.PermissionRules{
+			{GuildID: "g1", Target: storage.PermissionCommand, Name: "image", RoleID: "members"},
+			{GuildID: "g1", Target: storage.PermissionCategory, Name: "cats", RoleID: "mods"},
+		}, nil
+	})
+	var denied []string
+	r.Register(&Command{
+		Name: "image",
+		Options: []*Option{{Name: "category", Type: discordgo.ApplicationCommandOptionString, Autocomplete: func(c *Context, value string) []*discordgo.ApplicationCommandOptionChoice {
+			denied = c.Settings.DeniedCategories
+			return []*discordgo.ApplicationCommandOptionChoice{{Name: "wooper", Value: "wooper"}}
+		}}},
+	})
+
+	data := discordgo.ApplicationCommandInteractionData{Name: "image", Options: []*discordgo.ApplicationCommandInteractionDataOption{
+		{Name: "category", Type: discordgo.ApplicationCommandOptionString, Value: "", Focused: true},
+	}}
+	tests := []struct {
+		name     string
+		guildID  string
+		member   *discordgo.Member
+		expected int
+		denied   []string
+	}{
+		{name: "allowed", guildID: "g1", member: &discordgo.Member{Roles: []string{"members"}}, expected: 1, denied: []string{"cats"}},
+		{name: "restricted command", guildID: "g1", member: &discordgo.Member{}},
+		{name: "server manager", guildID: "g1", member: &discordgo.Member{Permissions: discordgo.PermissionManageServer}, expected: 1},
+		{name: "rules unavailable", guildID: "broken", member: &discordgo.Member{Roles: []string{"members"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			denied = nil
+			choices, ok := r.Autocomplete(autocompleteInteraction(tt.guildID, tt.member, data))
+			if !ok || choices == nil || len(choices) != tt.expected {
+				t.Fatalf("Expected %d suggestions, got %v (%v)", tt.expected, len(choices), ok)
+			}
+			if !slices.Equal(denied, tt.denied) {
+				t.Errorf("Expected denied categories %v, got %v", tt.denied, denied)
+			}
+		})
+	}
+}
+
+// autocompleteInteraction returns an autocomplete request for data from member in guildID.
+func autocompleteInteraction(guildID string, member *discordgo.Member, data discordgo.ApplicationCommandInteractionData) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:      discordgo.InteractionApplicationCommandAutocomplete,
+		GuildID:   guildID,
+		ChannelID: "c1",
+		Member:    member,
+		Data:      data,
+	}}
+}
+
+// TestApplicationCommand_Autocomplete tests that options with suggestions are registered as autocompleted.
+func TestApplicationCommand_Autocomplete(t *testing.T) {
+	cmd := (&Command{
+		Name: "dex",
+		Options: []*Option{
+			{Name: "pokemon", Type: discordgo.ApplicationCommandOptionString, Autocomplete: func(*Context, string) []*discordgo.ApplicationCommandOptionChoice { return nil }},
+			{Name: "shiny", Type: discordgo.ApplicationCommandOptionBoolean},
+		},
+	}).ApplicationCommand()
+
+	var got []string
+	for _, opt := range cmd.Options {
+		got = append(got, fmt.Sprintf("%s=%v", opt.Name, opt.Autocomplete))
+	}
+	if strings.Join(got, " ") != "pokemon=true shiny=false" {
+		t.Errorf("Unexpected autocompletion flags: %v", got)
+	}
+}
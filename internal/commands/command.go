@@ -0,0 +1,175 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"wooper-bot/internal/i18n"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// HandlerFunc runs a command. Returned errors are logged and answered from the error catalog;
+// handlers report expected failures to the user themselves.
+type HandlerFunc func(c *Context) error
+
+// Option is a command argument. Text commands take options positionally or as name:value.
+type Option struct {
+	Name         string
+	Description  string
+	Type         discordgo.ApplicationCommandOptionType
+	Required     bool
+	Choices      []*discordgo.ApplicationCommandOptionChoice
+	MinValue     *float64
+	MaxValue     float64
+	MaxLength    int
+	ChannelTypes []discordgo.ChannelType
+	// Autocomplete suggests values while a slash command is typed. Text commands take any value.
+	Autocomplete AutocompleteFunc
+}
+
+// Command is declared once and exposed both as a slash command and as a prefix command.
+type Command struct {
+	Name        string
+	Description string
+	Options     []*Option
+	Subcommands []*Command
+
+	// Permissions are the member permissions required to run the command, 0 for everyone.
+	// They become default_member_permissions and are also checked for text commands.
+	Permissions int64
+	// GuildOnly commands are rejected in direct messages and only exist for server installs.
+	GuildOnly bool
+	// RateLimits are token buckets the command must get a token from in every scope.
+	RateLimits []RateLimit
+	// Parent is, for components, the command whose messages carry them. Permission rules
+	// restricting that command also restrict its buttons.
+	Parent string
+
+	Handler HandlerFunc
+}
+
+// Subcommand returns the named subcommand, or nil.
+func (c *Command) Subcommand(name string) *Command {
+	for _, sub := range c.Subcommands {
+		if sub.Name == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+// ApplicationCommand converts the command to its slash command registration.
+func (c *Command) ApplicationCommand() *discordgo.ApplicationCommand {
+	key := "command." + c.Name
+	cmd := &discordgo.ApplicationCommand{
+		Name:                     c.Name,
+		Description:              c.Description,
+		NameLocalizations:        localizations(key + ".name"),
+		DescriptionLocalizations: localizations(key + ".description"),
+	}
+	if c.Permissions != 0 {
+		permissions := c.Permissions
+		cmd.DefaultMemberPermissions = &permissions
+	}
+
+	// Public commands also work in DMs and when the app is installed by a user rather than a server
+	contexts := []discordgo.InteractionContextType{
+		discordgo.InteractionContextGuild,
+		discordgo.InteractionContextBotDM,
+		discordgo.InteractionContextPrivateChannel,
+	}
+	integrationTypes := []discordgo.ApplicationIntegrationType{
+		discordgo.ApplicationIntegrationGuildInstall,
+		discordgo.ApplicationIntegrationUserInstall,
+	}
+	if c.GuildOnly || c.Permissions != 0 {
+		contexts = contexts[:1]
+		integrationTypes = integrationTypes[:1]
+	}
+	cmd.Contexts = &contexts
+	cmd.IntegrationTypes = &integrationTypes
+
+	for _, sub := range c.Subcommands {
+		subKey := key + "." + sub.Name
+		cmd.Options = append(cmd.Options, &discordgo.ApplicationCommandOption{
+			Type:                     discordgo.ApplicationCommandOptionSubCommand,
+			Name:                     sub.Name,
+			NameLocalizations:        i18n.Localizations(subKey + ".name"),
+			Description:              sub.Description,
+			DescriptionLocalizations: i18n.Localizations(subKey + ".description"),
+			Options:                  applicationOptions(subKey, sub.Options),
+		})
+	}
+	cmd.Options = append(cmd.Options, applicationOptions(key, c.Options)...)
+	return cmd
+}
+
+// applicationOptions converts options of the command with the given catalog key. Translations
+// live under "<key>.<option>.description", and "<key>.<option>.<choice name>" for choices.
+func applicationOptions(key string, options []*Option) []*discordgo.ApplicationCommandOption {
+	var converted []*discordgo.ApplicationCommandOption
+	for _, opt := range options {
+		optKey := key + "." + opt.Name
+		choices := opt.Choices
+		if len(choices) > 0 {
+			choices = make([]*discordgo.ApplicationCommandOptionChoice, len(opt.Choices))
+			for i, choice := range opt.Choices {
+				localized := *choice
+				localized.NameLocalizations = i18n.Localizations(optKey + "." + choice.Name)
+				choices[i] = &localized
+			}
+		}
+
+		converted = append(converted, &discordgo.ApplicationCommandOption{
+			Type:                     opt.Type,
+			Name:                     opt.Name,
+			NameLocalizations:        i18n.Localizations(optKey + ".name"),
+			Description:              opt.Description,
+			DescriptionLocalizations: i18n.Localizations(optKey + ".description"),
+			Required:                 opt.Required,
+			Choices:                  choices,
+			MinValue:                 opt.MinValue,
+			MaxValue:                 opt.MaxValue,
+			MaxLength:                opt.MaxLength,
+			ChannelTypes:             opt.ChannelTypes,
+			Autocomplete:             opt.Autocomplete != nil,
+		})
+	}
+	return converted
+}
+
+// localizations returns the translations of a catalog key, or nil when there are none.
+func localizations(key string) *map[discordgo.Locale]string {
+	localized := i18n.Localizations(key)
+	if localized == nil {
+		return nil
+	}
+	return &localized
+}
+
+// Usage renders the text command syntax, e.g. "!schedule add <cron> <category> <channel> [timezone]".
+// parent is the name of the enclosing command for subcommands, empty otherwise.
+func (c *Command) Usage(prefix, parent string) string {
+	name := c.Name
+	if parent != "" {
+		name = parent + " " + c.Name
+	}
+	parts := []string{prefix + name}
+
+	if len(c.Subcommands) > 0 {
+		var names []string
+		for _, sub := range c.Subcommands {
+			names = append(names, sub.Name)
+		}
+		parts = append(parts, fmt.Sprintf("<%s>", strings.Join(names, "|")))
+	}
+	for _, opt := range c.Options {
+		if opt.Required {
+			parts = append(parts, "<"+opt.Name+">")
+		} else {
+			parts = append(parts, "["+opt.Name+"]")
+		}
+	}
+	return strings.Join(parts, " ")
+}
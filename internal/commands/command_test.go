@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func testCommand() *Command {
+	return &Command{
+		Name:        "schedule",
+		Description: "Manage scheduled posts",
+		Permissions: discordgo.PermissionManageServer,
+		GuildOnly:   true,
+		Subcommands: []*Command{
+			{
+				Name:        "add",
+				Description: "Add a post",
+				Options: []*Option{
+					{Name: "cron", Description: "Cron expression", Type: discordgo.ApplicationCommandOptionString, Required: true},
+					{Name: "timezone", Description: "Time zone", Type: discordgo.ApplicationCommandOptionString},
+				},
+			},
+			{Name: "list", Description: "List posts"},
+		},
+	}
+}
+
+// TestCommand_ApplicationCommand tests the slash command registration built from a command.
+func TestCommand_ApplicationCommand(t *testing.T) {
+	cmd := testCommand().ApplicationCommand()
+
+	if cmd.Name != "schedule" || cmd.Description != "Manage scheduled posts" {
+		t.Errorf("Unexpected name or description: %s, %s", cmd.Name, cmd.Description)
+	}
+	if cmd.DefaultMemberPermissions == nil || *cmd.DefaultMemberPermissions != discordgo.PermissionManageServer {
+		t.Errorf("Expected Manage Server default permissions, got %v", cmd.DefaultMemberPermissions)
+	}
+	if cmd.Contexts == nil || len(*cmd.Contexts) != 1 || (*cmd.Contexts)[0] != discordgo.InteractionContextGuild {
+		t.Errorf("Expected a guild-only command, got contexts %v", cmd.Contexts)
+	}
+	if cmd.IntegrationTypes == nil || len(*cmd.IntegrationTypes) != 1 || (*cmd.IntegrationTypes)[0] != discordgo.ApplicationIntegrationGuildInstall {
+		t.Errorf("Expected a server install only command, got %v", cmd.IntegrationTypes)
+	}
+	if len(cmd.Options) != 2 || cmd.Options[0].Type != discordgo.ApplicationCommandOptionSubCommand {
+		t.Fatalf("Expected 2 subcommands, got %+v", cmd.Options)
+	}
+	add := cmd.Options[0]
+	if len(add.Options) != 2 || add.Options[0].Name != "cron" || !add.Options[0].Required {
+		t.Errorf("Expected add options to be converted, got %+v", add.Options)
+	}
+
+	plain := (&Command{Name: "image", Description: "Image"}).ApplicationCommand()
+	if plain.DefaultMemberPermissions != nil {
+		t.Errorf("Expected no default permissions on a public command")
+	}
+	if plain.Contexts == nil || len(*plain.Contexts) != 3 {
+		t.Errorf("Expected a public command in guilds, DMs and group DMs, got %v", plain.Contexts)
+	}
+	if plain.IntegrationTypes == nil || len(*plain.IntegrationTypes) != 2 {
+		t.Errorf("Expected a public command to be user installable, got %v", plain.IntegrationTypes)
+	}
+}
+
+// TestCommand_Usage tests the text syntax rendering.
+func TestCommand_Usage(t *testing.T) {
+	cmd := testCommand()
+
+	if got := cmd.Usage("!", ""); got != "!schedule <add|list>" {
+		t.Errorf("Unexpected usage %q", got)
+	}
+	if got := cmd.Subcommand("add").Usage("w!", "schedule"); got != "w!schedule add <cron> [timezone]" {
+		t.Errorf("Unexpected usage %q", got)
+	}
+	if cmd.Subcommand("missing") != nil {
+		t.Errorf("Expected no subcommand")
+	}
+}
@@ -0,0 +1,85 @@
+package commands
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"wooper-bot/internal/logger"
+	"wooper-bot/internal/services"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// customIDSeparator separates the component name from its arguments in a custom ID.
+const customIDSeparator = ":"
+
+// maxCustomIDLength is the longest custom ID Discord accepts.
+const maxCustomIDLength = 100
+
+// CustomID builds the custom ID of a button handled by the component named name. Arguments are
+// bound to the component's options positionally, like text command arguments; they must not
+// contain the separator, so pass IDs rather than user input.
+func CustomID(name string, args ...string) string {
+	return strings.Join(append([]string{name}, args...), customIDSeparator)
+}
+
+// RegisterComponent adds handlers for message components like buttons. A component is declared
+// as a Command whose name is the prefix of the custom IDs it handles; its permissions, rate
+// limits and middleware apply as for commands, but it is never registered as a slash command.
+func (r *Router) RegisterComponent(components ...*Command) {
+	for _, component := range components {
+		r.components[component.Name] = component
+	}
+}
+
+// HandleComponent runs the component handler for a button press, if it is one of ours.
+func (r *Router) HandleComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.MessageComponentData()
+	if len(data.CustomID) > maxCustomIDLength {
+		return
+	}
+	parts := strings.Split(data.CustomID, customIDSeparator)
+	component, ok := r.components[parts[0]]
+	if !ok {
+		return
+	}
+
+	user := interactionUser(i.Interaction)
+	if user == nil {
+		return
+	}
+
+	values, err := bindText(component.Options, parts[1:])
+	if err != nil {
+		// Custom IDs are ours, so this is a button from an older version of the bot
+		logger.Logger.Warn("Invalid component custom ID",
+			zap.String("custom_id", data.CustomID),
+			zap.String("user_id", user.ID),
+			zap.Error(err))
+		return
+	}
+
+	settings := r.Settings(i.GuildID)
+	c := &Context{
+		Responder:   &InteractionResponder{Session: s, Dispatcher: r.dispatcher, Interaction: i.Interaction},
+		Context:     context.Background(),
+		Session:     s,
+		Root:        component,
+		Command:     component,
+		Transport:   TransportComponent,
+		Prefix:      "/",
+		GuildID:     i.GuildID,
+		ChannelID:   i.ChannelID,
+		User:        user,
+		Member:      i.Member,
+		Settings:    settings,
+		Interaction: i,
+		args:        values,
+
+		CorrelationID: services.NewCorrelationID(),
+		Received:      time.Now(),
+	}
+	r.dispatch(c)
+}
@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// TestRouter_HandleComponent tests that button custom IDs reach their component with bound arguments.
+func TestRouter_HandleComponent(t *testing.T) {
+	r := setupTestRouter(t)
+
+	var got *Context
+	r.RegisterComponent(&Command{
+		Name:    "another",
+		Options: []*Option{{Name: "image", Type: discordgo.ApplicationCommandOptionString, Required: true}},
+		Handler: func(c *Context) error {
+			got = c
+			return nil
+		},
+	})
+
+	tests := []struct {
+		name          string
+		customID      string
+		expectedImage string
+	}{
+		{name: "bound argument", customID: CustomID("another", "a1b2c3"), expectedImage: "a1b2c3"},
+		{name: "unknown component", customID: CustomID("other", "a1b2c3")},
+		{name: "missing argument", customID: CustomID("another")},
+		{name: "too long", customID: CustomID("another", strings.Repeat("a", 100))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = nil
+			r.HandleComponent(nil, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+				Type:      discordgo.InteractionMessageComponent,
+				GuildID:   "g1",
+				ChannelID: "c1",
+				Member:    &discordgo.Member{User: &discordgo.User{ID: "u1"}},
+				Data:      discordgo.MessageComponentInteractionData{CustomID: tt.customID, ComponentType: discordgo.ButtonComponent},
+			}})
+
+			if tt.expectedImage == "" {
+				if got != nil {
+					t.Errorf("Expected no component to run for %q", tt.customID)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("Expected the component to run for %q", tt.customID)
+			}
+			if got.String("image") != tt.expectedImage || got.Transport != TransportComponent || got.User.ID != "u1" {
+				t.Errorf("Unexpected context: image %q, transport %s, user %v", got.String("image"), got.Transport, got.User)
+			}
+		})
+	}
+}
+
+// TestRouter_ComponentsAreNotCommands tests that components are left out of slash command registrations.
+func TestRouter_ComponentsAreNotCommands(t *testing.T) {
+	r := setupTestRouter(t)
+	r.RegisterComponent(&Command{Name: "another"})
+
+	if len(r.ApplicationCommands()) != 0 || len(r.Commands()) != 0 {
+		t.Errorf("Expected no commands, got %v", r.Commands())
+	}
+	if cmd, _ := r.Resolve(Invocation{Name: "another"}); cmd != nil {
+		t.Errorf("Expected components not to resolve as text commands")
+	}
+}
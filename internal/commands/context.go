@@ -0,0 +1,216 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"wooper-bot/internal/i18n"
+	"wooper-bot/internal/services"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// Transport is how a command reached the bot.
+type Transport string
+
+const (
+	TransportText      Transport = "text"
+	TransportSlash     Transport = "slash"
+	TransportComponent Transport = "component" // a button on a message the bot sent
+)
+
+// Context is everything a command handler needs, independent of the transport.
+type Context struct {
+	Responder
+
+	Context   context.Context
+	Session   *discordgo.Session
+	Root      *Command // the top-level command, the same as Command unless a subcommand runs
+	Command   *Command // the command or subcommand being run
+	Transport Transport
+	Prefix    string // prefix used to invoke a text command, "/" for slash commands
+
+	GuildID   string // empty in direct messages
+	ChannelID string
+	User      *discordgo.User
+	Member    *discordgo.Member // nil in direct messages
+
+	Settings services.GuildSettings
+
+	Message     *discordgo.MessageCreate     // set for text commands
+	Interaction *discordgo.InteractionCreate // set for slash commands and components
+
+	// CorrelationID is logged with the command and shown in error replies, so a user
+	// reporting an error can be matched with its log lines.
+	CorrelationID string
+	// Received is when the bot got the message or interaction, to measure response latencies.
+	Received time.Time
+
+	args      map[string]any
+	logFields []zap.Field
+}
+
+// Path returns the full command name, e.g. "schedule add".
+func (c *Context) Path() string {
+	if c.Root == nil || c.Root == c.Command {
+		return c.Command.Name
+	}
+	return c.Root.Name + " " + c.Command.Name
+}
+
+// eventFields identifies the message or interaction that invoked the command.
+func (c *Context) eventFields() []zap.Field {
+	fields := []zap.Field{
+		zap.String("correlation_id", c.CorrelationID),
+		zap.String("guild_id", c.GuildID),
+		zap.String("channel_id", c.ChannelID),
+	}
+	if c.User != nil {
+		fields = append(fields, zap.String("user_id", c.User.ID))
+	}
+	if c.Message != nil {
+		fields = append(fields, zap.String("message_id", c.Message.ID))
+	}
+	if c.Interaction != nil && c.Interaction.Interaction != nil {
+		fields = append(fields, zap.String("interaction_id", c.Interaction.ID))
+	}
+	return fields
+}
+
+// Locale is the language to answer in: the server's language setting when it forces one,
+// else the user's Discord language for slash commands and the server's for text commands.
+// It is empty when unknown.
+func (c *Context) Locale() string {
+	if c.Settings.Language != "" {
+		return c.Settings.Language
+	}
+	if c.Interaction != nil && c.Interaction.Interaction != nil {
+		if c.Interaction.Locale != "" {
+			return string(c.Interaction.Locale)
+		}
+		if c.Interaction.GuildLocale != nil {
+			return string(*c.Interaction.GuildLocale)
+		}
+	}
+	if c.GuildID != "" && c.Session != nil && c.Session.State != nil {
+		if guild, err := c.Session.State.Guild(c.GuildID); err == nil {
+			return guild.PreferredLocale
+		}
+	}
+	return ""
+}
+
+// T returns the message for key in the language of the command, see i18n.T.
+func (c *Context) T(key string, args ...any) string {
+	return i18n.T(c.Locale(), key, args...)
+}
+
+// Fail answers the command with the catalog message for err and its correlation ID.
+// The details of err are never shown.
+func (c *Context) Fail(err error) error {
+	return c.Error(services.UserMessage(err, c.Locale(), c.CorrelationID))
+}
+
+// LogFields attaches fields to the log line written when the command completes.
+func (c *Context) LogFields(fields ...zap.Field) {
+	c.logFields = append(c.logFields, fields...)
+}
+
+// Has reports whether an option was given.
+func (c *Context) Has(name string) bool {
+	_, ok := c.args[name]
+	return ok
+}
+
+// String returns a string, channel, user or role option, or "" when absent.
+func (c *Context) String(name string) string {
+	v, _ := c.args[name].(string)
+	return v
+}
+
+// Int returns an integer option, or def when absent.
+func (c *Context) Int(name string, def int64) int64 {
+	if v, ok := c.args[name].(int64); ok {
+		return v
+	}
+	return def
+}
+
+// Bool returns a boolean option, or false when absent.
+func (c *Context) Bool(name string) bool {
+	v, _ := c.args[name].(bool)
+	return v
+}
+
+// Reply sends a plain text reply.
+func (c *Context) Reply(content string) error {
+	return c.Respond(&Response{Content: content})
+}
+
+// updateDeferrer is implemented by responders that can acknowledge a button press and update
+// its message later.
+type updateDeferrer interface {
+	DeferUpdate() error
+}
+
+// DeferUpdate acknowledges a button press that needs time before its Update response.
+// It does nothing for other transports.
+func (c *Context) DeferUpdate() error {
+	if d, ok := c.Responder.(updateDeferrer); ok && c.Transport == TransportComponent {
+		return d.DeferUpdate()
+	}
+	return nil
+}
+
+// ReplyLong sends a text reply that may be over MaxMessageLength, split into several messages.
+func (c *Context) ReplyLong(content string) error {
+	for _, part := range SplitMessage(content, MaxMessageLength) {
+		if err := c.Reply(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CanEmbed reports whether the bot may send embeds where the command was run. When the
+// permissions are unknown it assumes it can, since servers rarely take Embed Links away.
+func (c *Context) CanEmbed() bool {
+	if c.GuildID == "" {
+		return true
+	}
+	if c.Interaction != nil && c.Interaction.Interaction != nil {
+		return c.Interaction.AppPermissions == 0 || c.Interaction.AppPermissions&discordgo.PermissionEmbedLinks != 0
+	}
+	if c.Session == nil || c.Session.State == nil || c.Session.State.User == nil {
+		return true
+	}
+	permissions, err := c.Session.State.UserChannelPermissions(c.Session.State.User.ID, c.ChannelID)
+	if err != nil {
+		return true
+	}
+	return permissions&discordgo.PermissionEmbedLinks != 0
+}
+
+// ReplyEphemeral sends a text reply only the invoking user can see.
+func (c *Context) ReplyEphemeral(content string) error {
+	return c.Respond(&Response{Content: content, Ephemeral: true})
+}
+
+// React adds a reaction to the invoking message. Slash commands have no message to react to,
+// so it does nothing for them.
+func (c *Context) React(emoji string) error {
+	if c.Message == nil {
+		return nil
+	}
+	if err := c.Session.MessageReactionAdd(c.ChannelID, c.Message.ID, emoji); err != nil {
+		return fmt.Errorf("add reaction: %w", err)
+	}
+	return nil
+}
+
+// Error sends an error reply, ephemeral when the guild asks for it.
+func (c *Context) Error(content string) error {
+	return c.Respond(&Response{Content: content, Ephemeral: c.Settings.EphemeralErrors})
+}
@@ -0,0 +1,203 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"wooper-bot/internal/logger"
+	"wooper-bot/internal/metrics"
+	"wooper-bot/internal/services"
+	"wooper-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// Middleware wraps command execution, like HTTP middleware. It may run code before and
+// after next, or answer the command itself and not call next at all.
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Recover turns a panicking handler into an error so one bad command can't take down
+// the event goroutine. The router then answers with the internal error message.
+func Recover() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) (err error) {
+			defer func() {
+				if p := recover(); p != nil {
+					metrics.Inc(metrics.HandlerPanics)
+					logger.Logger.Error("Command panicked", append(c.eventFields(),
+						zap.String("command", c.Path()),
+						zap.Any("panic", p),
+						zap.Stack("stack"))...)
+					err = fmt.Errorf("panic: %v", p)
+				}
+			}()
+			return next(c)
+		}
+	}
+}
+
+// Logging logs every command with who ran it, and how it ended with how long it took.
+// Handlers add details to the completion line with Context.LogFields.
+func Logging() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			fields := []zap.Field{
+				zap.String("command", c.Path()),
+				zap.String("correlation_id", c.CorrelationID),
+				zap.String("transport", string(c.Transport)),
+				zap.String("user", c.User.Username),
+				zap.String("user_id", c.User.ID),
+				zap.String("channel_id", c.ChannelID),
+				zap.String("guild_id", c.GuildID),
+			}
+			logger.Logger.Info("Command received", fields...)
+
+			start := time.Now()
+			err := next(c)
+
+			fields = append(fields, c.logFields...)
+			fields = append(fields, zap.Duration("duration", time.Since(start)))
+			if err != nil {
+				logger.Logger.Error("Command failed", append(fields, zap.Error(err))...)
+				return err
+			}
+			logger.Logger.Info("Command completed", fields...)
+			return nil
+		}
+	}
+}
+
+// Timing warns about commands slower than threshold, e.g. uploads stuck on a slow connection.
+func Timing(threshold time.Duration) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			start := time.Now()
+			err := next(c)
+			if duration := time.Since(start); duration > threshold {
+				logger.Logger.Warn("Slow command",
+					zap.String("command", c.Path()),
+					zap.String("guild_id", c.GuildID),
+					zap.Duration("duration", duration),
+					zap.Duration("threshold", threshold))
+			}
+			return err
+		}
+	}
+}
+
+// RequirePermissions rejects guild-only commands in DMs and members missing the command's
+// permissions. default_member_permissions can be overridden by server admins and doesn't
+// apply to text commands, so the check is always repeated here.
+func RequirePermissions() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			root := c.Root
+			if root == nil {
+				root = c.Command
+			}
+
+			if (root.GuildOnly || root.Permissions != 0) && c.GuildID == "" {
+				return c.ReplyEphemeral(c.T("command.guild_only"))
+			}
+			if root.Permissions != 0 && !hasPermissions(c, root.Permissions) {
+				logger.Logger.Warn("Command denied, missing permissions",
+					zap.String("command", c.Path()),
+					zap.String("user_id", c.User.ID),
+					zap.String("guild_id", c.GuildID))
+				return c.ReplyEphemeral(c.T("command.missing_permission"))
+			}
+			return next(c)
+		}
+	}
+}
+
+// PermissionRulesFunc loads a guild's permission rules.
+type PermissionRulesFunc func(ctx context.Context, guildID string) (services.PermissionRules, error)
+
+// RequireAccess enforces the guild's permission rules. Restricted commands, and the buttons
+// on their messages, only run for the roles and in the channels their rules allow, and
+// categories the member may not use in the channel are set as Settings.DeniedCategories, so
+// handlers treat them as disabled. Members who can manage the server are never restricted,
+// so admins can't lock themselves out.
+func RequireAccess(rules PermissionRulesFunc) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			allowed, err := access(c, rules)
+			if err != nil {
+				// Unlike settings, restrictions can't fall back to defaults without opening everything
+				return services.NewError(services.ErrCodeStorage, err)
+			}
+			if !allowed {
+				logger.Logger.Info("Command denied by permission rules",
+					zap.String("command", c.Path()),
+					zap.String("user_id", c.User.ID),
+					zap.String("channel_id", c.ChannelID),
+					zap.String("guild_id", c.GuildID))
+				return c.ReplyEphemeral(c.T("command.restricted"))
+			}
+			return next(c)
+		}
+	}
+}
+
+// access loads the guild's permission rules and reports whether they let c's member run its
+// command in its channel, setting the categories they may not use as Settings.DeniedCategories.
+func access(c *Context, rules PermissionRulesFunc) (bool, error) {
+	if c.GuildID == "" {
+		return true, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	loaded, err := rules(ctx, c.GuildID)
+	cancel()
+	if err != nil {
+		return false, err
+	}
+	if len(loaded) == 0 || hasPermissions(c, discordgo.PermissionManageServer) {
+		return true, nil
+	}
+
+	var roles []string
+	if c.Member != nil {
+		roles = c.Member.Roles
+	}
+	name := c.Command.Name
+	if c.Root != nil {
+		name = c.Root.Name
+	}
+	if c.Command.Parent != "" {
+		name = c.Command.Parent
+	}
+	if !loaded.Allows(storage.PermissionCommand, name, roles, c.ChannelID) {
+		return false, nil
+	}
+	c.Settings.DeniedCategories = loaded.DeniedCategories(roles, c.ChannelID)
+	return true, nil
+}
+
+// hasPermissions checks the invoking member's permissions. Slash interactions carry them;
+// for text commands they are computed from the cached guild state.
+func hasPermissions(c *Context, required int64) bool {
+	var permissions int64
+	switch {
+	case c.Interaction != nil && c.Member != nil:
+		permissions = c.Member.Permissions
+	case c.Message != nil:
+		p, err := c.Session.State.MessagePermissions(c.Message.Message)
+		if err != nil {
+			logger.Logger.Warn("Failed to compute member permissions",
+				zap.String("user_id", c.User.ID),
+				zap.String("channel_id", c.ChannelID),
+				zap.Error(err))
+			return false
+		}
+		permissions = p
+	}
+
+	if permissions&discordgo.PermissionAdministrator != 0 {
+		return true
+	}
+	return permissions&required == required
+}
@@ -0,0 +1,193 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"wooper-bot/internal/services"
+	"wooper-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// TestRecover tests that a panicking handler becomes an error.
+func TestRecover(t *testing.T) {
+	setupTestRouter(t)
+
+	handler := Recover()(func(c *Context) error {
+		var m *discordgo.Member
+		_ = m.User // nil dereference
+		return nil
+	})
+
+	err := handler(testContext(&fakeResponder{}, &Command{Name: "image"}, "g1", 0))
+	if err == nil {
+		t.Errorf("Expected panic to be returned as an error")
+	}
+}
+
+// TestLogging tests that errors pass through and log fields are kept.
+func TestLogging(t *testing.T) {
+	setupTestRouter(t)
+
+	boom := errors.New("boom")
+	handler := Logging()(func(c *Context) error {
+		c.LogFields()
+		return boom
+	})
+	if err := handler(testContext(&fakeResponder{}, &Command{Name: "image"}, "g1", 0)); !errors.Is(err, boom) {
+		t.Errorf("Expected %v, got %v", boom, err)
+	}
+}
+
+// TestRequirePermissions tests guild-only and permission restrictions.
+func TestRequirePermissions(t *testing.T) {
+	setupTestRouter(t)
+
+	tests := []struct {
+		name            string
+		command         *Command
+		guildID         string
+		permissions     int64
+		expectRun       bool
+		expectedContent string
+	}{
+		{name: "public command", command: &Command{Name: "image"}, expectRun: true},
+		{name: "guild only in DM", command: &Command{Name: "daily", GuildOnly: true}, expectedContent: "This command can only be used in a server."},
+		{name: "permission in DM", command: &Command{Name: "config", Permissions: discordgo.PermissionManageServer}, expectedContent: "This command can only be used in a server."},
+		{name: "missing permission", command: &Command{Name: "config", Permissions: discordgo.PermissionManageServer}, guildID: "g1", permissions: discordgo.PermissionSendMessages, expectedContent: "You don't have permission to use this command."},
+		{name: "has permission", command: &Command{Name: "config", Permissions: discordgo.PermissionManageServer}, guildID: "g1", permissions: discordgo.PermissionManageServer, expectRun: true},
+		{name: "administrator", command: &Command{Name: "config", Permissions: discordgo.PermissionManageServer}, guildID: "g1", permissions: discordgo.PermissionAdministrator, expectRun: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := false
+			handler := RequirePermissions()(func(c *Context) error {
+				ran = true
+				return nil
+			})
+
+			responder := &fakeResponder{}
+			if err := handler(testContext(responder, tt.command, tt.guildID, tt.permissions)); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if ran != tt.expectRun {
+				t.Errorf("Expected handler run %t, got %t", tt.expectRun, ran)
+			}
+			if tt.expectedContent == "" {
+				if len(responder.responses) != 0 {
+					t.Errorf("Expected no response, got %q", responder.responses[0].Content)
+				}
+				return
+			}
+			if len(responder.responses) != 1 || responder.responses[0].Content != tt.expectedContent {
+				t.Errorf("Expected response %q, got %+v", tt.expectedContent, responder.responses)
+			}
+		})
+	}
+}
+
+// TestRequirePermissions_Subcommand tests that subcommands use their parent's restrictions.
+func TestRequirePermissions_Subcommand(t *testing.T) {
+	setupTestRouter(t)
+
+	sub := &Command{Name: "add"}
+	root := &Command{Name: "schedule", Permissions: discordgo.PermissionManageServer, Subcommands: []*Command{sub}}
+
+	ran := false
+	handler := RequirePermissions()(func(c *Context) error {
+		ran = true
+		return nil
+	})
+
+	c := testContext(&fakeResponder{}, sub, "g1", 0)
+	c.Root = root
+	_ = handler(c)
+	if ran {
+		t.Errorf("Expected subcommand to be denied")
+	}
+}
+
+// TestRequireAccess tests that permission rules restrict commands and deny categories,
+// except for server managers.
+func TestRequireAccess(t *testing.T) {
+	setupTestRouter(t)
+
+	rules := services.PermissionRules{
+		{GuildID: "g1", Target: storage.PermissionCommand, Name: "image", RoleID: "members", ChannelID: "memes"},
+		{GuildID: "g1", Target: storage.PermissionCategory, Name: "cats", RoleID: "mods"},
+	}
+	load := func(ctx context.Context, guildID string) (services.PermissionRules, error) {
+		if guildID == "broken" {
+			return nil, errors.New("database is locked")
+		}
+		if guildID != "g1" {
+			return nil, nil
+		}
+		return rules, nil
+	}
+
+	tests := []struct {
+		name            string
+		command         string
+		parent          string
+		guildID         string
+		channelID       string
+		roles           []string
+		permissions     int64
+		expectRun       bool
+		expectedDenied  []string
+		expectedContent string
+	}{
+		{name: "allowed role and channel", command: "image", guildID: "g1", channelID: "memes", roles: []string{"members"}, expectRun: true, expectedDenied: []string{"cats"}},
+		{name: "wrong channel", command: "image", guildID: "g1", channelID: "general", roles: []string{"members"}, expectedContent: "You can't use this command here."},
+		{name: "missing role", command: "image", guildID: "g1", channelID: "memes", expectedContent: "You can't use this command here."},
+		{name: "button of a restricted command", command: "another", parent: "image", guildID: "g1", channelID: "memes", expectedContent: "You can't use this command here."},
+		{name: "button allowed like its command", command: "another", parent: "image", guildID: "g1", channelID: "memes", roles: []string{"members"}, expectRun: true, expectedDenied: []string{"cats"}},
+		{name: "unrestricted command", command: "dex", guildID: "g1", channelID: "general", roles: []string{"mods"}, expectRun: true},
+		{name: "server manager", command: "image", guildID: "g1", channelID: "general", permissions: discordgo.PermissionManageServer, expectRun: true},
+		{name: "guild without rules", command: "image", guildID: "g2", channelID: "general", expectRun: true},
+		{name: "direct message", command: "image", channelID: "dm", expectRun: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *Context
+			handler := RequireAccess(load)(func(c *Context) error {
+				got = c
+				return nil
+			})
+
+			responder := &fakeResponder{}
+			c := testContext(responder, &Command{Name: tt.command, Parent: tt.parent}, tt.guildID, tt.permissions)
+			c.ChannelID = tt.channelID
+			c.Member.Roles = tt.roles
+			if err := handler(c); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if (got != nil) != tt.expectRun {
+				t.Fatalf("Expected handler run %t, got %t", tt.expectRun, got != nil)
+			}
+			if got != nil && !reflect.DeepEqual(got.Settings.DeniedCategories, tt.expectedDenied) {
+				t.Errorf("Expected denied categories %v, got %v", tt.expectedDenied, got.Settings.DeniedCategories)
+			}
+			if tt.expectedContent != "" && (len(responder.responses) != 1 || responder.responses[0].Content != tt.expectedContent) {
+				t.Errorf("Expected response %q, got %+v", tt.expectedContent, responder.responses)
+			}
+		})
+	}
+
+	// Rules that can't be loaded don't open restricted commands
+	handler := RequireAccess(load)(func(c *Context) error {
+		t.Error("Expected the command not to run")
+		return nil
+	})
+	if err := handler(testContext(&fakeResponder{}, &Command{Name: "image"}, "broken", 0)); services.CodeOf(err) != services.ErrCodeStorage {
+		t.Errorf("Expected a storage error, got %v", err)
+	}
+}
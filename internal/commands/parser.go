@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"sort"
+	"strings"
+)
+
+// Invocation is a text command parsed from a message.
+type Invocation struct {
+	Prefix string   // the prefix or bot mention that matched
+	Name   string   // lower-cased command name, empty for a bare prefix
+	Args   []string // remaining arguments with quotes removed
+}
+
+// Parse resolves a message against a guild's prefixes and mentions of the bot
+// ("@wooper-bot wooper"). It reports false when the message isn't addressed to the bot.
+func Parse(content string, prefixes []string, botID string) (Invocation, bool) {
+	content = strings.TrimSpace(content)
+
+	matched, ok := matchMention(content, botID)
+	if !ok {
+		matched, ok = matchPrefix(content, prefixes)
+	}
+	if !ok {
+		return Invocation{}, false
+	}
+
+	inv := Invocation{Prefix: matched}
+	fields := splitArgs(content[len(matched):])
+	if len(fields) > 0 {
+		inv.Name = strings.ToLower(fields[0])
+		inv.Args = fields[1:]
+	}
+	return inv, true
+}
+
+func matchMention(content, botID string) (string, bool) {
+	if botID == "" {
+		return "", false
+	}
+	for _, mention := range []string{"<@" + botID + ">", "<@!" + botID + ">"} {
+		if strings.HasPrefix(content, mention) {
+			return mention, true
+		}
+	}
+	return "", false
+}
+
+// matchPrefix returns the longest matching prefix so "w!" wins over "w" when both are configured.
+func matchPrefix(content string, prefixes []string) (string, bool) {
+	sorted := append([]string(nil), prefixes...)
+	sort.Slice(sorted, func(a, b int) bool { return len(sorted[a]) > len(sorted[b]) })
+
+	for _, prefix := range sorted {
+		if prefix != "" && strings.HasPrefix(content, prefix) {
+			return prefix, true
+		}
+	}
+	return "", false
+}
+
+// splitArgs splits on whitespace while keeping double-quoted sections together,
+// so `cron:"0 */6 * * *" wooper` yields [cron:0 */6 * * * wooper].
+func splitArgs(s string) []string {
+	var (
+		args    []string
+		current strings.Builder
+		quoted  bool
+		started bool
+	)
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			started = true
+		case !quoted && (r == ' ' || r == '\t' || r == '\n'):
+			if started {
+				args = append(args, current.String())
+				current.Reset()
+				started = false
+			}
+		default:
+			current.WriteRune(r)
+			started = true
+		}
+	}
+	if started {
+		args = append(args, current.String())
+	}
+	return args
+}
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParse tests prefix and mention resolution.
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name           string
+		content        string
+		prefixes       []string
+		expectMatch    bool
+		expectedPrefix string
+		expectedName   string
+		expectedArgs   []string
+	}{
+		{name: "default prefix", content: "!wooper", prefixes: []string{"!"}, expectMatch: true, expectedPrefix: "!", expectedName: "wooper"},
+		{name: "command is lower-cased", content: "!WOOPER", prefixes: []string{"!"}, expectMatch: true, expectedPrefix: "!", expectedName: "wooper"},
+		{name: "space after prefix", content: "! wooper", prefixes: []string{"!"}, expectMatch: true, expectedPrefix: "!", expectedName: "wooper"},
+		{name: "arguments", content: "!image cats", prefixes: []string{"!"}, expectMatch: true, expectedPrefix: "!", expectedName: "image", expectedArgs: []string{"cats"}},
+		{name: "second prefix", content: "?help", prefixes: []string{"!", "?"}, expectMatch: true, expectedPrefix: "?", expectedName: "help"},
+		{name: "longest prefix wins", content: "w!cats", prefixes: []string{"w", "w!"}, expectMatch: true, expectedPrefix: "w!", expectedName: "cats"},
+		{name: "bare prefix", content: "!", prefixes: []string{"!"}, expectMatch: true, expectedPrefix: "!"},
+		{name: "mention", content: "<@42> wooper", prefixes: []string{"!"}, expectMatch: true, expectedPrefix: "<@42>", expectedName: "wooper"},
+		{name: "nickname mention", content: "<@!42> help", prefixes: []string{"!"}, expectMatch: true, expectedPrefix: "<@!42>", expectedName: "help"},
+		{name: "bare mention", content: "<@42>", prefixes: []string{"!"}, expectMatch: true, expectedPrefix: "<@42>"},
+		{name: "mention of someone else", content: "<@7> wooper", prefixes: []string{"!"}},
+		{name: "no prefix", content: "wooper", prefixes: []string{"!"}},
+		{name: "mention in the middle", content: "hi <@42>", prefixes: []string{"!"}},
+		{name: "no prefixes configured", content: "!wooper"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inv, ok := Parse(tt.content, tt.prefixes, "42")
+			if ok != tt.expectMatch {
+				t.Fatalf("Expected match %t, got %t", tt.expectMatch, ok)
+			}
+			if !ok {
+				return
+			}
+			if inv.Prefix != tt.expectedPrefix {
+				t.Errorf("Expected prefix %q, got %q", tt.expectedPrefix, inv.Prefix)
+			}
+			if inv.Name != tt.expectedName {
+				t.Errorf("Expected name %q, got %q", tt.expectedName, inv.Name)
+			}
+			if strings.Join(inv.Args, "|") != strings.Join(tt.expectedArgs, "|") {
+				t.Errorf("Expected args %q, got %q", tt.expectedArgs, inv.Args)
+			}
+		})
+	}
+}
+
+// TestSplitArgs tests whitespace splitting with quoted sections.
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{input: "", expected: nil},
+		{input: "  a  b\tc ", expected: []string{"a", "b", "c"}},
+		{input: `add "0 */6 * * *" wooper`, expected: []string{"add", "0 */6 * * *", "wooper"}},
+		{input: `cron:"@daily" x`, expected: []string{"cron:@daily", "x"}},
+		{input: `""`, expected: []string{""}},
+		{input: `"unterminated quote`, expected: []string{"unterminated quote"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := splitArgs(tt.input)
+			if strings.Join(got, "|") != strings.Join(tt.expected, "|") || len(got) != len(tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package commands
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"wooper-bot/internal/logger"
+
+	"go.uber.org/zap"
+)
+
+// Scope is what a rate limit is counted per.
+type Scope string
+
+const (
+	ScopeUser    Scope = "user"
+	ScopeChannel Scope = "channel"
+	ScopeGuild   Scope = "guild"
+)
+
+// RateLimit is a token bucket: Burst uses are allowed at once, and one more becomes
+// available every Every.
+type RateLimit struct {
+	Scope Scope
+	Burst int
+	Every time.Duration
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Limiter tracks token buckets for every command, scope and key.
+type Limiter struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	buckets map[string]*bucket
+}
+
+func NewLimiter() *Limiter {
+	return &Limiter{now: time.Now, buckets: make(map[string]*bucket)}
+}
+
+// maxBuckets bounds memory; beyond it idle buckets are dropped.
+const maxBuckets = 10000
+
+// Allow takes a token from every bucket, or from none when one of them is empty.
+// keys holds one key per limit. It returns how long to wait when denied.
+func (l *Limiter) Allow(keys []string, limits []RateLimit) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	var wait time.Duration
+	for i, limit := range limits {
+		b := l.refill(keys[i], limit, now)
+		if b.tokens < 1 {
+			missing := time.Duration((1 - b.tokens) * float64(limit.Every))
+			wait = max(wait, missing)
+		}
+	}
+	if wait > 0 {
+		return false, wait
+	}
+
+	for i := range limits {
+		l.buckets[keys[i]].tokens--
+	}
+	if len(l.buckets) > maxBuckets {
+		l.prune(now, limits)
+	}
+	return true, 0
+}
+
+// refill returns the bucket for key with the tokens earned since its last use.
+func (l *Limiter) refill(key string, limit RateLimit, now time.Time) *bucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		l.buckets[key] = b
+		return b
+	}
+	if limit.Every > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+float64(now.Sub(b.updated))/float64(limit.Every))
+	}
+	b.updated = now
+	return b
+}
+
+// prune drops buckets untouched for longer than it takes any of them to refill completely.
+func (l *Limiter) prune(now time.Time, limits []RateLimit) {
+	var longest time.Duration
+	for _, limit := range limits {
+		longest = max(longest, time.Duration(limit.Burst)*limit.Every)
+	}
+	longest = max(longest, time.Hour)
+
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) > longest {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// RateLimits enforces each command's RateLimits. Slash commands get an ephemeral
+// "slow down" reply; text commands get reaction on the message, or are dropped silently
+// when reaction is empty, so a spammer can't make the bot flood the channel itself.
+func RateLimits(limiter *Limiter, reaction string) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c *Context) error {
+			root := c.Root
+			if root == nil {
+				root = c.Command
+			}
+			if len(root.RateLimits) == 0 {
+				return next(c)
+			}
+
+			keys := make([]string, len(root.RateLimits))
+			for i, limit := range root.RateLimits {
+				keys[i] = c.Path() + "/" + string(limit.Scope) + ":" + c.scopeKey(limit.Scope)
+			}
+
+			ok, wait := limiter.Allow(keys, root.RateLimits)
+			if ok {
+				return next(c)
+			}
+
+			logger.Logger.Info("Command rate limited",
+				zap.String("command", c.Path()),
+				zap.String("user_id", c.User.ID),
+				zap.String("channel_id", c.ChannelID),
+				zap.String("guild_id", c.GuildID),
+				zap.Duration("retry_after", wait))
+
+			if c.Transport == TransportText {
+				if reaction == "" {
+					return nil
+				}
+				return c.React(reaction)
+			}
+			return c.ReplyEphemeral(c.T("command.rate_limited", int(math.Ceil(wait.Seconds()))))
+		}
+	}
+}
+
+// scopeKey returns the ID a scope is counted per. DMs count per channel for the guild scope.
+func (c *Context) scopeKey(scope Scope) string {
+	switch scope {
+	case ScopeChannel:
+		return c.ChannelID
+	case ScopeGuild:
+		if c.GuildID == "" {
+			return "dm:" + c.ChannelID
+		}
+		return c.GuildID
+	}
+	return c.User.ID
+}
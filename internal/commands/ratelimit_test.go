@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func setupTestLimiter() (*Limiter, *time.Time) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := NewLimiter()
+	limiter.now = func() time.Time { return now }
+	return limiter, &now
+}
+
+// TestLimiter_Allow tests bursts and refills of a single bucket.
+func TestLimiter_Allow(t *testing.T) {
+	limiter, now := setupTestLimiter()
+	limits := []RateLimit{{Scope: ScopeUser, Burst: 2, Every: 2 * time.Second}}
+	keys := []string{"image/user:u1"}
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := limiter.Allow(keys, limits); !ok {
+			t.Fatalf("Expected use %d of the burst to be allowed", i+1)
+		}
+	}
+
+	ok, wait := limiter.Allow(keys, limits)
+	if ok {
+		t.Fatalf("Expected the empty bucket to deny")
+	}
+	if wait != 2*time.Second {
+		t.Errorf("Expected to wait 2s, got %v", wait)
+	}
+
+	*now = now.Add(time.Second)
+	if _, wait := limiter.Allow(keys, limits); wait != time.Second {
+		t.Errorf("Expected to wait 1s after half a refill, got %v", wait)
+	}
+
+	*now = now.Add(time.Second)
+	if ok, _ := limiter.Allow(keys, limits); !ok {
+		t.Errorf("Expected a refilled token to be allowed")
+	}
+
+	// Long idle periods never refill past the burst
+	*now = now.Add(time.Hour)
+	for i := 0; i < 2; i++ {
+		limiter.Allow(keys, limits)
+	}
+	if ok, _ := limiter.Allow(keys, limits); ok {
+		t.Errorf("Expected the bucket to be capped at its burst")
+	}
+}
+
+// TestLimiter_AllowAllScopes tests that a denied scope doesn't consume tokens of the others.
+func TestLimiter_AllowAllScopes(t *testing.T) {
+	limiter, _ := setupTestLimiter()
+	limits := []RateLimit{
+		{Scope: ScopeUser, Burst: 2, Every: time.Second},
+		{Scope: ScopeChannel, Burst: 1, Every: time.Minute},
+	}
+
+	if ok, _ := limiter.Allow([]string{"u1", "c1"}, limits); !ok {
+		t.Fatalf("Expected first use to be allowed")
+	}
+	if ok, _ := limiter.Allow([]string{"u1", "c1"}, limits); ok {
+		t.Fatalf("Expected the channel limit to deny")
+	}
+	// u1 still has a token because the denied attempt took none
+	if ok, _ := limiter.Allow([]string{"u1", "c2"}, limits); !ok {
+		t.Errorf("Expected the user token to be left after a denied attempt")
+	}
+}
+
+// TestRateLimits tests the middleware replies for each transport.
+func TestRateLimits(t *testing.T) {
+	setupTestRouter(t)
+	limiter, _ := setupTestLimiter()
+
+	runs := 0
+	handler := RateLimits(limiter, "")(func(c *Context) error {
+		runs++
+		return nil
+	})
+	cmd := &Command{Name: "image", RateLimits: []RateLimit{{Scope: ScopeUser, Burst: 1, Every: 3 * time.Second}}}
+
+	slash := &fakeResponder{}
+	_ = handler(testContext(slash, cmd, "g1", 0))
+	_ = handler(testContext(slash, cmd, "g1", 0))
+	if runs != 1 {
+		t.Errorf("Expected 1 run, got %d", runs)
+	}
+	if len(slash.responses) != 1 || slash.responses[0].Content != "Slow down! Try again in 3 seconds." || !slash.responses[0].Ephemeral {
+		t.Errorf("Expected an ephemeral slow down reply, got %+v", slash.responses)
+	}
+
+	// Text commands are dropped without a reply
+	text := &fakeResponder{}
+	c := testContext(text, cmd, "g1", 0)
+	c.Transport = TransportText
+	c.Interaction = nil
+	c.Message = &discordgo.MessageCreate{Message: &discordgo.Message{ID: "m1"}}
+	_ = handler(c)
+	if runs != 1 || len(text.responses) != 0 {
+		t.Errorf("Expected a silent drop, got %d runs and %+v", runs, text.responses)
+	}
+
+	// Other users and commands without limits are unaffected
+	other := testContext(slash, cmd, "g1", 0)
+	other.User = &discordgo.User{ID: "u2"}
+	_ = handler(other)
+	_ = handler(testContext(slash, &Command{Name: "help"}, "g1", 0))
+	if runs != 3 {
+		t.Errorf("Expected 3 runs, got %d", runs)
+	}
+}
+
+// TestScopeKey tests which ID each scope counts per.
+func TestScopeKey(t *testing.T) {
+	c := &Context{GuildID: "g1", ChannelID: "c1", User: &discordgo.User{ID: "u1"}}
+	if c.scopeKey(ScopeUser) != "u1" || c.scopeKey(ScopeChannel) != "c1" || c.scopeKey(ScopeGuild) != "g1" {
+		t.Errorf("Unexpected scope keys")
+	}
+
+	dm := &Context{ChannelID: "d1", User: &discordgo.User{ID: "u1"}}
+	if dm.scopeKey(ScopeGuild) != "dm:d1" {
+		t.Errorf("Expected DMs to count per channel, got %q", dm.scopeKey(ScopeGuild))
+	}
+}
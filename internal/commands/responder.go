@@ -0,0 +1,243 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"wooper-bot/internal/outbound"
+	"wooper-bot/internal/services"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// MaxMessageLength is the most characters Discord accepts in a message's content.
+const MaxMessageLength = 2000
+
+const (
+	// interactionTimeout bounds the first answer to an interaction, which Discord only accepts for 3 seconds.
+	interactionTimeout = 3 * time.Second
+	// sendTimeout bounds messages and follow-ups, including time spent queued and retrying.
+	sendTimeout = 30 * time.Second
+)
+
+// Response is a transport-agnostic command reply.
+type Response struct {
+	Content    string
+	Files      []*discordgo.File
+	Embeds     []*discordgo.MessageEmbed
+	Components []discordgo.MessageComponent
+	// Ephemeral replies are only shown to the invoking user. Text commands can't hide
+	// messages, so they ignore it.
+	Ephemeral bool
+	// Update replaces the message whose button was pressed instead of sending a new one,
+	// attachments included. Only components have such a message; other transports ignore it.
+	Update bool
+
+	// Sent is set by Respond to the message the reply created or updated, when Discord
+	// returns it: text replies, follow-ups and edits. First interaction answers leave it nil.
+	Sent *discordgo.Message
+}
+
+// Responder sends command replies over the transport the command came from.
+type Responder interface {
+	// Defer acknowledges a command that needs time before its first reply.
+	Defer(ephemeral bool) error
+	// Respond sends a reply. It may be called several times.
+	Respond(r *Response) error
+}
+
+// MessageResponder replies to text commands in the channel they were sent in.
+type MessageResponder struct {
+	Session    *discordgo.Session
+	Dispatcher *outbound.Dispatcher
+	Message    *discordgo.MessageCreate
+	Settings   services.GuildSettings
+}
+
+// Defer shows the typing indicator while the command works.
+func (m *MessageResponder) Defer(ephemeral bool) error {
+	return m.Session.ChannelTyping(m.Message.ChannelID)
+}
+
+func (m *MessageResponder) Respond(r *Response) error {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	data := m.message(r)
+	err := m.Dispatcher.Do(ctx, outbound.ChannelRoute(m.Message.ChannelID), outbound.Resendable(data.Files, func(options ...discordgo.RequestOption) error {
+		message, err := m.Session.ChannelMessageSendComplex(m.Message.ChannelID, data, options...)
+		r.Sent = message
+		return err
+	}))
+	if err != nil {
+		return fmt.Errorf("send message: %w", err)
+	}
+	return nil
+}
+
+// message builds the channel message, replying to the invoking message when the guild asks for it.
+func (m *MessageResponder) message(r *Response) *discordgo.MessageSend {
+	data := &discordgo.MessageSend{
+		Content:    r.Content,
+		Files:      r.Files,
+		Embeds:     r.Embeds,
+		Components: r.Components,
+	}
+	if m.Settings.ReplyStyle == services.ReplyStyleReply {
+		data.Reference = m.Message.Reference()
+	}
+	return data
+}
+
+// InteractionResponder replies to slash commands, switching to follow-ups once the
+// interaction has been answered or deferred.
+type InteractionResponder struct {
+	Session     *discordgo.Session
+	Dispatcher  *outbound.Dispatcher
+	Interaction *discordgo.Interaction
+
+	acknowledged bool
+}
+
+func (ir *InteractionResponder) Defer(ephemeral bool) error {
+	if ir.acknowledged {
+		return nil
+	}
+	err := ir.respond(&discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Flags: ephemeralFlag(ephemeral)},
+	})
+	if err != nil {
+		return fmt.Errorf("defer interaction: %w", err)
+	}
+	ir.acknowledged = true
+	return nil
+}
+
+// DeferUpdate acknowledges a button press whose message is updated later.
+func (ir *InteractionResponder) DeferUpdate() error {
+	if ir.acknowledged {
+		return nil
+	}
+	err := ir.respond(&discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredMessageUpdate})
+	if err != nil {
+		return fmt.Errorf("defer interaction: %w", err)
+	}
+	ir.acknowledged = true
+	return nil
+}
+
+func (ir *InteractionResponder) Respond(r *Response) error {
+	update := r.Update && ir.Interaction.Type == discordgo.InteractionMessageComponent
+	// Attachments left out of an update are removed, the new files replace them
+	var attachments *[]*discordgo.MessageAttachment
+	if update {
+		attachments = &[]*discordgo.MessageAttachment{}
+	}
+
+	if !ir.acknowledged {
+		responseType := discordgo.InteractionResponseChannelMessageWithSource
+		if update {
+			responseType = discordgo.InteractionResponseUpdateMessage
+		}
+		err := ir.respond(&discordgo.InteractionResponse{
+			Type: responseType,
+			Data: &discordgo.InteractionResponseData{
+				Content:     r.Content,
+				Files:       r.Files,
+				Embeds:      r.Embeds,
+				Components:  r.Components,
+				Attachments: attachments,
+				Flags:       ephemeralFlag(r.Ephemeral),
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("respond to interaction: %w", err)
+		}
+		ir.acknowledged = true
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	if update {
+		edit := &discordgo.WebhookEdit{
+			Content:     &r.Content,
+			Files:       r.Files,
+			Embeds:      &r.Embeds,
+			Components:  &r.Components,
+			Attachments: attachments,
+		}
+		err := ir.Dispatcher.Do(ctx, outbound.InteractionRoute(ir.Interaction), outbound.Resendable(edit.Files, func(options ...discordgo.RequestOption) error {
+			message, err := ir.Session.InteractionResponseEdit(ir.Interaction, edit, options...)
+			r.Sent = message
+			return err
+		}))
+		if err != nil {
+			return fmt.Errorf("edit message: %w", err)
+		}
+		return nil
+	}
+
+	params := &discordgo.WebhookParams{
+		Content:    r.Content,
+		Files:      r.Files,
+		Embeds:     r.Embeds,
+		Components: r.Components,
+		Flags:      ephemeralFlag(r.Ephemeral),
+	}
+	err := ir.Dispatcher.Do(ctx, outbound.InteractionRoute(ir.Interaction), outbound.Resendable(params.Files, func(options ...discordgo.RequestOption) error {
+		message, err := ir.Session.FollowupMessageCreate(ir.Interaction, true, params, options...)
+		r.Sent = message
+		return err
+	}))
+	if err != nil {
+		return fmt.Errorf("send follow-up: %w", err)
+	}
+	return nil
+}
+
+// respond sends the first answer to the interaction.
+func (ir *InteractionResponder) respond(resp *discordgo.InteractionResponse) error {
+	ctx, cancel := context.WithTimeout(context.Background(), interactionTimeout)
+	defer cancel()
+
+	var files []*discordgo.File
+	if resp.Data != nil {
+		files = resp.Data.Files
+	}
+	return ir.Dispatcher.Do(ctx, outbound.InteractionRoute(ir.Interaction), outbound.Resendable(files, func(options ...discordgo.RequestOption) error {
+		return ir.Session.InteractionRespond(ir.Interaction, resp, options...)
+	}))
+}
+
+// SplitMessage cuts content into messages of at most limit characters, at line breaks when
+// possible so lists stay readable.
+func SplitMessage(content string, limit int) []string {
+	var parts []string
+	for utf8.RuneCountInString(content) > limit {
+		cut := len(string([]rune(content)[:limit]))
+		if i := strings.LastIndexByte(content[:cut], '\n'); i > 0 {
+			parts = append(parts, content[:i])
+			content = content[i+1:]
+			continue
+		}
+		parts = append(parts, content[:cut])
+		content = content[cut:]
+	}
+	if content != "" || len(parts) == 0 {
+		parts = append(parts, content)
+	}
+	return parts
+}
+
+func ephemeralFlag(ephemeral bool) discordgo.MessageFlags {
+	if ephemeral {
+		return discordgo.MessageFlagsEphemeral
+	}
+	return 0
+}
@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"wooper-bot/internal/services"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// TestMessageResponder_ReplyStyle tests that responses only reference the invoking message in reply mode.
+func TestMessageResponder_ReplyStyle(t *testing.T) {
+	m := &discordgo.MessageCreate{Message: &discordgo.Message{ID: "m1", ChannelID: "c1", GuildID: "g1"}}
+
+	plain := (&MessageResponder{Message: m, Settings: services.GuildSettings{ReplyStyle: services.ReplyStylePlain}}).message(&Response{Content: "hi"})
+	if plain.Reference != nil {
+		t.Errorf("Expected no reference in plain mode, got %+v", plain.Reference)
+	}
+	if plain.Content != "hi" {
+		t.Errorf("Expected content hi, got %q", plain.Content)
+	}
+
+	reply := (&MessageResponder{Message: m, Settings: services.GuildSettings{ReplyStyle: services.ReplyStyleReply}}).message(&Response{})
+	if reply.Reference == nil || reply.Reference.MessageID != "m1" {
+		t.Errorf("Expected reference to m1, got %+v", reply.Reference)
+	}
+}
+
+// TestEphemeralFlag tests the interaction flags used for ephemeral replies.
+func TestEphemeralFlag(t *testing.T) {
+	if ephemeralFlag(true) != discordgo.MessageFlagsEphemeral {
+		t.Errorf("Expected ephemeral flag")
+	}
+	if ephemeralFlag(false) != 0 {
+		t.Errorf("Expected no flags")
+	}
+}
+
+// TestSplitMessage tests cutting long content at line breaks.
+func TestSplitMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		limit    int
+		expected []string
+	}{
+		{name: "short", content: "hello", limit: 10, expected: []string{"hello"}},
+		{name: "empty", content: "", limit: 10, expected: []string{""}},
+		{name: "at line breaks", content: "one\ntwo\nthree", limit: 8, expected: []string{"one\ntwo", "three"}},
+		{name: "long line", content: "abcdefghij", limit: 4, expected: []string{"abcd", "efgh", "ij"}},
+		{name: "multibyte", content: "ééééé", limit: 2, expected: []string{"éé", "éé", "é"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitMessage(tt.content, tt.limit)
+			if strings.Join(got, "|") != strings.Join(tt.expected, "|") || len(got) != len(tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestContext_CanEmbed tests the embed permission check of slash commands.
+func TestContext_CanEmbed(t *testing.T) {
+	tests := []struct {
+		name        string
+		guildID     string
+		permissions int64
+		expected    bool
+	}{
+		{name: "direct message", expected: true},
+		{name: "permissions unknown", guildID: "g1", expected: true},
+		{name: "embed links", guildID: "g1", permissions: discordgo.PermissionEmbedLinks | discordgo.PermissionSendMessages, expected: true},
+		{name: "no embed links", guildID: "g1", permissions: discordgo.PermissionSendMessages, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Context{GuildID: tt.guildID, Interaction: &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+				AppPermissions: tt.permissions,
+			}}}
+			if got := c.CanEmbed(); got != tt.expected {
+				t.Errorf("Expected %t, got %t", tt.expected, got)
+			}
+		})
+	}
+}
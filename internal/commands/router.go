@@ -0,0 +1,269 @@
+package commands
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"wooper-bot/internal/logger"
+	"wooper-bot/internal/metrics"
+	"wooper-bot/internal/outbound"
+	"wooper-bot/internal/services"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// alias is a text-only shortcut, e.g. "!wooper" for "!image wooper".
+type alias struct {
+	command *Command
+	args    []string
+}
+
+// Router dispatches text and slash invocations to the registered commands.
+type Router struct {
+	settings   *services.SettingsService
+	dispatcher *outbound.Dispatcher
+	commands   map[string]*Command
+	components map[string]*Command
+	aliases    map[string]alias
+	order      []*Command
+
+	middleware []Middleware
+	rules      PermissionRulesFunc
+}
+
+func NewRouter(settings *services.SettingsService, dispatcher *outbound.Dispatcher) *Router {
+	return &Router{
+		settings:   settings,
+		dispatcher: dispatcher,
+		commands:   make(map[string]*Command),
+		components: make(map[string]*Command),
+		aliases:    make(map[string]alias),
+	}
+}
+
+// SetPermissionRules makes autocompletion follow the permission rules, which RequireAccess
+// enforces on commands: suggestions skip middleware.
+func (r *Router) SetPermissionRules(rules PermissionRulesFunc) {
+	r.rules = rules
+}
+
+// Register adds commands to the router. Later registrations replace earlier ones of the same name.
+func (r *Router) Register(commands ...*Command) {
+	for _, cmd := range commands {
+		if _, exists := r.commands[cmd.Name]; !exists {
+			r.order = append(r.order, cmd)
+		}
+		r.commands[cmd.Name] = cmd
+	}
+}
+
+// Use appends middleware to the chain every command runs through. The first middleware is the outermost.
+func (r *Router) Use(middleware ...Middleware) {
+	r.middleware = append(r.middleware, middleware...)
+}
+
+// Alias adds a text shortcut that runs cmd with the given leading arguments.
+// An empty name matches a bare prefix or mention.
+func (r *Router) Alias(name string, cmd *Command, args ...string) {
+	r.aliases[strings.ToLower(name)] = alias{command: cmd, args: args}
+}
+
+// Commands returns the registered commands in registration order.
+func (r *Router) Commands() []*Command {
+	return r.order
+}
+
+// ApplicationCommands returns the slash command registrations for every command.
+func (r *Router) ApplicationCommands() []*discordgo.ApplicationCommand {
+	var cmds []*discordgo.ApplicationCommand
+	for _, cmd := range r.order {
+		cmds = append(cmds, cmd.ApplicationCommand())
+	}
+	return cmds
+}
+
+// Settings loads a guild's settings, falling back to the defaults when they can't be read
+// so a database hiccup never stops the bot from answering.
+func (r *Router) Settings(guildID string) services.GuildSettings {
+	if r.settings == nil {
+		return services.DefaultGuildSettings()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	settings, err := r.settings.Get(ctx, guildID)
+	if err != nil {
+		logger.Logger.Warn("Failed to load guild settings, using defaults",
+			zap.String("guild_id", guildID),
+			zap.Error(err))
+	}
+	return settings
+}
+
+// HandleMessage runs the text command in m, if any, and reports whether it was one.
+func (r *Router) HandleMessage(s *discordgo.Session, m *discordgo.MessageCreate) bool {
+	settings := r.Settings(m.GuildID)
+
+	var botID string
+	if s.State != nil && s.State.User != nil {
+		botID = s.State.User.ID
+	}
+
+	inv, ok := Parse(m.Content, settings.Prefixes, botID)
+	if !ok {
+		return false
+	}
+
+	cmd, args := r.Resolve(inv)
+	if cmd == nil {
+		logger.Logger.Info("Unknown command received",
+			zap.String("command", inv.Name),
+			zap.String("user", m.Author.Username),
+			zap.String("user_id", m.Author.ID))
+		return false
+	}
+
+	c := r.messageContext(s, m, settings, inv.Prefix, cmd)
+	parent := ""
+	if len(cmd.Subcommands) > 0 {
+		var sub *Command
+		if len(args) > 0 {
+			sub = cmd.Subcommand(strings.ToLower(args[0]))
+		}
+		if sub == nil {
+			_ = c.Error(c.T("command.usage", cmd.Usage(inv.Prefix, "")))
+			return true
+		}
+		parent, c.Command, args = cmd.Name, sub, args[1:]
+	}
+
+	values, err := bindText(c.Command.Options, args)
+	if err != nil {
+		_ = c.Error(c.T("command.invalid_arguments", capitalize(argumentError(err, c.Locale())), c.Command.Usage(inv.Prefix, parent)))
+		return true
+	}
+	c.args = values
+
+	r.dispatch(c)
+	return true
+}
+
+// messageContext builds the context of a text command answering m.
+func (r *Router) messageContext(s *discordgo.Session, m *discordgo.MessageCreate, settings services.GuildSettings, prefix string, cmd *Command) *Context {
+	return &Context{
+		Responder: &MessageResponder{Session: s, Dispatcher: r.dispatcher, Message: m, Settings: settings},
+		Context:   context.Background(),
+		Session:   s,
+		Root:      cmd,
+		Command:   cmd,
+		Transport: TransportText,
+		Prefix:    prefix,
+		GuildID:   m.GuildID,
+		ChannelID: m.ChannelID,
+		User:      m.Author,
+		Member:    m.Member,
+		Settings:  settings,
+		Message:   m,
+
+		CorrelationID: services.NewCorrelationID(),
+		Received:      time.Now(),
+	}
+}
+
+// Resolve finds the command for a text invocation, following aliases.
+// It returns the arguments the command should be bound with, or a nil command.
+func (r *Router) Resolve(inv Invocation) (*Command, []string) {
+	if cmd, ok := r.commands[inv.Name]; ok && inv.Name != "" {
+		return cmd, inv.Args
+	}
+	if a, ok := r.aliases[inv.Name]; ok {
+		return a.command, append(append([]string(nil), a.args...), inv.Args...)
+	}
+	return nil, nil
+}
+
+// HandleInteraction runs the slash command in i, if it is one of ours.
+func (r *Router) HandleInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	cmd, ok := r.commands[data.Name]
+	if !ok {
+		return
+	}
+
+	user := interactionUser(i.Interaction)
+	if user == nil {
+		return
+	}
+
+	settings := r.Settings(i.GuildID)
+	c := &Context{
+		Responder:   &InteractionResponder{Session: s, Dispatcher: r.dispatcher, Interaction: i.Interaction},
+		Context:     context.Background(),
+		Session:     s,
+		Root:        cmd,
+		Command:     cmd,
+		Transport:   TransportSlash,
+		Prefix:      "/",
+		GuildID:     i.GuildID,
+		ChannelID:   i.ChannelID,
+		User:        user,
+		Member:      i.Member,
+		Settings:    settings,
+		Interaction: i,
+
+		CorrelationID: services.NewCorrelationID(),
+		Received:      time.Now(),
+	}
+
+	options := data.Options
+	if len(cmd.Subcommands) > 0 && len(options) > 0 && options[0].Type == discordgo.ApplicationCommandOptionSubCommand {
+		sub := cmd.Subcommand(options[0].Name)
+		if sub == nil {
+			return
+		}
+		c.Command, options = sub, options[0].Options
+	}
+	c.args = bindSlash(options)
+
+	r.dispatch(c)
+}
+
+// interactionUser returns the invoking user. Member is only set in guilds; DMs, group DMs
+// and user-installed contexts outside the guilds the bot is in carry User instead.
+func interactionUser(i *discordgo.Interaction) *discordgo.User {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// dispatch runs the command's handler through the middleware chain.
+func (r *Router) dispatch(c *Context) {
+	if c.Command.Handler == nil {
+		return
+	}
+
+	handler := c.Command.Handler
+	for i := len(r.middleware) - 1; i >= 0; i-- {
+		handler = r.middleware[i](handler)
+	}
+
+	if err := handler(c); err != nil {
+		// Middleware has logged the failure; the user only gets the catalog message
+		metrics.Inc(metrics.CommandErrors)
+		_ = c.Fail(err)
+	}
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
@@ -0,0 +1,208 @@
+package commands
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"wooper-bot/internal/logger"
+	"wooper-bot/internal/services"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// fakeResponder records responses instead of sending them.
+type fakeResponder struct {
+	deferred  bool
+	responses []*Response
+}
+
+func (f *fakeResponder) Defer(ephemeral bool) error {
+	f.deferred = true
+	return nil
+}
+
+func (f *fakeResponder) Respond(r *Response) error {
+	f.responses = append(f.responses, r)
+	return nil
+}
+
+func setupTestRouter(t *testing.T) *Router {
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+	t.Cleanup(logger.Close)
+
+	return NewRouter(nil, nil)
+}
+
+// TestRouter_RegisterAndResolve tests command lookup, aliases and registration order.
+func TestRouter_RegisterAndResolve(t *testing.T) {
+	r := setupTestRouter(t)
+
+	image := &Command{Name: "image", Description: "Image"}
+	help := &Command{Name: "help", Description: "Help"}
+	r.Register(image, help)
+	r.Alias("wooper", image, "wooper")
+	r.Alias("", image)
+
+	if len(r.Commands()) != 2 || r.Commands()[0] != image {
+		t.Errorf("Expected commands in registration order")
+	}
+	if len(r.ApplicationCommands()) != 2 {
+		t.Errorf("Expected 2 application commands")
+	}
+
+	// Replacing a command keeps its position
+	replacement := &Command{Name: "image", Description: "New image"}
+	r.Register(replacement)
+	if len(r.Commands()) != 2 {
+		t.Errorf("Expected replacement not to add a command")
+	}
+
+	cmd, args := r.Resolve(Invocation{Name: "wooper", Args: []string{"extra"}})
+	if cmd != image || len(args) != 2 || args[0] != "wooper" || args[1] != "extra" {
+		t.Errorf("Expected alias to prepend its arguments, got %v %v", cmd, args)
+	}
+	if cmd, _ := r.Resolve(Invocation{}); cmd != image {
+		t.Errorf("Expected bare prefix alias to resolve")
+	}
+	if cmd, _ := r.Resolve(Invocation{Name: "help"}); cmd != help {
+		t.Errorf("Expected help to resolve")
+	}
+	if cmd, _ := r.Resolve(Invocation{Name: "unknown"}); cmd != nil {
+		t.Errorf("Expected unknown command not to resolve")
+	}
+}
+
+// TestRouter_Dispatch tests the middleware order and the generic reply to failed commands.
+func TestRouter_Dispatch(t *testing.T) {
+	r := setupTestRouter(t)
+
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next HandlerFunc) HandlerFunc {
+			return func(c *Context) error {
+				calls = append(calls, name+" before")
+				err := next(c)
+				calls = append(calls, name+" after")
+				return err
+			}
+		}
+	}
+	r.Use(trace("outer"), trace("inner"))
+
+	cmd := &Command{Name: "image", Handler: func(c *Context) error {
+		calls = append(calls, "handler")
+		return errors.New("boom")
+	}}
+	responder := &fakeResponder{}
+	c := testContext(responder, cmd, "g1", 0)
+	c.CorrelationID = "abc123"
+	r.dispatch(c)
+
+	expected := "outer before,inner before,handler,inner after,outer after"
+	if got := strings.Join(calls, ","); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+	if len(responder.responses) != 1 || responder.responses[0].Content != "Something went wrong, please try again. (error ID: `abc123`)" {
+		t.Errorf("Expected generic error reply, got %+v", responder.responses)
+	}
+}
+
+// TestRouter_DispatchCatalogError tests that failures are answered from the error catalog,
+// in the user's language and without their details.
+func TestRouter_DispatchCatalogError(t *testing.T) {
+	r := setupTestRouter(t)
+
+	cmd := &Command{Name: "image", Handler: func(c *Context) error {
+		return services.NewError(services.ErrCodeImageLoad, errors.New("open /srv/img/wooper/x.jpg: permission denied"))
+	}}
+	responder := &fakeResponder{}
+	c := testContext(responder, cmd, "g1", 0)
+	c.CorrelationID = "abc123"
+	c.Interaction = &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{Locale: discordgo.French}}
+	r.dispatch(c)
+
+	expected := "Impossible de charger cette image, veuillez réessayer. (ID d'erreur : `abc123`)"
+	if len(responder.responses) != 1 || responder.responses[0].Content != expected {
+		t.Errorf("Expected %q, got %+v", expected, responder.responses)
+	}
+}
+
+func testContext(responder Responder, cmd *Command, guildID string, permissions int64) *Context {
+	return &Context{
+		Responder:   responder,
+		Root:        cmd,
+		Command:     cmd,
+		Transport:   TransportSlash,
+		GuildID:     guildID,
+		User:        &discordgo.User{ID: "u1", Username: "user"},
+		Member:      &discordgo.Member{Permissions: permissions},
+		Interaction: &discordgo.InteractionCreate{},
+	}
+}
+
+// TestInteractionUser tests resolving the invoking user in guilds, DMs and user-installed contexts.
+func TestInteractionUser(t *testing.T) {
+	member := &discordgo.User{ID: "member"}
+	user := &discordgo.User{ID: "user"}
+
+	tests := []struct {
+		name        string
+		interaction *discordgo.Interaction
+		expected    *discordgo.User
+	}{
+		{name: "guild", interaction: &discordgo.Interaction{Member: &discordgo.Member{User: member}}, expected: member},
+		{name: "direct message", interaction: &discordgo.Interaction{User: user}, expected: user},
+		{name: "member without user", interaction: &discordgo.Interaction{Member: &discordgo.Member{}, User: user}, expected: user},
+		{name: "no user", interaction: &discordgo.Interaction{}, expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := interactionUser(tt.interaction); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestRouter_HandleInteraction_DM tests that slash commands run in DMs, where Member is nil.
+func TestRouter_HandleInteraction_DM(t *testing.T) {
+	r := setupTestRouter(t)
+	r.Use(RequirePermissions())
+
+	var got *Context
+	r.Register(&Command{
+		Name:    "image",
+		Options: []*Option{{Name: "category", Type: discordgo.ApplicationCommandOptionString}},
+		Handler: func(c *Context) error {
+			got = c
+			return nil
+		},
+	})
+
+	r.HandleInteraction(nil, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:      discordgo.InteractionApplicationCommand,
+		ChannelID: "dm1",
+		Context:   discordgo.InteractionContextBotDM,
+		User:      &discordgo.User{ID: "u1"},
+		Data: discordgo.ApplicationCommandInteractionData{
+			Name: "image",
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: "category", Type: discordgo.ApplicationCommandOptionString, Value: "wooper"},
+			},
+		},
+	}})
+
+	if got == nil {
+		t.Fatalf("Expected the command to run")
+	}
+	if got.User.ID != "u1" || got.Member != nil || got.GuildID != "" {
+		t.Errorf("Unexpected context user %v, member %v, guild %q", got.User, got.Member, got.GuildID)
+	}
+	if got.String("category") != "wooper" {
+		t.Errorf("Expected category wooper, got %q", got.String("category"))
+	}
+}
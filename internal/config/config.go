@@ -7,8 +7,15 @@ import (
 	"github.com/joho/godotenv"
 )
 
+const (
+	defaultDatabasePath = "data/wooper.db"
+)
+
 type Config struct {
 	DiscordBotToken string
+	DatabasePath    string
+	// AdminChannelID is where alerts for the bot admins are posted; empty only logs them.
+	AdminChannelID string
 }
 
 // Load reads configuration from environment variables and validates required fields.
@@ -21,5 +28,15 @@ func Load() (Config, error) {
 	if token == "" {
 		return Config{}, errors.New("missing DISCORD_BOT_TOKEN env var")
 	}
-	return Config{DiscordBotToken: token}, nil
+
+	databasePath := os.Getenv("DATABASE_PATH")
+	if databasePath == "" {
+		databasePath = defaultDatabasePath
+	}
+
+	return Config{
+		DiscordBotToken: token,
+		DatabasePath:    databasePath,
+		AdminChannelID:  os.Getenv("ADMIN_CHANNEL_ID"),
+	}, nil
 }
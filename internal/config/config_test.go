@@ -82,3 +82,51 @@ func TestLoadWithEnvFile(t *testing.T) {
 		t.Errorf("Expected token 'test-token-from-env', got %s", config.DiscordBotToken)
 	}
 }
+
+// TestLoadDatabasePath tests the default and overridden database location.
+func TestLoadDatabasePath(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("DISCORD_BOT_TOKEN", "test-token")
+	defer os.Clearenv()
+
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.DatabasePath != "data/wooper.db" {
+		t.Errorf("Expected default database path, got %s", config.DatabasePath)
+	}
+
+	os.Setenv("DATABASE_PATH", "/tmp/bot.db")
+	config, err = Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.DatabasePath != "/tmp/bot.db" {
+		t.Errorf("Expected /tmp/bot.db, got %s", config.DatabasePath)
+	}
+}
+
+// TestLoadAdminChannel tests that the admin channel is optional.
+func TestLoadAdminChannel(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("DISCORD_BOT_TOKEN", "test-token")
+	defer os.Clearenv()
+
+	config, err := Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.AdminChannelID != "" {
+		t.Errorf("Expected no admin channel, got %s", config.AdminChannelID)
+	}
+
+	os.Setenv("ADMIN_CHANNEL_ID", "123")
+	config, err = Load()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.AdminChannelID != "123" {
+		t.Errorf("Expected admin channel 123, got %s", config.AdminChannelID)
+	}
+}
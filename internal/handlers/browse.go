@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/logger"
+	"wooper-bot/internal/services"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// Names of the gallery buttons.
+const (
+	componentBrowse     = "browse"
+	componentBrowsePost = "browse-post"
+)
+
+// Directions of the gallery page buttons.
+const (
+	browseFirst    = "first"
+	browsePrevious = "previous"
+	browseNext     = "next"
+	browseLast     = "last"
+)
+
+// registerBrowse adds the browse command, a gallery stepping through a category in order.
+func (h *ImageHandler) registerBrowse(r *commands.Router) {
+	r.Register(&commands.Command{
+		Name:        "browse",
+		Description: "Step through every image of a category",
+		Options: []*commands.Option{
+			{
+				Name:         "category",
+				Description:  "Image category to browse",
+				Type:         discordgo.ApplicationCommandOptionString,
+				Required:     true,
+				Autocomplete: categoryAutocomplete(h.ImageService),
+			},
+		},
+		Handler: h.handleBrowse,
+	})
+
+	// Page buttons carry the shown image and a direction: the image gives the category and the
+	// position, and the direction keeps custom IDs unique when two buttons lead to the same image
+	directions := []*discordgo.ApplicationCommandOptionChoice{
+		{Name: browseFirst, Value: browseFirst},
+		{Name: browsePrevious, Value: browsePrevious},
+		{Name: browseNext, Value: browseNext},
+		{Name: browseLast, Value: browseLast},
+	}
+	imageOption := &commands.Option{Name: "image", Type: discordgo.ApplicationCommandOptionString, Required: true}
+	// Every page is an upload, keep paging at a human pace
+	pageLimits := []commands.RateLimit{{Scope: commands.ScopeUser, Burst: 5, Every: 2 * time.Second}}
+	r.RegisterComponent(
+		&commands.Command{
+			Name: componentBrowse,
+			Options: []*commands.Option{
+				imageOption,
+				{Name: "to", Type: discordgo.ApplicationCommandOptionString, Required: true, Choices: directions},
+			},
+			RateLimits: pageLimits,
+			Parent:     "browse",
+			Handler:    h.handleBrowsePage,
+		},
+		&commands.Command{
+			Name:       componentBrowsePost,
+			Options:    []*commands.Option{imageOption},
+			RateLimits: []commands.RateLimit{{Scope: commands.ScopeUser, Burst: 3, Every: 3 * time.Second}},
+			Parent:     "browse",
+			Handler:    h.handleBrowsePost,
+		},
+	)
+}
+
+func (h *ImageHandler) handleBrowse(c *commands.Context) error {
+	category := c.String("category")
+	if !h.ImageService.HasCategory(category) || !c.Settings.CategoryEnabled(category) {
+		availableCategories := c.Settings.FilterCategories(h.ImageService.GetAvailableCategories())
+		return c.Error(c.T("image.category_not_found", category, strings.Join(availableCategories, ", ")))
+	}
+	images := h.ImageService.GetCategoryImages(category)
+	if len(images) == 0 {
+		return c.Error(c.T("image.none_available", category))
+	}
+
+	if err := c.Defer(true); err != nil {
+		logger.Logger.Warn("Failed to acknowledge command", zap.Error(err))
+	}
+	c.LogFields(zap.String("category", category), zap.Int("images", len(images)))
+	return h.showBrowse(c, category, images, 0)
+}
+
+// handleBrowsePage moves the gallery to the first, previous, next or last image.
+func (h *ImageHandler) handleBrowsePage(c *commands.Context) error {
+	current, category, ok := h.ImageService.ImageByID(c.String("image"))
+	if !ok || !c.Settings.CategoryEnabled(category) {
+		return c.ReplyEphemeral(c.T("image.gone"))
+	}
+	images := h.ImageService.GetCategoryImages(category)
+	index := slices.Index(images, current)
+	if index < 0 {
+		return c.ReplyEphemeral(c.T("image.gone"))
+	}
+
+	switch c.String("to") {
+	case browseFirst:
+		index = 0
+	case browsePrevious:
+		index--
+	case browseNext:
+		index++
+	case browseLast:
+		index = len(images) - 1
+	}
+	index = min(max(index, 0), len(images)-1)
+
+	if err := c.DeferUpdate(); err != nil {
+		logger.Logger.Warn("Failed to acknowledge button", zap.Error(err))
+	}
+	return h.showBrowse(c, category, images, index)
+}
+
+// showBrowse shows the image at index in the gallery, replacing the previous one on button presses.
+func (h *ImageHandler) showBrowse(c *commands.Context, category string, images []string, index int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	imagePath := images[index]
+	reader, fileName, err := h.ImageService.GetImageFile(ctx, imagePath)
+	if err != nil {
+		return services.NewError(services.ErrCodeImageLoad, err)
+	}
+	defer reader.Close()
+
+	id := h.ImageService.ImageID(imagePath)
+	file := imageAttachment(id, fileName, reader)
+	last := len(images) - 1
+
+	button := func(emoji, direction string, disabled bool) discordgo.Button {
+		return discordgo.Button{
+			Style:    discordgo.SecondaryButton,
+			Emoji:    &discordgo.ComponentEmoji{Name: emoji},
+			CustomID: commands.CustomID(componentBrowse, id, direction),
+			Disabled: disabled,
+		}
+	}
+	response := &commands.Response{
+		Files: []*discordgo.File{file},
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:  category,
+			Color:  embedColor,
+			Image:  &discordgo.MessageEmbedImage{URL: "attachment://" + file.Name},
+			Footer: &discordgo.MessageEmbedFooter{Text: c.T("browse.footer", index+1, len(images), id)},
+		}},
+		Components: []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			button("⏮️", browseFirst, index == 0),
+			button("◀️", browsePrevious, index == 0),
+			button("▶️", browseNext, index == last),
+			button("⏭️", browseLast, index == last),
+			discordgo.Button{
+				Label:    c.T("browse.post"),
+				Style:    discordgo.PrimaryButton,
+				Emoji:    &discordgo.ComponentEmoji{Name: "📤"},
+				CustomID: commands.CustomID(componentBrowsePost, id),
+			},
+		}}},
+		Ephemeral: true,
+		Update:    c.Transport == commands.TransportComponent,
+	}
+	if err := c.Respond(response); err != nil {
+		return services.NewError(services.ErrCodeImageUpload, err)
+	}
+	c.LogFields(zap.String("image_id", id), zap.Int("index", index))
+	return nil
+}
+
+// handleBrowsePost posts the image shown in the gallery to the channel, for everyone to see.
+func (h *ImageHandler) handleBrowsePost(c *commands.Context) error {
+	imagePath, category, ok := h.ImageService.ImageByID(c.String("image"))
+	if !ok || !c.Settings.CategoryEnabled(category) {
+		return c.ReplyEphemeral(c.T("image.gone"))
+	}
+	return h.serve(c, "browse", category, imagePath)
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/services"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// TestImageHandler_Browse tests stepping through a category in order with the gallery buttons.
+func TestImageHandler_Browse(t *testing.T) {
+	_, imageService := setupTestHandler(t)
+	handler := NewImageHandler(imageService, nil, nil)
+	images := imageService.GetCategoryImages("wooper")
+	if len(images) != 2 {
+		t.Fatalf("Expected 2 wooper images, got %v", images)
+	}
+	first, second := imageService.ImageID(images[0]), imageService.ImageID(images[1])
+
+	tests := []struct {
+		name      string
+		image     string
+		direction string
+		expected  string
+	}{
+		{name: "next", image: first, direction: browseNext, expected: second},
+		{name: "previous", image: second, direction: browsePrevious, expected: first},
+		{name: "previous stops at the first", image: first, direction: browsePrevious, expected: first},
+		{name: "last", image: first, direction: browseLast, expected: second},
+		{name: "first", image: second, direction: browseFirst, expected: first},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := commands.NewRouter(nil, nil)
+			handler.Register(router)
+
+			responder := &uploadResponder{}
+			pressButton(router, responder, commands.CustomID(componentBrowse, tt.image, tt.direction))
+			if len(responder.responses) != 1 {
+				t.Fatalf("Expected one response, got %d", len(responder.responses))
+			}
+			response := responder.responses[0]
+			if !response.Update || !response.Ephemeral || response.Files[0].Name != tt.expected+".jpg" {
+				t.Errorf("Expected the gallery updated to %s, got %s (update %t)", tt.expected, response.Files[0].Name, response.Update)
+			}
+		})
+	}
+}
+
+// TestImageHandler_BrowseStart tests the first gallery page and its buttons.
+func TestImageHandler_BrowseStart(t *testing.T) {
+	_, imageService := setupTestHandler(t)
+	handler := NewImageHandler(imageService, nil, nil)
+
+	responder := &uploadResponder{}
+	router := commands.NewRouter(nil, nil)
+	handler.Register(router)
+	router.Use(func(next commands.HandlerFunc) commands.HandlerFunc {
+		return func(c *commands.Context) error {
+			c.Responder = responder
+			return next(c)
+		}
+	})
+	router.HandleInteraction(nil, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:      discordgo.InteractionApplicationCommand,
+		ChannelID: "c1",
+		User:      &discordgo.User{ID: "u1"},
+		Data: discordgo.ApplicationCommandInteractionData{
+			Name: "browse",
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: "category", Type: discordgo.ApplicationCommandOptionString, Value: "wooper"},
+			},
+		},
+	}})
+
+	if len(responder.responses) != 1 {
+		t.Fatalf("Expected one response, got %d", len(responder.responses))
+	}
+	response := responder.responses[0]
+	if response.Update || !response.Ephemeral || response.Embeds[0].Footer.Text[:13] != "Image 1 of 2 " {
+		t.Errorf("Expected a new ephemeral gallery on the first image, got %+v", response.Embeds[0].Footer)
+	}
+
+	var disabled []bool
+	for _, component := range response.Components[0].(discordgo.ActionsRow).Components {
+		disabled = append(disabled, component.(discordgo.Button).Disabled)
+	}
+	expected := []bool{true, true, false, false, false}
+	for i := range expected {
+		if disabled[i] != expected[i] {
+			t.Fatalf("Expected disabled buttons %v, got %v", expected, disabled)
+		}
+	}
+}
+
+// TestImageHandler_BrowsePost tests posting the shown image to the channel.
+func TestImageHandler_BrowsePost(t *testing.T) {
+	_, imageService := setupTestHandler(t)
+	router := commands.NewRouter(nil, nil)
+	NewImageHandler(imageService, nil, nil).Register(router)
+
+	id := imageService.ImageID(imageService.GetCategoryImages("cats")[1])
+	responder := &uploadResponder{}
+	pressButton(router, responder, commands.CustomID(componentBrowsePost, id))
+
+	if len(responder.responses) != 1 {
+		t.Fatalf("Expected one response, got %d", len(responder.responses))
+	}
+	response := responder.responses[0]
+	if response.Ephemeral || response.Update || response.Files[0].Name != id+".jpg" || response.Embeds[0].Title != "cats" {
+		t.Errorf("Expected %s posted publicly as a new message, got %+v", id, response)
+	}
+}
+
+// TestImageHandler_BrowsePostRejected tests that a file Discord rejects counts towards its
+// quarantine and another image of the category is posted instead.
+func TestImageHandler_BrowsePostRejected(t *testing.T) {
+	_, imageService := setupTestHandler(t)
+	router := commands.NewRouter(nil, nil)
+	NewImageHandler(imageService, nil, nil).Register(router)
+
+	imagePath := imageService.GetCategoryImages("cats")[1]
+	id := imageService.ImageID(imagePath)
+	tooLarge := &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusRequestEntityTooLarge}}
+	for i := 0; i < services.QuarantineThreshold; i++ {
+		responder := &uploadResponder{failures: 1, err: tooLarge}
+		pressButton(router, responder, commands.CustomID(componentBrowsePost, id))
+		if len(responder.files) != 2 || responder.files[1] == id+".jpg" || len(responder.responses) != 1 {
+			t.Fatalf("Expected another image posted after the rejected one, got %v", responder.files)
+		}
+	}
+	if !imageService.IsQuarantined(imagePath) {
+		t.Errorf("Expected %s quarantined after %d rejections", id, services.QuarantineThreshold)
+	}
+}
@@ -0,0 +1,331 @@
+package handlers
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/logger"
+	"wooper-bot/internal/services"
+	"wooper-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+const (
+	// catchCooldown is how long a user waits between two catches.
+	catchCooldown = 30 * time.Minute
+	// inventoryPageSize is how many catches an inventory page shows.
+	inventoryPageSize = 10
+	// maxCompletionLines caps the categories listed in the inventory completion field.
+	maxCompletionLines = 15
+
+	componentInventoryPage = "inventory-page"
+)
+
+// rarityStyles are the emoji marking each rarity in inventories and the embed color of catches.
+var rarityStyles = map[services.Rarity]struct {
+	emoji string
+	color int
+}{
+	services.RarityCommon:    {"⚪", 0x9e9e9e},
+	services.RarityUncommon:  {"🟢", 0x4caf50},
+	services.RarityRare:      {"🔵", 0x2196f3},
+	services.RarityLegendary: {"🟡", 0xffc107},
+}
+
+// CatchHandler handles the catch game: catching random images of some rarity, and the
+// inventory of caught images.
+type CatchHandler struct {
+	ImageService *services.ImageService
+	Store        *storage.Store
+
+	// mu guards catching, the users whose catch is being sent. A catch is only recorded once
+	// its image is sent, so they can't start another one meanwhile.
+	mu       sync.Mutex
+	catching map[string]bool
+}
+
+func NewCatchHandler(imageService *services.ImageService, store *storage.Store) *CatchHandler {
+	return &CatchHandler{ImageService: imageService, Store: store, catching: make(map[string]bool)}
+}
+
+// Register adds the catch and inventory commands and the inventory page buttons.
+func (h *CatchHandler) Register(r *commands.Router) {
+	rateLimits := []commands.RateLimit{{Scope: commands.ScopeUser, Burst: 3, Every: 3 * time.Second}}
+	r.Register(&commands.Command{
+		Name:        "catch",
+		Description: "Catch a random image for your inventory",
+		Options: []*commands.Option{
+			{
+				Name:         "category",
+				Description:  "Category to catch from, a random one if not set",
+				Type:         discordgo.ApplicationCommandOptionString,
+				Autocomplete: categoryAutocomplete(h.ImageService),
+			},
+		},
+		RateLimits: rateLimits,
+		Handler:    h.handleCatch,
+	})
+
+	minPage := 1.0
+	r.Register(&commands.Command{
+		Name:        "inventory",
+		Description: "Show the images you caught",
+		Options: []*commands.Option{
+			{
+				Name:        "page",
+				Description: "Page to show",
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				MinValue:    &minPage,
+			},
+		},
+		RateLimits: rateLimits,
+		Handler:    h.handleInventory,
+	})
+
+	// Page buttons carry the owner of the inventory, since text command replies are visible to everyone
+	r.RegisterComponent(&commands.Command{
+		Name: componentInventoryPage,
+		Options: []*commands.Option{
+			{Name: "user", Type: discordgo.ApplicationCommandOptionString, Required: true},
+			{Name: "page", Type: discordgo.ApplicationCommandOptionInteger, Required: true},
+		},
+		Parent:  "inventory",
+		Handler: h.handlePage,
+	})
+}
+
+func (h *CatchHandler) handleCatch(c *commands.Context) error {
+	availableCategories := c.Settings.FilterCategories(h.ImageService.GetAvailableCategories())
+	category := c.String("category")
+	if category == "" && len(availableCategories) > 0 {
+		category = availableCategories[rand.Intn(len(availableCategories))]
+	}
+	if !h.ImageService.HasCategory(category) || !c.Settings.CategoryEnabled(category) {
+		return c.Error(c.T("image.category_not_found", category, strings.Join(availableCategories, ", ")))
+	}
+
+	imagePath, rarity := h.ImageService.GetRandomImageOfRarity(category, services.RollRarity())
+	if imagePath == "" {
+		return c.Error(c.T("image.none_available", category))
+	}
+	id := h.ImageService.ImageID(imagePath)
+
+	ok, next, err := h.reserve(c.User.ID)
+	if err != nil {
+		return services.NewError(services.ErrCodeStorage, err)
+	}
+	if !ok && next.IsZero() {
+		return c.ReplyEphemeral(c.T("catch.in_progress"))
+	}
+	if !ok {
+		return c.ReplyEphemeral(c.T("catch.cooldown", next.Unix()))
+	}
+	defer h.release(c.User.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// The catch is recorded once the image is sent, so a failed upload doesn't use it up
+	previous, _, err := h.Store.CatchOf(ctx, c.User.ID, id)
+	if err != nil {
+		return services.NewError(services.ErrCodeStorage, err)
+	}
+	caught, err := h.Store.CatchCounts(ctx, c.User.ID)
+	if err != nil {
+		return services.NewError(services.ErrCodeStorage, err)
+	}
+	count := previous.Count + 1
+	if count == 1 {
+		caught[category]++
+	}
+
+	if err := c.Defer(false); err != nil {
+		logger.Logger.Warn("Failed to acknowledge command", zap.Error(err))
+	}
+	reader, fileName, err := h.ImageService.GetImageFile(ctx, imagePath)
+	if err != nil {
+		return services.NewError(services.ErrCodeImageLoad, err)
+	}
+	defer reader.Close()
+
+	description := c.T("catch.new")
+	if count > 1 {
+		description = c.T("catch.duplicate", count)
+	}
+	total := h.ImageService.GetImageCount(category)
+	file := imageAttachment(id, fileName, reader)
+	err = c.Respond(&commands.Response{
+		Files: []*discordgo.File{file},
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:       c.T("catch.caught", c.T("rarity."+rarity.String()), category),
+			Description: description,
+			Color:       rarityStyles[rarity].color,
+			Image:       &discordgo.MessageEmbedImage{URL: "attachment://" + file.Name},
+			Footer:      &discordgo.MessageEmbedFooter{Text: c.T("catch.footer", id, category, caught[category], total, completion(caught[category], total))},
+		}},
+	})
+	if err != nil {
+		return services.NewError(services.ErrCodeImageUpload, err)
+	}
+	if count, err = h.Store.AddCatch(ctx, c.User.ID, id, category, int(rarity)); err != nil {
+		return services.NewError(services.ErrCodeStorage, err)
+	}
+
+	c.LogFields(zap.String("category", category), zap.String("rarity", rarity.String()), zap.Int("count", count))
+	recordUsage(c, h.Store, storage.Usage{
+		Command:  "catch",
+		Category: category,
+		Image:    fileName,
+		ImageID:  id,
+	})
+	return nil
+}
+
+// reserve starts a catch unless the user is on cooldown, in which case it returns when the
+// user can catch again, or is already catching, in which case the time is zero. A reserved
+// catch must be released once it is recorded or failed.
+func (h *CatchHandler) reserve(userID string) (bool, time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.catching[userID] {
+		return false, time.Time{}, nil
+	}
+	last, err := h.Store.LastCatch(ctx, userID)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	if next := last.Add(catchCooldown); time.Now().Before(next) {
+		return false, next, nil
+	}
+	h.catching[userID] = true
+	return true, time.Time{}, nil
+}
+
+// release ends a catch started by reserve.
+func (h *CatchHandler) release(userID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.catching, userID)
+}
+
+// completion is the percentage of a category's images that were caught, capped at 100 since
+// caught images may have been removed since.
+func completion(caught, total int) int {
+	if total == 0 {
+		return 0
+	}
+	return min(caught*100/total, 100)
+}
+
+func (h *CatchHandler) handleInventory(c *commands.Context) error {
+	response, err := h.inventoryPage(c, int(c.Int("page", 1))-1)
+	if err != nil {
+		return err
+	}
+	return c.Respond(response)
+}
+
+// handlePage turns the inventory to the page whose button was pressed.
+func (h *CatchHandler) handlePage(c *commands.Context) error {
+	if c.String("user") != c.User.ID {
+		return c.ReplyEphemeral(c.T("inventory.not_yours"))
+	}
+	response, err := h.inventoryPage(c, int(c.Int("page", 0)))
+	if err != nil {
+		return err
+	}
+	response.Update = true
+	return c.Respond(response)
+}
+
+// inventoryPage renders a page of the user's inventory, rarest first, with the completion of
+// every category the user caught images in.
+func (h *CatchHandler) inventoryPage(c *commands.Context, page int) (*commands.Response, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	caught, err := h.Store.CatchCounts(ctx, c.User.ID)
+	if err != nil {
+		return nil, services.NewError(services.ErrCodeStorage, err)
+	}
+	count := 0
+	categories := make([]string, 0, len(caught))
+	for category, n := range caught {
+		count += n
+		categories = append(categories, category)
+	}
+	if count == 0 {
+		return &commands.Response{Content: c.T("inventory.none"), Ephemeral: true}, nil
+	}
+
+	pages := (count + inventoryPageSize - 1) / inventoryPageSize
+	page = min(max(page, 0), pages-1)
+	catches, err := h.Store.Catches(ctx, c.User.ID, inventoryPageSize, page*inventoryPageSize)
+	if err != nil {
+		return nil, services.NewError(services.ErrCodeStorage, err)
+	}
+
+	lines := make([]string, len(catches))
+	for i, catch := range catches {
+		rarity := services.Rarity(catch.Rarity)
+		lines[i] = c.T("inventory.entry", rarityStyles[rarity].emoji, catch.ImageID, catch.Category, c.T("rarity."+rarity.String()), catch.Count)
+	}
+
+	// Most complete categories first
+	sort.Slice(categories, func(i, j int) bool {
+		ci, cj := categories[i], categories[j]
+		pi := completion(caught[ci], h.ImageService.GetImageCount(ci))
+		pj := completion(caught[cj], h.ImageService.GetImageCount(cj))
+		if pi != pj {
+			return pi > pj
+		}
+		return ci < cj
+	})
+	var progress []string
+	for _, category := range categories[:min(len(categories), maxCompletionLines)] {
+		total := h.ImageService.GetImageCount(category)
+		progress = append(progress, c.T("inventory.category", category, caught[category], total, completion(caught[category], total)))
+	}
+
+	response := &commands.Response{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:       c.T("inventory.title", c.User.Username),
+			Description: strings.Join(lines, "\n"),
+			Color:       embedColor,
+			Fields:      []*discordgo.MessageEmbedField{{Name: c.T("inventory.completion"), Value: strings.Join(progress, "\n")}},
+			Footer:      &discordgo.MessageEmbedFooter{Text: c.T("inventory.footer", page+1, pages, count)},
+		}},
+	}
+	if pages > 1 {
+		response.Components = []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    c.T("help.previous"),
+				Style:    discordgo.SecondaryButton,
+				Emoji:    &discordgo.ComponentEmoji{Name: "◀️"},
+				CustomID: commands.CustomID(componentInventoryPage, c.User.ID, strconv.Itoa(page-1)),
+				Disabled: page == 0,
+			},
+			discordgo.Button{
+				Label:    c.T("help.next"),
+				Style:    discordgo.SecondaryButton,
+				Emoji:    &discordgo.ComponentEmoji{Name: "▶️"},
+				CustomID: commands.CustomID(componentInventoryPage, c.User.ID, strconv.Itoa(page+1)),
+				Disabled: page == pages-1,
+			},
+		}}}
+	}
+	c.LogFields(zap.Int("catches", count), zap.Int("page", page+1))
+	return response, nil
+}
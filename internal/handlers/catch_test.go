@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// runCatchCommand runs a catch game command as user u1, answering with responder.
+func runCatchCommand(router *commands.Router, responder commands.Responder, name string, options ...*discordgo.ApplicationCommandInteractionDataOption) {
+	router.Use(func(next commands.HandlerFunc) commands.HandlerFunc {
+		return func(c *commands.Context) error {
+			c.Responder = responder
+			return next(c)
+		}
+	})
+	router.HandleInteraction(nil, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:      discordgo.InteractionApplicationCommand,
+		ChannelID: "c1",
+		User:      &discordgo.User{ID: "u1", Username: "user"},
+		Data:      discordgo.ApplicationCommandInteractionData{Name: name, Options: options},
+	}})
+}
+
+// setupCatch registers the catch game on a router backed by a test store.
+func setupCatch(t *testing.T) (*commands.Router, *storage.Store) {
+	t.Helper()
+	_, imageService := setupTestHandler(t)
+
+	store, err := storage.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	router := commands.NewRouter(nil, nil)
+	NewCatchHandler(imageService, store).Register(router)
+	return router, store
+}
+
+// TestCatchHandler_Catch tests catching an image and the cooldown before the next one.
+func TestCatchHandler_Catch(t *testing.T) {
+	router, _ := setupCatch(t)
+	category := &discordgo.ApplicationCommandInteractionDataOption{Name: "category", Type: discordgo.ApplicationCommandOptionString, Value: "wooper"}
+
+	responder := &uploadResponder{}
+	runCatchCommand(router, responder, "catch", category)
+	if len(responder.responses) != 1 || len(responder.responses[0].Embeds) != 1 {
+		t.Fatalf("Expected a catch embed, got %+v", responder.responses)
+	}
+	embed := responder.responses[0].Embeds[0]
+	if !strings.HasSuffix(embed.Title, "image from wooper!") || !strings.HasPrefix(embed.Description, "New!") {
+		t.Errorf("Expected a new wooper catch, got %q: %q", embed.Title, embed.Description)
+	}
+	if !strings.HasSuffix(embed.Footer.Text, "wooper: 1/2 caught (50%)") {
+		t.Errorf("Expected the completion in the footer, got %q", embed.Footer.Text)
+	}
+
+	responder = &uploadResponder{}
+	runCatchCommand(router, responder, "catch")
+	if len(responder.responses) != 1 || !responder.responses[0].Ephemeral || !strings.Contains(responder.responses[0].Content, "catch again <t:") {
+		t.Errorf("Expected the cooldown message, got %+v", responder.responses)
+	}
+}
+
+// TestCatchHandler_FailedUpload tests that a catch whose image can't be sent isn't recorded.
+func TestCatchHandler_FailedUpload(t *testing.T) {
+	router, store := setupCatch(t)
+	ctx := context.Background()
+
+	responder := &uploadResponder{failures: 1, err: errors.New("upload failed")}
+	runCatchCommand(router, responder, "catch")
+	if len(responder.files) != 1 {
+		t.Fatalf("Expected an upload attempt, got %v", responder.files)
+	}
+	if caught, _ := store.Catches(ctx, "u1", 10, 0); len(caught) != 0 {
+		t.Errorf("Expected no catch recorded, got %+v", caught)
+	}
+	if last, _ := store.LastCatch(ctx, "u1"); !last.IsZero() {
+		t.Errorf("Expected no cooldown, got %v", last)
+	}
+
+	responder = &uploadResponder{}
+	runCatchCommand(router, responder, "catch")
+	if len(responder.responses) != 1 || len(responder.responses[0].Embeds) != 1 {
+		t.Fatalf("Expected the next catch to work, got %+v", responder.responses)
+	}
+	if caught, _ := store.Catches(ctx, "u1", 10, 0); len(caught) != 1 {
+		t.Errorf("Expected the catch recorded once sent, got %+v", caught)
+	}
+}
+
+// TestCatchHandler_CooldownAfterTrade tests that trading away the last catch keeps the cooldown.
+func TestCatchHandler_CooldownAfterTrade(t *testing.T) {
+	router, store := setupCatch(t)
+	ctx := context.Background()
+
+	responder := &uploadResponder{}
+	runCatchCommand(router, responder, "catch")
+	caught, err := store.Catches(ctx, "u1", 1, 0)
+	if err != nil || len(caught) != 1 {
+		t.Fatalf("Expected one catch, got %+v (%v)", caught, err)
+	}
+
+	if _, err := store.AddCatch(ctx, "u2", "other", "cats", 0); err != nil {
+		t.Fatalf("Failed to add catch: %v", err)
+	}
+	id, err := store.CreateTrade(ctx, storage.Trade{
+		GuildID: "g1", FromUserID: "u1", ToUserID: "u2", GiveID: caught[0].ImageID, WantID: "other",
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create trade: %v", err)
+	}
+	if _, err := store.AcceptTrade(ctx, id, "u2"); err != nil {
+		t.Fatalf("Failed to accept trade: %v", err)
+	}
+
+	responder = &uploadResponder{}
+	runCatchCommand(router, responder, "catch")
+	if len(responder.responses) != 1 || !strings.Contains(responder.responses[0].Content, "catch again <t:") {
+		t.Errorf("Expected the cooldown to survive the trade, got %+v", responder.responses)
+	}
+}
+
+// TestCatchHandler_Inventory tests the inventory pages, completion and owner-only buttons.
+func TestCatchHandler_Inventory(t *testing.T) {
+	router, store := setupCatch(t)
+
+	responder := &uploadResponder{}
+	runCatchCommand(router, responder, "inventory")
+	if len(responder.responses) != 1 || !strings.Contains(responder.responses[0].Content, "inventory is empty") {
+		t.Fatalf("Expected the empty inventory message, got %+v", responder.responses)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < inventoryPageSize+2; i++ {
+		if _, err := store.AddCatch(ctx, "u1", fmt.Sprintf("id%02d", i), "wooper", i%4); err != nil {
+			t.Fatalf("Failed to add catch: %v", err)
+		}
+	}
+	if _, err := store.AddCatch(ctx, "u1", "id00", "wooper", 0); err != nil {
+		t.Fatalf("Failed to add catch: %v", err)
+	}
+
+	responder = &uploadResponder{}
+	runCatchCommand(router, responder, "inventory")
+	embed := responder.responses[0].Embeds[0]
+	if !strings.HasPrefix(embed.Description, "🟡 `id03` · wooper · legendary ×1") || !strings.HasSuffix(embed.Description, "`id00` · wooper · common ×2") {
+		t.Errorf("Expected the rarest catches first and the duplicate counted, got %q", embed.Description)
+	}
+	if embed.Fields[0].Value != "wooper: 12/2 (100%)" || embed.Footer.Text != "Page 1 of 2 · 12 different images caught" {
+		t.Errorf("Unexpected completion %q and footer %q", embed.Fields[0].Value, embed.Footer.Text)
+	}
+
+	next := responder.responses[0].Components[0].(discordgo.ActionsRow).Components[1].(discordgo.Button)
+	responder = &uploadResponder{}
+	pressButton(router, responder, next.CustomID)
+	if !responder.responses[0].Update || !strings.HasSuffix(responder.responses[0].Embeds[0].Description, "`id08` · wooper · common ×1") {
+		t.Errorf("Expected page 2 to end with the last common catch, got %+v", responder.responses[0])
+	}
+
+	responder = &uploadResponder{}
+	pressButton(router, responder, commands.CustomID(componentInventoryPage, "u2", "1"))
+	if !responder.responses[0].Ephemeral || !strings.Contains(responder.responses[0].Content, "Only the owner") {
+		t.Errorf("Expected other users to be refused, got %+v", responder.responses[0])
+	}
+}
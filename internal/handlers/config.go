@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/i18n"
+	"wooper-bot/internal/logger"
+	"wooper-bot/internal/services"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// ConfigHandler handles the config command used to edit per-guild settings.
+type ConfigHandler struct {
+	Settings     *services.SettingsService
+	ImageService *services.ImageService
+}
+
+func NewConfigHandler(settings *services.SettingsService, imageService *services.ImageService) *ConfigHandler {
+	return &ConfigHandler{Settings: settings, ImageService: imageService}
+}
+
+// Register adds the config command.
+func (h *ConfigHandler) Register(r *commands.Router) {
+	r.Register(&commands.Command{
+		Name:        "config",
+		Description: "Change how the bot behaves in this server",
+		Permissions: discordgo.PermissionManageServer,
+		GuildOnly:   true,
+		Subcommands: []*commands.Command{
+			{
+				Name:        "show",
+				Description: "Show the current settings",
+				Handler:     h.handleShow,
+			},
+			{
+				Name:        "prefix",
+				Description: "Set the prefixes for text commands",
+				Options: []*commands.Option{
+					{
+						Name:        "value",
+						Description: "Up to 5 prefixes separated by spaces, e.g. ? or \"! w!\"",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+						MaxLength:   29,
+					},
+				},
+				Handler: h.setting(services.SettingPrefix, "value"),
+			},
+			{
+				Name:        "categories",
+				Description: "Choose which image categories can be used",
+				Options: []*commands.Option{
+					{
+						Name:        "enabled",
+						Description: "Comma separated categories, or \"all\"",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+					},
+				},
+				Handler: h.setting(services.SettingEnabledCategories, "enabled"),
+			},
+			{
+				Name:        "default-category",
+				Description: "Category used when none is given (leave empty to clear)",
+				Options: []*commands.Option{
+					{
+						Name:         "category",
+						Description:  "Default image category",
+						Type:         discordgo.ApplicationCommandOptionString,
+						Autocomplete: categoryAutocomplete(h.ImageService),
+					},
+				},
+				Handler: h.setting(services.SettingDefaultCategory, "category"),
+			},
+			{
+				Name:        "reply-style",
+				Description: "Answer text commands as plain messages or as replies",
+				Options: []*commands.Option{
+					{
+						Name:        "style",
+						Description: "Reply style",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "plain message", Value: string(services.ReplyStylePlain)},
+							{Name: "reply", Value: string(services.ReplyStyleReply)},
+						},
+					},
+				},
+				Handler: h.setting(services.SettingReplyStyle, "style"),
+			},
+			{
+				Name:        "ephemeral-errors",
+				Description: "Show slash command errors only to the user who ran the command",
+				Options: []*commands.Option{
+					{
+						Name:        "enabled",
+						Description: "Whether errors are ephemeral",
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Required:    true,
+					},
+				},
+				Handler: h.setting(services.SettingEphemeralErrors, "enabled"),
+			},
+			{
+				Name:        "weighted-selection",
+				Description: "Pick well rated images more often",
+				Options: []*commands.Option{
+					{
+						Name:        "enabled",
+						Description: "Whether ratings weigh on random images",
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Required:    true,
+					},
+				},
+				Handler: h.setting(services.SettingWeightedSelection, "enabled"),
+			},
+			{
+				Name:        "language",
+				Description: "Set the language the bot answers in",
+				Options: []*commands.Option{
+					{
+						Name:        "language",
+						Description: "Language, or automatic to follow each user's Discord language",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+						Choices:     languageChoices(),
+					},
+				},
+				Handler: h.setting(services.SettingLanguage, "language"),
+			},
+			{
+				Name:        "reset",
+				Description: "Restore the default settings",
+				Handler:     h.handleReset,
+			},
+		},
+	})
+}
+
+// setting returns a handler storing the given option as a guild setting.
+func (h *ConfigHandler) setting(key, option string) commands.HandlerFunc {
+	return func(c *commands.Context) error {
+		value := c.String(option)
+		if key == services.SettingEphemeralErrors || key == services.SettingWeightedSelection {
+			value = strconv.FormatBool(c.Bool(option))
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := h.Settings.Set(ctx, c.GuildID, key, value); err != nil {
+			logger.Logger.Warn("Invalid guild setting",
+				zap.String("guild_id", c.GuildID),
+				zap.String("key", key),
+				zap.String("value", value),
+				zap.Error(err))
+			if services.CodeOf(err) != "" {
+				return err
+			}
+			return c.ReplyEphemeral(c.T("config.invalid", err))
+		}
+		return h.handleShow(c)
+	}
+}
+
+func (h *ConfigHandler) handleShow(c *commands.Context) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	settings, err := h.Settings.Get(ctx, c.GuildID)
+	if err != nil {
+		return services.NewError(services.ErrCodeStorage, fmt.Errorf("load guild settings: %w", err))
+	}
+	return c.ReplyEphemeral(formatSettings(c.Locale(), settings))
+}
+
+func (h *ConfigHandler) handleReset(c *commands.Context) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := h.Settings.Reset(ctx, c.GuildID); err != nil {
+		return fmt.Errorf("reset guild settings: %w", err)
+	}
+	return c.ReplyEphemeral(c.T("config.reset"))
+}
+
+// languageChoices lists the supported languages for /config language, each named in itself.
+func languageChoices() []*discordgo.ApplicationCommandOptionChoice {
+	choices := []*discordgo.ApplicationCommandOptionChoice{{Name: "automatic", Value: services.LanguageAuto}}
+	for _, lang := range i18n.Languages() {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  i18n.T(lang, "language.name"),
+			Value: lang,
+		})
+	}
+	return choices
+}
+
+// formatSettings renders guild settings for /config show in the given locale.
+func formatSettings(locale string, settings services.GuildSettings) string {
+	categories := i18n.T(locale, "config.all")
+	if len(settings.EnabledCategories) > 0 {
+		categories = strings.Join(settings.EnabledCategories, ", ")
+	}
+	defaultCategory := i18n.T(locale, "config.none")
+	if settings.DefaultCategory != "" {
+		defaultCategory = settings.DefaultCategory
+	}
+	language := i18n.T(locale, "config.language_auto")
+	if settings.Language != "" {
+		language = i18n.T(settings.Language, "language.name")
+	}
+
+	lines := []string{
+		i18n.T(locale, "config.title"),
+		i18n.T(locale, "config.prefixes", strings.Join(settings.Prefixes, "` `")),
+		i18n.T(locale, "config.categories", categories),
+		i18n.T(locale, "config.default_category", defaultCategory),
+		i18n.T(locale, "config.reply_style", settings.ReplyStyle),
+		i18n.T(locale, "config.ephemeral_errors", settings.EphemeralErrors),
+		i18n.T(locale, "config.weighted_selection", settings.WeightedSelection),
+		i18n.T(locale, "config.language", language),
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
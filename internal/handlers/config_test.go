@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/services"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// TestFormatSettings tests the /config show rendering.
+func TestFormatSettings(t *testing.T) {
+	message := formatSettings("en-US", services.GuildSettings{
+		Prefixes:          []string{"?", "w!"},
+		EnabledCategories: []string{"wooper", "cats"},
+		ReplyStyle:        services.ReplyStyleReply,
+		EphemeralErrors:   true,
+	})
+
+	for _, expected := range []string{"Prefixes: `?` `w!`", "wooper, cats", "Default category: none", "Reply style: reply", "Ephemeral errors: true", "Language: automatic"} {
+		if !strings.Contains(message, expected) {
+			t.Errorf("Expected %q in %q", expected, message)
+		}
+	}
+
+	french := formatSettings("fr", services.GuildSettings{Prefixes: []string{"!"}, Language: "en"})
+	for _, expected := range []string{"Paramètres du serveur", "Catégorie par défaut : aucune", "Langue : English"} {
+		if !strings.Contains(french, expected) {
+			t.Errorf("Expected %q in %q", expected, french)
+		}
+	}
+}
+
+// TestCommandLocalizations tests that every slash command, subcommand and option has a french description.
+func TestCommandLocalizations(t *testing.T) {
+	_, imageService := setupTestHandler(t)
+
+	router := commands.NewRouter(nil, nil)
+	images := NewImageHandler(imageService, nil, nil)
+	images.Register(router)
+	NewFavoritesHandler(images, nil).Register(router)
+	NewRatingHandler(imageService, nil).Register(router)
+	NewStatsHandler(imageService, nil).Register(router)
+	NewCatchHandler(imageService, nil).Register(router)
+	NewTradeHandler(imageService, nil).Register(router)
+	NewQuizHandler(imageService, nil).Register(router)
+	NewDexHandler(imageService, nil).Register(router)
+	NewTriggerHandler(router, imageService, nil, nil).Register(router)
+	NewPermissionHandler(router, imageService, nil).Register(router)
+	NewConfigHandler(nil, imageService).Register(router)
+	NewDailyHandler(imageService, nil).Register(router)
+	NewScheduleHandler(imageService, nil).Register(router)
+
+	var check func(path string, description string, localized map[discordgo.Locale]string, options []*discordgo.ApplicationCommandOption)
+	check = func(path string, description string, localized map[discordgo.Locale]string, options []*discordgo.ApplicationCommandOption) {
+		if localized[discordgo.French] == "" {
+			t.Errorf("Missing french description of %s (%q)", path, description)
+		}
+		for _, option := range options {
+			check(path+" "+option.Name, option.Description, option.DescriptionLocalizations, option.Options)
+		}
+	}
+	for _, cmd := range router.ApplicationCommands() {
+		var localized map[discordgo.Locale]string
+		if cmd.DescriptionLocalizations != nil {
+			localized = *cmd.DescriptionLocalizations
+		}
+		check(cmd.Name, cmd.Description, localized, cmd.Options)
+	}
+}
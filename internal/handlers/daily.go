@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/logger"
+	"wooper-bot/internal/scheduler"
+	"wooper-bot/internal/services"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// DailyHandler handles the daily command used to configure the image of the day.
+type DailyHandler struct {
+	ImageService *services.ImageService
+	Scheduler    *scheduler.Scheduler
+}
+
+func NewDailyHandler(imageService *services.ImageService, sched *scheduler.Scheduler) *DailyHandler {
+	return &DailyHandler{ImageService: imageService, Scheduler: sched}
+}
+
+// Register adds the daily command.
+func (h *DailyHandler) Register(r *commands.Router) {
+	minWindow := 0.0
+	r.Register(&commands.Command{
+		Name:        "daily",
+		Description: "Configure the daily image post for this server",
+		Permissions: discordgo.PermissionManageServer,
+		GuildOnly:   true,
+		Subcommands: []*commands.Command{
+			{
+				Name:        "set",
+				Description: "Post an image every day at a fixed time",
+				Options: []*commands.Option{
+					{
+						Name:         "channel",
+						Description:  "Channel to post in",
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Required:     true,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					},
+					{
+						Name:        "time",
+						Description: "Local posting time as HH:MM",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+					},
+					{
+						Name:        "timezone",
+						Description: "IANA time zone, e.g. Europe/Paris (default UTC)",
+						Type:        discordgo.ApplicationCommandOptionString,
+					},
+					{
+						Name:         "category",
+						Description:  "Image category to post (default wooper)",
+						Type:         discordgo.ApplicationCommandOptionString,
+						Autocomplete: categoryAutocomplete(h.ImageService),
+					},
+					{
+						Name:        "window",
+						Description: "Number of days before an image may be posted again (default 7)",
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						MinValue:    &minWindow,
+						MaxValue:    365,
+					},
+				},
+				Handler: h.handleSet,
+			},
+			{
+				Name:        "show",
+				Description: "Show the current daily image settings",
+				Handler:     h.handleShow,
+			},
+			{
+				Name:        "disable",
+				Description: "Stop posting the daily image",
+				Handler:     h.handleDisable,
+			},
+		},
+	})
+}
+
+func (h *DailyHandler) handleSet(c *commands.Context) error {
+	post := scheduler.DailyPost{
+		GuildID:   c.GuildID,
+		ChannelID: c.String("channel"),
+		Time:      strings.TrimSpace(c.String("time")),
+		Category:  "wooper",
+		Timezone:  "UTC",
+		Window:    int(c.Int("window", scheduler.DefaultWindow)),
+	}
+	if c.Has("timezone") {
+		post.Timezone = strings.TrimSpace(c.String("timezone"))
+	}
+	if c.Has("category") {
+		post.Category = c.String("category")
+	}
+
+	saved, err := h.Scheduler.SetDaily(post)
+	if err != nil {
+		logger.Logger.Warn("Invalid daily post configuration",
+			zap.String("guild_id", c.GuildID),
+			zap.Error(err))
+		if services.CodeOf(err) != "" {
+			return err
+		}
+		return c.ReplyEphemeral(c.T("daily.invalid", err))
+	}
+
+	return c.ReplyEphemeral(c.T("daily.set",
+		saved.Category, saved.ChannelID, saved.Time, saved.Timezone, saved.NextRun.Unix()))
+}
+
+func (h *DailyHandler) handleShow(c *commands.Context) error {
+	post, ok := h.Scheduler.Daily(c.GuildID)
+	if !ok {
+		return c.ReplyEphemeral(c.T("daily.not_configured"))
+	}
+
+	return c.ReplyEphemeral(c.T("daily.show",
+		post.Category, post.ChannelID, post.Time, post.Timezone, post.Window, post.NextRun.Unix()))
+}
+
+func (h *DailyHandler) handleDisable(c *commands.Context) error {
+	removed, err := h.Scheduler.RemoveDaily(c.GuildID)
+	if err != nil {
+		return fmt.Errorf("remove daily post: %w", err)
+	}
+	if !removed {
+		return c.ReplyEphemeral(c.T("daily.not_configured"))
+	}
+	return c.ReplyEphemeral(c.T("daily.disabled"))
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/services"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// maxDexSuggestions is how many close names a failed lookup suggests.
+const maxDexSuggestions = 5
+
+// typeColors are the embed colors of Pokémon types, keyed as in the dataset.
+var typeColors = map[string]int{
+	"normal":   0xa8a77a,
+	"fire":     0xee8130,
+	"water":    0x6390f0,
+	"electric": 0xf7d02c,
+	"grass":    0x7ac74c,
+	"ice":      0x96d9d6,
+	"fighting": 0xc22e28,
+	"poison":   0xa33ea1,
+	"ground":   0xe2bf65,
+	"flying":   0xa98ff3,
+	"psychic":  0xf95587,
+	"bug":      0xa6b91a,
+	"rock":     0xb6a136,
+	"ghost":    0x735797,
+	"dragon":   0x6f35fc,
+	"dark":     0x705746,
+	"steel":    0xb7b7ce,
+	"fairy":    0xd685ad,
+}
+
+// typeColor is the embed color of a Pokémon's first type, or the bot's color without one.
+func typeColor(types []string) int {
+	if len(types) == 0 {
+		return embedColor
+	}
+	if color, ok := typeColors[types[0]]; ok {
+		return color
+	}
+	return embedColor
+}
+
+// DexHandler looks Pokémon up in the bundled Pokédex.
+type DexHandler struct {
+	ImageService *services.ImageService
+	Dex          *services.Dex
+}
+
+func NewDexHandler(imageService *services.ImageService, dex *services.Dex) *DexHandler {
+	return &DexHandler{ImageService: imageService, Dex: dex}
+}
+
+// Register adds the dex command.
+func (h *DexHandler) Register(r *commands.Router) {
+	r.Register(&commands.Command{
+		Name:        "dex",
+		Description: "Look a Pokémon up in the Pokédex",
+		Options: []*commands.Option{
+			{
+				Name:         "pokemon",
+				Description:  "Name or number of the Pokémon",
+				Type:         discordgo.ApplicationCommandOptionString,
+				Required:     true,
+				MaxLength:    50,
+				Autocomplete: h.autocomplete,
+			},
+		},
+		Handler: h.handleDex,
+	})
+}
+
+// autocomplete suggests Pokémon whose name contains what was typed.
+func (h *DexHandler) autocomplete(c *commands.Context, value string) []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, entry := range h.Dex.Search(value, 25) {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{
+			Name:  fmt.Sprintf("#%03d %s", entry.Number, entry.Name),
+			Value: entry.Name,
+		})
+	}
+	return choices
+}
+
+func (h *DexHandler) handleDex(c *commands.Context) error {
+	query := c.String("pokemon")
+	entry, ok := h.Dex.Lookup(query)
+	if !ok {
+		var names []string
+		for _, suggestion := range h.Dex.Search(query, maxDexSuggestions) {
+			names = append(names, suggestion.Name)
+		}
+		if len(names) > 0 {
+			return c.Error(c.T("dex.not_found_suggestions", query, strings.Join(names, ", ")))
+		}
+		return c.Error(c.T("dex.not_found", query))
+	}
+
+	types := make([]string, len(entry.Types))
+	for i, t := range entry.Types {
+		types[i] = c.T("dex.type." + t)
+	}
+	if len(types) == 0 {
+		types = []string{c.T("dex.type.unknown")}
+	}
+	abilities := make([]string, len(entry.Abilities))
+	for i, ability := range entry.Abilities {
+		abilities[i] = ability.Name
+		if ability.Hidden {
+			abilities[i] = c.T("dex.hidden_ability", ability.Name)
+		}
+	}
+	stats := entry.Stats
+
+	embed := &discordgo.MessageEmbed{
+		Title:       c.T("dex.title", entry.Number, entry.Name),
+		Description: "*" + entry.Flavor + "*",
+		Color:       typeColor(entry.Types),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: c.T("dex.types"), Value: strings.Join(types, " / "), Inline: true},
+			{Name: c.T("dex.abilities"), Value: strings.Join(abilities, ", "), Inline: true},
+			{Name: c.T("dex.base_stats"), Value: c.T("dex.stats",
+				stats.HP, stats.Attack, stats.Defense, stats.SpecialAttack, stats.SpecialDefense, stats.Speed, stats.Total())},
+			{Name: c.T("dex.evolutions"), Value: h.evolutions(c, entry)},
+		},
+	}
+	if category := h.category(c, entry); category != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  c.T("dex.images"),
+			Value: c.T("dex.category", categoryUsage(c.Transport, c.Settings.PrimaryPrefix(), category), h.ImageService.GetImageCount(category)),
+		})
+	}
+	return c.Respond(&commands.Response{Embeds: []*discordgo.MessageEmbed{embed}})
+}
+
+// evolutions describes the stages before and after entry.
+func (h *DexHandler) evolutions(c *commands.Context, entry services.Pokemon) string {
+	var lines []string
+	if entry.EvolvesFrom != "" {
+		lines = append(lines, c.T("dex.evolves_from", entry.EvolvesFrom))
+	}
+	for _, evolution := range entry.EvolvesTo {
+		lines = append(lines, c.T("dex.evolves_into", evolution.Name, evolution.Method))
+	}
+	if len(lines) == 0 {
+		return c.T("dex.no_evolution")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// category returns the enabled image category named after entry, or "".
+func (h *DexHandler) category(c *commands.Context, entry services.Pokemon) string {
+	name := services.NormalizeAnswer(entry.Name)
+	for _, category := range c.Settings.FilterCategories(h.ImageService.GetAvailableCategories()) {
+		if services.NormalizeAnswer(category) == name {
+			return category
+		}
+	}
+	return ""
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/services"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// setupDex registers the dex command on a router with the wooper and cats image categories.
+func setupDex(t *testing.T) *commands.Router {
+	t.Helper()
+	_, imageService := setupTestHandler(t)
+	dex, err := services.NewDex()
+	if err != nil {
+		t.Fatalf("Failed to load dex: %v", err)
+	}
+
+	router := commands.NewRouter(nil, nil)
+	NewDexHandler(imageService, dex).Register(router)
+	return router
+}
+
+// lookUp runs /dex with the given pokemon option.
+func lookUp(router *commands.Router, pokemon string) *uploadResponder {
+	responder := &uploadResponder{}
+	router.Use(func(next commands.HandlerFunc) commands.HandlerFunc {
+		return func(c *commands.Context) error {
+			c.Responder = responder
+			return next(c)
+		}
+	})
+	router.HandleInteraction(nil, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:      discordgo.InteractionApplicationCommand,
+		ChannelID: "c1",
+		User:      &discordgo.User{ID: "u1"},
+		Data: discordgo.ApplicationCommandInteractionData{Name: "dex", Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: "pokemon", Type: discordgo.ApplicationCommandOptionString, Value: pokemon},
+		}},
+	}})
+	return responder
+}
+
+// TestDexHandler tests the entry embed and the link to the image category of the same name.
+func TestDexHandler(t *testing.T) {
+	router := setupDex(t)
+
+	responder := lookUp(router, "wooper")
+	if len(responder.responses) != 1 || len(responder.responses[0].Embeds) != 1 {
+		t.Fatalf("Expected an embed, got %+v", responder.responses)
+	}
+	embed := responder.responses[0].Embeds[0]
+	if embed.Title != "#194 Wooper" || embed.Color != typeColors["water"] {
+		t.Errorf("Unexpected title %q or color %x", embed.Title, embed.Color)
+	}
+	fields := make(map[string]string)
+	for _, field := range embed.Fields {
+		fields[field.Name] = field.Value
+	}
+	expected := map[string]string{
+		"Types":      "Water / Ground",
+		"Abilities":  "Damp, Water Absorb, Unaware (hidden)",
+		"Evolutions": "Evolves into **Quagsire** (Level 20)",
+		"Images":     "`/image category:wooper` sends one of its 2 images",
+	}
+	for name, value := range expected {
+		if fields[name] != value {
+			t.Errorf("Expected %s %q, got %q", name, value, fields[name])
+		}
+	}
+	if !strings.Contains(fields["Base stats"], "Total       210") {
+		t.Errorf("Expected the stat total, got %q", fields["Base stats"])
+	}
+
+	responder = lookUp(router, "195")
+	embed = responder.responses[0].Embeds[0]
+	if embed.Title != "#195 Quagsire" || len(embed.Fields) != 4 {
+		t.Errorf("Expected Quagsire without an image category, got %q with %d fields", embed.Title, len(embed.Fields))
+	}
+}
+
+// TestDexHandler_Mention tests that the image hint uses the server's prefix when the bot is mentioned.
+func TestDexHandler_Mention(t *testing.T) {
+	router := setupDex(t)
+	responder := &uploadResponder{}
+	router.Use(func(next commands.HandlerFunc) commands.HandlerFunc {
+		return func(c *commands.Context) error {
+			c.Responder = responder
+			return next(c)
+		}
+	})
+	s, err := discordgo.New("Bot test")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	s.Client = &http.Client{Transport: &messageTransport{message: "{}"}}
+	s.State.User = &discordgo.User{ID: "bot"}
+
+	router.HandleMessage(s, &discordgo.MessageCreate{Message: &discordgo.Message{
+		ID: "m1", ChannelID: "c1", Content: "<@bot> dex wooper", Author: &discordgo.User{ID: "u1"},
+	}})
+	if len(responder.responses) != 1 || len(responder.responses[0].Embeds) != 1 {
+		t.Fatalf("Expected an embed, got %+v", responder.responses)
+	}
+	fields := responder.responses[0].Embeds[0].Fields
+	if got := fields[len(fields)-1].Value; got != "`!wooper` sends one of its 2 images" {
+		t.Errorf("Expected the primary prefix in the hint, got %q", got)
+	}
+}
+
+// TestDexHandler_NotFound tests that unknown names suggest close ones.
+func TestDexHandler_NotFound(t *testing.T) {
+	router := setupDex(t)
+
+	responder := lookUp(router, "flare")
+	if len(responder.responses) != 1 || !strings.Contains(responder.responses[0].Content, "Did you mean: Flareon?") {
+		t.Errorf("Expected suggestions, got %+v", responder.responses)
+	}
+	responder = lookUp(router, "missingno")
+	if len(responder.responses) != 1 || !strings.HasSuffix(responder.responses[0].Content, "in the Pokédex.") {
+		t.Errorf("Expected the not found message, got %+v", responder.responses)
+	}
+}
+
+// TestDexHandler_Autocomplete tests the name suggestions shown while typing.
+func TestDexHandler_Autocomplete(t *testing.T) {
+	router := setupDex(t)
+
+	choices, ok := suggest(router, nil, "c1", "dex", "pokemon", "quag")
+	if !ok || len(choices) != 1 || choices[0].Name != "#195 Quagsire" || choices[0].Value != "Quagsire" {
+		t.Errorf("Expected Quagsire to be suggested, got %+v", choices)
+	}
+}
+
+// TestTypeColor tests the embed color of entries with a known, unknown or missing type.
+func TestTypeColor(t *testing.T) {
+	tests := []struct {
+		name     string
+		types    []string
+		expected int
+	}{
+		{name: "first type", types: []string{"water", "ground"}, expected: typeColors["water"]},
+		{name: "unknown type", types: []string{"shadow"}, expected: embedColor},
+		{name: "no type", expected: embedColor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := typeColor(tt.types); got != tt.expected {
+				t.Errorf("Expected %x, got %x", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/services"
+	"wooper-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxFavorites is how many images a user can keep as favorites.
+	maxFavorites = 100
+	// favoritesPageSize is how many favorites a list page shows.
+	favoritesPageSize = 10
+
+	componentFavoritesPage = "favorites-page"
+)
+
+// FavoritesHandler handles the favorites command, the images users saved with the favorite button.
+type FavoritesHandler struct {
+	Images *ImageHandler // sends favorites with the usual image embed and buttons
+	Store  *storage.Store
+}
+
+func NewFavoritesHandler(images *ImageHandler, store *storage.Store) *FavoritesHandler {
+	return &FavoritesHandler{Images: images, Store: store}
+}
+
+// Register adds the favorites command and its page buttons.
+func (h *FavoritesHandler) Register(r *commands.Router) {
+	minPage := 1.0
+	r.Register(&commands.Command{
+		Name:        "favorites",
+		Description: "Your favorite images",
+		Subcommands: []*commands.Command{
+			{
+				Name:        "list",
+				Description: "List your favorite images",
+				Options: []*commands.Option{
+					{
+						Name:        "page",
+						Description: "Page to show",
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						MinValue:    &minPage,
+					},
+				},
+				Handler: h.handleList,
+			},
+			{
+				Name:        "random",
+				Description: "Send one of your favorite images",
+				Handler:     h.handleRandom,
+			},
+			{
+				Name:        "remove",
+				Description: "Remove an image from your favorites",
+				Options: []*commands.Option{
+					{
+						Name:        "image",
+						Description: "ID of the image, as shown by /favorites list",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+						MaxLength:   20,
+					},
+				},
+				Handler: h.handleRemove,
+			},
+		},
+		RateLimits: []commands.RateLimit{
+			{Scope: commands.ScopeUser, Burst: 3, Every: 3 * time.Second},
+		},
+	})
+
+	// Page buttons carry the owner of the list, since text command replies are visible to everyone
+	r.RegisterComponent(&commands.Command{
+		Name: componentFavoritesPage,
+		Options: []*commands.Option{
+			{Name: "user", Type: discordgo.ApplicationCommandOptionString, Required: true},
+			{Name: "page", Type: discordgo.ApplicationCommandOptionInteger, Required: true},
+		},
+		Parent:  "favorites",
+		Handler: h.handlePage,
+	})
+}
+
+func (h *FavoritesHandler) handleList(c *commands.Context) error {
+	response, err := h.listPage(c, int(c.Int("page", 1))-1)
+	if err != nil {
+		return err
+	}
+	return c.Respond(response)
+}
+
+// handlePage turns the favorites list to the page whose button was pressed.
+func (h *FavoritesHandler) handlePage(c *commands.Context) error {
+	if c.String("user") != c.User.ID {
+		return c.ReplyEphemeral(c.T("favorites.not_yours"))
+	}
+	response, err := h.listPage(c, int(c.Int("page", 0)))
+	if err != nil {
+		return err
+	}
+	response.Update = true
+	return c.Respond(response)
+}
+
+// listPage renders a page of the user's favorites, newest first.
+func (h *FavoritesHandler) listPage(c *commands.Context, page int) (*commands.Response, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := h.Store.FavoriteCount(ctx, c.User.ID)
+	if err != nil {
+		return nil, services.NewError(services.ErrCodeStorage, err)
+	}
+	if count == 0 {
+		return &commands.Response{Content: c.T("favorites.none"), Ephemeral: true}, nil
+	}
+
+	pages := (count + favoritesPageSize - 1) / favoritesPageSize
+	page = min(max(page, 0), pages-1)
+	favorites, err := h.Store.Favorites(ctx, c.User.ID, favoritesPageSize, page*favoritesPageSize)
+	if err != nil {
+		return nil, services.NewError(services.ErrCodeStorage, err)
+	}
+
+	lines := make([]string, len(favorites))
+	for i, favorite := range favorites {
+		lines[i] = c.T("favorites.entry", favorite.ImageID, favorite.Category, favorite.CreatedAt.Unix())
+	}
+	response := &commands.Response{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:       c.T("favorites.title"),
+			Description: strings.Join(lines, "\n"),
+			Color:       embedColor,
+			Footer:      &discordgo.MessageEmbedFooter{Text: c.T("favorites.footer", page+1, pages, count, maxFavorites)},
+		}},
+		Ephemeral: true,
+	}
+	if pages > 1 {
+		response.Components = []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    c.T("help.previous"),
+				Style:    discordgo.SecondaryButton,
+				Emoji:    &discordgo.ComponentEmoji{Name: "◀️"},
+				CustomID: commands.CustomID(componentFavoritesPage, c.User.ID, strconv.Itoa(page-1)),
+				Disabled: page == 0,
+			},
+			discordgo.Button{
+				Label:    c.T("help.next"),
+				Style:    discordgo.SecondaryButton,
+				Emoji:    &discordgo.ComponentEmoji{Name: "▶️"},
+				CustomID: commands.CustomID(componentFavoritesPage, c.User.ID, strconv.Itoa(page+1)),
+				Disabled: page == pages-1,
+			},
+		}}}
+	}
+	c.LogFields(zap.Int("favorites", count), zap.Int("page", page+1))
+	return response, nil
+}
+
+// handleRandom sends a random favorite that is still available in the server.
+func (h *FavoritesHandler) handleRandom(c *commands.Context) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	favorites, err := h.Store.Favorites(ctx, c.User.ID, maxFavorites, 0)
+	if err != nil {
+		return services.NewError(services.ErrCodeStorage, err)
+	}
+	if len(favorites) == 0 {
+		return c.ReplyEphemeral(c.T("favorites.none"))
+	}
+
+	// Images may have been deleted, or their category disabled here, since they were saved
+	rand.Shuffle(len(favorites), func(i, j int) { favorites[i], favorites[j] = favorites[j], favorites[i] })
+	for _, favorite := range favorites {
+		imagePath, category, ok := h.Images.ImageService.ImageByID(favorite.ImageID)
+		if !ok || !c.Settings.CategoryEnabled(category) || h.Images.ImageService.IsQuarantined(imagePath) {
+			continue
+		}
+		return h.Images.serve(c, "favorites", category, imagePath)
+	}
+	return c.ReplyEphemeral(c.T("favorites.unavailable"))
+}
+
+func (h *FavoritesHandler) handleRemove(c *commands.Context) error {
+	id := strings.ToLower(strings.Trim(c.String("image"), "` "))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	removed, err := h.Store.RemoveFavorite(ctx, c.User.ID, id)
+	if err != nil {
+		return services.NewError(services.ErrCodeStorage, err)
+	}
+	if !removed {
+		return c.ReplyEphemeral(c.T("favorites.not_found", id))
+	}
+	c.LogFields(zap.String("image_id", id))
+	return c.ReplyEphemeral(c.T("favorites.removed", id))
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/services"
+	"wooper-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// setupFavorites registers the image and favorites handlers on a router backed by a test store.
+func setupFavorites(t *testing.T) (*commands.Router, *services.ImageService, *storage.Store) {
+	t.Helper()
+	_, imageService := setupTestHandler(t)
+
+	store, err := storage.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	router := commands.NewRouter(nil, nil)
+	images := NewImageHandler(imageService, store, nil)
+	images.Register(router)
+	NewFavoritesHandler(images, store).Register(router)
+	return router, imageService, store
+}
+
+// runFavorites runs a favorites subcommand as user u1, answering with responder.
+func runFavorites(router *commands.Router, responder commands.Responder, sub string, options ...*discordgo.ApplicationCommandInteractionDataOption) {
+	router.Use(func(next commands.HandlerFunc) commands.HandlerFunc {
+		return func(c *commands.Context) error {
+			c.Responder = responder
+			return next(c)
+		}
+	})
+	router.HandleInteraction(nil, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:      discordgo.InteractionApplicationCommand,
+		ChannelID: "c1",
+		User:      &discordgo.User{ID: "u1"},
+		Data: discordgo.ApplicationCommandInteractionData{
+			Name: "favorites",
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: sub, Type: discordgo.ApplicationCommandOptionSubCommand, Options: options},
+			},
+		},
+	}})
+}
+
+// TestFavoritesHandler_List tests the paginated favorites list and its owner-only buttons.
+func TestFavoritesHandler_List(t *testing.T) {
+	router, _, store := setupFavorites(t)
+
+	responder := &uploadResponder{}
+	runFavorites(router, responder, "list")
+	if len(responder.responses) != 1 || !strings.Contains(responder.responses[0].Content, "no favorites") {
+		t.Fatalf("Expected the empty list message, got %+v", responder.responses)
+	}
+
+	for i := 0; i < favoritesPageSize+3; i++ {
+		if _, err := store.AddFavorite(context.Background(), "u1", fmt.Sprintf("id%02d", i), "wooper"); err != nil {
+			t.Fatalf("Failed to add favorite: %v", err)
+		}
+	}
+
+	responder = &uploadResponder{}
+	runFavorites(router, responder, "list", &discordgo.ApplicationCommandInteractionDataOption{
+		Name: "page", Type: discordgo.ApplicationCommandOptionInteger, Value: float64(2),
+	})
+	embed := responder.responses[0].Embeds[0]
+	if strings.Count(embed.Description, "\n") != 2 || embed.Footer.Text != fmt.Sprintf("Page 2 of 2 · 13/%d favorites", maxFavorites) {
+		t.Fatalf("Expected the 3 oldest favorites on page 2, got %q (%s)", embed.Description, embed.Footer.Text)
+	}
+	previous := responder.responses[0].Components[0].(discordgo.ActionsRow).Components[0].(discordgo.Button)
+
+	// Pressed by its owner, the button turns the page
+	responder = &uploadResponder{}
+	pressButton(router, responder, previous.CustomID)
+	if len(responder.responses) != 1 || !responder.responses[0].Update || strings.Count(responder.responses[0].Embeds[0].Description, "\n") != favoritesPageSize-1 {
+		t.Fatalf("Expected the first page, got %+v", responder.responses)
+	}
+
+	// Pressed by someone else, it doesn't
+	responder = &uploadResponder{}
+	pressButton(router, responder, commands.CustomID(componentFavoritesPage, "u2", "0"))
+	if len(responder.responses) != 1 || responder.responses[0].Update {
+		t.Errorf("Expected a refusal, got %+v", responder.responses)
+	}
+}
+
+// TestFavoritesHandler_RandomAndRemove tests sending and removing saved favorites.
+func TestFavoritesHandler_RandomAndRemove(t *testing.T) {
+	router, imageService, _ := setupFavorites(t)
+
+	id := imageService.ImageID(imageService.GetCategoryImages("cats")[0])
+	pressButton(router, &uploadResponder{}, commands.CustomID(componentFavorite, id))
+
+	responder := &uploadResponder{}
+	runFavorites(router, responder, "random")
+	if len(responder.files) != 1 || responder.files[0] != id+".jpg" {
+		t.Fatalf("Expected the favorite %s sent, got %v", id, responder.files)
+	}
+
+	tests := []struct {
+		name     string
+		image    string
+		expected string
+	}{
+		{name: "removed", image: "`" + strings.ToUpper(id) + "`", expected: "Removed `" + id + "` from your favorites."},
+		{name: "already removed", image: id, expected: "`" + id + "` is not in your favorites."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responder := &uploadResponder{}
+			runFavorites(router, responder, "remove", &discordgo.ApplicationCommandInteractionDataOption{
+				Name: "image", Type: discordgo.ApplicationCommandOptionString, Value: tt.image,
+			})
+			if len(responder.responses) != 1 || responder.responses[0].Content != tt.expected {
+				t.Errorf("Expected %q, got %+v", tt.expected, responder.responses)
+			}
+		})
+	}
+
+	responder = &uploadResponder{}
+	runFavorites(router, responder, "random")
+	if len(responder.files) != 0 || len(responder.responses) != 1 {
+		t.Errorf("Expected no image without favorites, got %v", responder.files)
+	}
+}
+
+// TestFavoritesHandler_RandomRejected tests that a favorite Discord rejects counts towards its
+// quarantine and another image is sent instead.
+func TestFavoritesHandler_RandomRejected(t *testing.T) {
+	router, imageService, _ := setupFavorites(t)
+
+	imagePath := imageService.GetCategoryImages("cats")[0]
+	id := imageService.ImageID(imagePath)
+	pressButton(router, &uploadResponder{}, commands.CustomID(componentFavorite, id))
+
+	tooLarge := &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusRequestEntityTooLarge}}
+	for i := 0; i < services.QuarantineThreshold; i++ {
+		responder := &uploadResponder{failures: 1, err: tooLarge}
+		runFavorites(router, responder, "random")
+		if len(responder.files) != 2 || len(responder.responses) != 1 {
+			t.Fatalf("Expected another image sent after the rejected favorite, got %v", responder.files)
+		}
+	}
+	if !imageService.IsQuarantined(imagePath) {
+		t.Errorf("Expected %s quarantined after %d rejections", id, services.QuarantineThreshold)
+	}
+}
+
+// TestImageHandler_FavoriteLimit tests that the favorite button stops at maxFavorites.
+func TestImageHandler_FavoriteLimit(t *testing.T) {
+	router, imageService, store := setupFavorites(t)
+	for i := 0; i < maxFavorites; i++ {
+		if _, err := store.AddFavorite(context.Background(), "u1", fmt.Sprintf("id%03d", i), "wooper"); err != nil {
+			t.Fatalf("Failed to add favorite: %v", err)
+		}
+	}
+
+	responder := &uploadResponder{}
+	pressButton(router, responder, commands.CustomID(componentFavorite, imageService.ImageID(imageService.GetRandomImage("cats"))))
+	if len(responder.responses) != 1 || !strings.Contains(responder.responses[0].Content, "already have 100 favorites") {
+		t.Errorf("Expected the limit message, got %+v", responder.responses)
+	}
+}
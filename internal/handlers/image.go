@@ -0,0 +1,470 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"wooper-bot/internal/alerts"
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/i18n"
+	"wooper-bot/internal/logger"
+	"wooper-bot/internal/outbound"
+	"wooper-bot/internal/services"
+	"wooper-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// Names of the buttons under every image, used as the prefix of their custom IDs.
+const (
+	componentAnother  = "another"
+	componentFavorite = "favorite"
+	componentReport   = "report"
+	componentHelpPage = "help-page"
+)
+
+// embedColor is the accent color of image embeds.
+const embedColor = 0x5fa8d3
+
+// ImageHandler serves random images through /image, !image and the per-category shortcuts like !wooper.
+type ImageHandler struct {
+	ImageService *services.ImageService
+	Store        *storage.Store
+	Alerts       *alerts.Notifier // receives reported images, may be nil
+}
+
+func NewImageHandler(imageService *services.ImageService, store *storage.Store, notifier *alerts.Notifier) *ImageHandler {
+	return &ImageHandler{ImageService: imageService, Store: store, Alerts: notifier}
+}
+
+// Register adds the image and help commands, a text shortcut for every category and the
+// buttons shown under images.
+func (h *ImageHandler) Register(r *commands.Router) {
+	image := &commands.Command{
+		Name:        "image",
+		Description: "Get a random image from a category",
+		Options: []*commands.Option{
+			{
+				Name:         "category",
+				Description:  "Image category to get a random image from (defaults to the server default)",
+				Type:         discordgo.ApplicationCommandOptionString,
+				Autocomplete: categoryAutocomplete(h.ImageService),
+			},
+		},
+		// Enough for a few images in a row, not for flooding a channel or our Discord quota
+		RateLimits: []commands.RateLimit{
+			{Scope: commands.ScopeUser, Burst: 3, Every: 3 * time.Second},
+			{Scope: commands.ScopeChannel, Burst: 5, Every: 2 * time.Second},
+			{Scope: commands.ScopeGuild, Burst: 10, Every: time.Second},
+		},
+		Handler: h.handleImage,
+	}
+	help := &commands.Command{
+		Name:        "help",
+		Description: "List the available image categories",
+		Handler:     h.handleHelp,
+	}
+	r.Register(image, help)
+
+	for _, category := range h.ImageService.GetAvailableCategories() {
+		r.Alias(category, image, category)
+	}
+	// A bare prefix or mention sends an image from the default category
+	r.Alias("", image)
+	r.Alias("list", help)
+
+	h.registerBrowse(r)
+
+	// Buttons only carry the image ID: it can't break the custom ID format, and the
+	// category is found from it
+	imageOption := []*commands.Option{{Name: "image", Type: discordgo.ApplicationCommandOptionString, Required: true}}
+	r.RegisterComponent(
+		&commands.Command{
+			Name:       componentAnother,
+			Options:    imageOption,
+			RateLimits: image.RateLimits,
+			Parent:     image.Name,
+			Handler:    h.handleAnother,
+		},
+		&commands.Command{
+			Name:    componentFavorite,
+			Options: imageOption,
+			Parent:  "favorites",
+			Handler: h.handleFavorite,
+		},
+		&commands.Command{
+			Name:       componentReport,
+			Options:    imageOption,
+			Parent:     image.Name,
+			RateLimits: []commands.RateLimit{{Scope: commands.ScopeUser, Burst: 3, Every: time.Minute}},
+			Handler:    h.handleReport,
+		},
+		&commands.Command{
+			Name: componentHelpPage,
+			Options: []*commands.Option{
+				{Name: "page", Type: discordgo.ApplicationCommandOptionInteger, Required: true},
+				{Name: "transport", Type: discordgo.ApplicationCommandOptionString, Required: true},
+			},
+			Parent:  help.Name,
+			Handler: h.handleHelpPage,
+		},
+	)
+}
+
+func (h *ImageHandler) handleImage(c *commands.Context) error {
+	category := c.String("category")
+	if category == "" {
+		category = c.Settings.DefaultCategory
+	}
+	if category == "" && c.Message != nil && strings.TrimSpace(c.Message.Content) == c.Prefix {
+		// A lone prefix is usually not meant for the bot, stay quiet. !image itself gets told
+		// to choose a category like /image does.
+		return nil
+	}
+
+	if !h.ImageService.HasCategory(category) || !c.Settings.CategoryEnabled(category) {
+		availableCategories := c.Settings.FilterCategories(h.ImageService.GetAvailableCategories())
+		message := c.T("image.category_not_found", category, strings.Join(availableCategories, ", "))
+		if category == "" {
+			message = c.T("image.choose_category", strings.Join(availableCategories, ", "))
+		}
+
+		logger.Logger.Warn("Invalid category requested",
+			zap.String("category", category),
+			zap.Strings("available_categories", availableCategories),
+			zap.String("user", c.User.Username))
+		return c.Error(message)
+	}
+
+	return h.serve(c, "image", category, h.pickImage(c, category))
+}
+
+// pickImage picks a random image of category, favoring well rated images when the server
+// enabled weighted selection.
+func (h *ImageHandler) pickImage(c *commands.Context, category string) string {
+	if !c.Settings.WeightedSelection || h.Store == nil {
+		return h.ImageService.GetRandomImage(category)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	scores, err := h.Store.ImageScores(ctx, category)
+	if err != nil {
+		logger.Logger.Warn("Failed to load image scores, picking uniformly",
+			zap.String("category", category),
+			zap.Error(err))
+		return h.ImageService.GetRandomImage(category)
+	}
+	return h.ImageService.GetWeightedRandomImage(category, scores)
+}
+
+// handleAnother sends another image from the category of the image whose button was pressed.
+func (h *ImageHandler) handleAnother(c *commands.Context) error {
+	previous, category, ok := h.ImageService.ImageByID(c.String("image"))
+	if !ok || !c.Settings.CategoryEnabled(category) {
+		return c.ReplyEphemeral(c.T("image.gone"))
+	}
+	return h.serve(c, "image", category, h.ImageService.GetRandomImageExcluding(category, []string{previous}))
+}
+
+// serve sends imagePath, picked from category, and falls back to other images of the
+// category when the file can't be sent. Sent images are recorded as uses of command.
+func (h *ImageHandler) serve(c *commands.Context, command, category, imagePath string) error {
+	if imagePath == "" {
+		logger.Logger.Warn("No images available for category",
+			zap.String("category", category),
+			zap.String("user", c.User.Username))
+		return c.Error(c.T("image.none_available", category))
+	}
+
+	// Loading and uploading can take a while, acknowledge first
+	if err := c.Defer(false); err != nil {
+		logger.Logger.Warn("Failed to acknowledge command", zap.Error(err))
+	}
+
+	// Transient errors are already retried when sending. When the file itself is the problem,
+	// another image of the category is tried instead and the failure counts towards quarantine.
+	var tried []string
+	for {
+		fileName, fileProblem, err := h.sendImage(c, category, imagePath)
+		if err == nil {
+			h.ImageService.ReportSuccess(imagePath)
+			c.LogFields(zap.String("category", category), zap.String("filename", fileName), zap.Int("attempts", len(tried)+1))
+			recordUsage(c, h.Store, storage.Usage{
+				Command:  command,
+				Category: category,
+				Image:    fileName,
+				ImageID:  h.ImageService.ImageID(imagePath),
+			})
+			return nil
+		}
+
+		logger.Logger.Error("Failed to send image",
+			zap.String("category", category),
+			zap.String("image_path", imagePath),
+			zap.String("user", c.User.Username),
+			zap.Bool("file_problem", fileProblem),
+			zap.Error(err))
+		if !fileProblem {
+			return err
+		}
+
+		h.ImageService.ReportFailure(imagePath, err)
+		tried = append(tried, imagePath)
+		next := h.ImageService.GetRandomImageExcluding(category, tried)
+		if len(tried) >= maxImageAttempts || next == "" || slices.Contains(tried, next) {
+			return err
+		}
+		imagePath = next
+	}
+}
+
+// maxImageAttempts is how many different images one command tries before giving up.
+const maxImageAttempts = 3
+
+// sendImage uploads imagePath as the command's reply. fileProblem reports whether the error
+// is caused by the file, so sending another image may work. Errors carry a catalog code.
+func (h *ImageHandler) sendImage(c *commands.Context, category, imagePath string) (fileName string, fileProblem bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	reader, fileName, err := h.ImageService.GetImageFile(ctx, imagePath)
+	if err != nil {
+		return "", true, services.NewError(services.ErrCodeImageLoad, err)
+	}
+	defer reader.Close()
+
+	id := h.ImageService.ImageID(imagePath)
+	file := imageAttachment(id, fileName, reader)
+
+	response := imageResponse(c, category, id, h.ImageService.GetImageCount(category), file)
+	if err := c.Respond(response); err != nil {
+		return fileName, outbound.IsFileRejected(err), services.NewError(services.ErrCodeImageUpload, err)
+	}
+	if response.Sent != nil && h.Store != nil {
+		// Reactions to the message count as votes on the image
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := h.Store.RecordImagePost(ctx, response.Sent.ID, id, category); err != nil {
+			logger.Logger.Warn("Failed to record image post",
+				zap.String("message_id", response.Sent.ID),
+				zap.String("image_id", id),
+				zap.Error(err))
+		}
+	}
+	return fileName, false, nil
+}
+
+// imageAttachment names an image upload after its ID. Embeds refer to attachments by name,
+// and unlike file names IDs are always safe in a URL.
+func imageAttachment(id, fileName string, reader io.Reader) *discordgo.File {
+	return &discordgo.File{Name: id + strings.ToLower(filepath.Ext(fileName)), Reader: reader}
+}
+
+// imageResponse shows an image in an embed, with buttons for another image of the category,
+// adding it to the user's favorites and reporting it to the bot admins.
+func imageResponse(c *commands.Context, category, id string, count int, file *discordgo.File) *commands.Response {
+	return &commands.Response{
+		Files: []*discordgo.File{file},
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:  category,
+			Color:  embedColor,
+			Image:  &discordgo.MessageEmbedImage{URL: "attachment://" + file.Name},
+			Footer: &discordgo.MessageEmbedFooter{Text: c.T("image.footer", id, count)},
+		}},
+		Components: []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    c.T("image.another"),
+				Style:    discordgo.PrimaryButton,
+				Emoji:    &discordgo.ComponentEmoji{Name: "🔁"},
+				CustomID: commands.CustomID(componentAnother, id),
+			},
+			discordgo.Button{
+				Label:    c.T("image.favorite"),
+				Style:    discordgo.SecondaryButton,
+				Emoji:    &discordgo.ComponentEmoji{Name: "⭐"},
+				CustomID: commands.CustomID(componentFavorite, id),
+			},
+			discordgo.Button{
+				Label:    c.T("image.report"),
+				Style:    discordgo.DangerButton,
+				Emoji:    &discordgo.ComponentEmoji{Name: "🚩"},
+				CustomID: commands.CustomID(componentReport, id),
+			},
+		}}},
+	}
+}
+
+// handleFavorite adds the image whose button was pressed to the user's favorites.
+func (h *ImageHandler) handleFavorite(c *commands.Context) error {
+	id := c.String("image")
+	_, category, ok := h.ImageService.ImageByID(id)
+	if !ok {
+		return c.ReplyEphemeral(c.T("image.gone"))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := h.Store.FavoriteCount(ctx, c.User.ID)
+	if err != nil {
+		return services.NewError(services.ErrCodeStorage, err)
+	}
+	if count >= maxFavorites {
+		return c.ReplyEphemeral(c.T("favorites.full", maxFavorites))
+	}
+
+	added, err := h.Store.AddFavorite(ctx, c.User.ID, id, category)
+	if err != nil {
+		return services.NewError(services.ErrCodeStorage, err)
+	}
+	c.LogFields(zap.String("image_id", id), zap.Bool("added", added))
+	if !added {
+		return c.ReplyEphemeral(c.T("image.already_favorite"))
+	}
+	return c.ReplyEphemeral(c.T("image.favorited"))
+}
+
+// handleReport tells the bot admins about an image a user flagged.
+func (h *ImageHandler) handleReport(c *commands.Context) error {
+	id := c.String("image")
+	imagePath, category, ok := h.ImageService.ImageByID(id)
+	if !ok {
+		return c.ReplyEphemeral(c.T("image.gone"))
+	}
+
+	c.LogFields(zap.String("image_id", id), zap.String("image_path", imagePath))
+	if h.Alerts != nil {
+		h.Alerts.Notify(fmt.Sprintf("Image `%s/%s` (ID `%s`) was reported by <@%s> in <#%s>",
+			category, filepath.Base(imagePath), id, c.User.ID, c.ChannelID))
+	}
+	return c.ReplyEphemeral(c.T("image.reported"))
+}
+
+func (h *ImageHandler) handleHelp(c *commands.Context) error {
+	categories := h.helpCategories(c)
+	if len(categories) == 0 {
+		logger.Logger.Warn("No categories available for help",
+			zap.String("user", c.User.Username))
+		return c.Error(c.T("help.no_categories"))
+	}
+
+	c.LogFields(zap.Int("categories_count", len(categories)))
+
+	if !c.CanEmbed() {
+		return c.ReplyLong(h.helpMessage(c.Locale(), c.Transport, c.Settings.PrimaryPrefix(), categories))
+	}
+	return c.Respond(h.helpPage(c, c.Transport, categories, 0))
+}
+
+// handleHelpPage turns the help message to the page whose button was pressed.
+func (h *ImageHandler) handleHelpPage(c *commands.Context) error {
+	categories := h.helpCategories(c)
+	if len(categories) == 0 {
+		return c.ReplyEphemeral(c.T("help.no_categories"))
+	}
+
+	page := h.helpPage(c, commands.Transport(c.String("transport")), categories, int(c.Int("page", 0)))
+	page.Update = true
+	return c.Respond(page)
+}
+
+// helpCategories returns the categories enabled in the guild, sorted so pages stay stable.
+func (h *ImageHandler) helpCategories(c *commands.Context) []string {
+	categories := c.Settings.FilterCategories(h.ImageService.GetAvailableCategories())
+	slices.Sort(categories)
+	return categories
+}
+
+// helpPageSize is how many categories a help page lists.
+const helpPageSize = 8
+
+// helpPage renders one page of categories as an embed, with buttons to the other pages.
+// transport is the one help was asked from, so examples keep its syntax on every page.
+func (h *ImageHandler) helpPage(c *commands.Context, transport commands.Transport, categories []string, page int) *commands.Response {
+	pages := (len(categories) + helpPageSize - 1) / helpPageSize
+	page = min(max(page, 0), pages-1)
+
+	embed := &discordgo.MessageEmbed{
+		Title:  c.T("help.embed_title"),
+		Color:  embedColor,
+		Footer: &discordgo.MessageEmbedFooter{Text: c.T("help.page", page+1, pages)},
+	}
+	for _, category := range categories[page*helpPageSize : min((page+1)*helpPageSize, len(categories))] {
+		value := c.T("help.example", categoryUsage(transport, c.Settings.PrimaryPrefix(), category))
+		if description := h.ImageService.GetCategoryDescription(category); description != "" {
+			value = description + "\n" + value
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  c.T("help.field", category, h.ImageService.GetImageCount(category)),
+			Value: value,
+		})
+	}
+
+	response := &commands.Response{Embeds: []*discordgo.MessageEmbed{embed}}
+	if pages > 1 {
+		response.Components = []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    c.T("help.previous"),
+				Style:    discordgo.SecondaryButton,
+				Emoji:    &discordgo.ComponentEmoji{Name: "◀️"},
+				CustomID: commands.CustomID(componentHelpPage, strconv.Itoa(page-1), string(transport)),
+				Disabled: page == 0,
+			},
+			discordgo.Button{
+				Label:    c.T("help.next"),
+				Style:    discordgo.SecondaryButton,
+				Emoji:    &discordgo.ComponentEmoji{Name: "▶️"},
+				CustomID: commands.CustomID(componentHelpPage, strconv.Itoa(page+1), string(transport)),
+				Disabled: page == pages-1,
+			},
+		}}}
+	}
+	return response
+}
+
+// helpMessage lists categories as plain text, for channels where the bot can't send embeds.
+func (h *ImageHandler) helpMessage(locale string, transport commands.Transport, prefix string, categories []string) string {
+	var b strings.Builder
+	b.WriteString(i18n.T(locale, "help.title") + "\n")
+	for _, category := range categories {
+		b.WriteString(i18n.T(locale, "help.category", categoryUsage(transport, prefix, category), h.ImageService.GetImageCount(category)) + "\n")
+	}
+	return b.String()
+}
+
+// categoryUsage shows how to get an image of category with the syntax of transport.
+func categoryUsage(transport commands.Transport, prefix, category string) string {
+	if transport == commands.TransportText {
+		return prefix + category
+	}
+	return "/image category:" + category
+}
+
+// categoryAutocomplete suggests the categories starting with what was typed that the guild
+// enabled and the member may use. Categories are autocompleted rather than listed as choices,
+// Discord refuses more than 25 choices.
+func categoryAutocomplete(imageService *services.ImageService) commands.AutocompleteFunc {
+	return func(c *commands.Context, value string) []*discordgo.ApplicationCommandOptionChoice {
+		value = strings.ToLower(strings.TrimSpace(value))
+		var choices []*discordgo.ApplicationCommandOptionChoice
+		for _, category := range imageService.GetAvailableCategories() {
+			if c.Settings.CategoryEnabled(category) && strings.HasPrefix(strings.ToLower(category), value) {
+				choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: category, Value: category})
+			}
+		}
+		// Sorted, so the suggestions kept past Discord's limit don't change between keystrokes
+		slices.SortFunc(choices, func(a, b *discordgo.ApplicationCommandOptionChoice) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+		return choices
+	}
+}
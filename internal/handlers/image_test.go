@@ -0,0 +1,505 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/services"
+	"wooper-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// TestImageHandler_Register tests the commands and text shortcuts added by the image handler.
+func TestImageHandler_Register(t *testing.T) {
+	_, imageService := setupTestHandler(t)
+
+	router := commands.NewRouter(nil, nil)
+	NewImageHandler(imageService, nil, nil).Register(router)
+
+	var names []string
+	for _, cmd := range router.Commands() {
+		names = append(names, cmd.Name)
+	}
+	if strings.Join(names, ",") != "image,help,browse" {
+		t.Errorf("Expected image, help and browse commands, got %v", names)
+	}
+
+	tests := []struct {
+		name         string
+		invocation   string
+		expectedCmd  string
+		expectedArgs []string
+	}{
+		{name: "category shortcut", invocation: "cats", expectedCmd: "image", expectedArgs: []string{"cats"}},
+		{name: "bare prefix", invocation: "", expectedCmd: "image"},
+		{name: "explicit command", invocation: "image", expectedCmd: "image"},
+		{name: "list alias", invocation: "list", expectedCmd: "help"},
+		{name: "unknown", invocation: "dogs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, args := router.Resolve(commands.Invocation{Name: tt.invocation})
+			if tt.expectedCmd == "" {
+				if cmd != nil {
+					t.Errorf("Expected no command, got %s", cmd.Name)
+				}
+				return
+			}
+			if cmd == nil || cmd.Name != tt.expectedCmd {
+				t.Fatalf("Expected command %s, got %v", tt.expectedCmd, cmd)
+			}
+			if strings.Join(args, " ") != strings.Join(tt.expectedArgs, " ") {
+				t.Errorf("Expected args %v, got %v", tt.expectedArgs, args)
+			}
+		})
+	}
+}
+
+// TestImageHandler_HelpMessage tests that help shows the syntax of the transport it was asked from.
+func TestImageHandler_HelpMessage(t *testing.T) {
+	_, imageService := setupTestHandler(t)
+	handler := NewImageHandler(imageService, nil, nil)
+
+	text := handler.helpMessage("en", commands.TransportText, "w!", []string{"wooper"})
+	if !strings.Contains(text, "`w!wooper` (2 images)") {
+		t.Errorf("Expected text syntax in %q", text)
+	}
+
+	slash := handler.helpMessage("en", commands.TransportSlash, "w!", []string{"wooper"})
+	if !strings.Contains(slash, "`/image category:wooper` (2 images)") {
+		t.Errorf("Expected slash syntax in %q", slash)
+	}
+}
+
+// TestCategoryAutocomplete tests suggesting the categories starting with what was typed,
+// leaving out those the guild disabled or the member may not use.
+func TestCategoryAutocomplete(t *testing.T) {
+	_, imageService := setupTestHandler(t)
+	autocomplete := categoryAutocomplete(imageService)
+
+	tests := []struct {
+		value    string
+		enabled  []string
+		denied   []string
+		expected []string
+	}{
+		{value: "", expected: []string{"cats", "wooper"}},
+		{value: "Woo", expected: []string{"wooper"}},
+		{value: " c", expected: []string{"cats"}},
+		{value: "quag"},
+		{value: "", enabled: []string{"wooper"}, expected: []string{"wooper"}},
+		{value: "", denied: []string{"wooper"}, expected: []string{"cats"}},
+	}
+	for _, tt := range tests {
+		c := imageContext(nil)
+		c.Settings.EnabledCategories, c.Settings.DeniedCategories = tt.enabled, tt.denied
+		var got []string
+		for _, choice := range autocomplete(c, tt.value) {
+			if choice.Name != choice.Value {
+				t.Errorf("Expected choice name and value to match, got %s and %v", choice.Name, choice.Value)
+			}
+			got = append(got, choice.Name)
+		}
+		if !slices.Equal(got, tt.expected) {
+			t.Errorf("autocomplete(%q) = %v, want %v", tt.value, got, tt.expected)
+		}
+	}
+}
+
+// TestManyCategories tests that commands still register with more categories than Discord
+// accepts as choices.
+func TestManyCategories(t *testing.T) {
+	dir := t.TempDir()
+	for i := range 30 {
+		category := filepath.Join(dir, fmt.Sprintf("category%02d", i))
+		if err := os.MkdirAll(category, 0755); err != nil {
+			t.Fatalf("Failed to create test directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(category, "1.jpg"), pngHeader, 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	imageService, err := services.NewImageService(dir)
+	if err != nil {
+		t.Fatalf("Failed to create image service: %v", err)
+	}
+
+	router := commands.NewRouter(nil, nil)
+	NewImageHandler(imageService, nil, nil).Register(router)
+	for _, cmd := range router.ApplicationCommands() {
+		for _, opt := range cmd.Options {
+			if len(opt.Choices) > 25 {
+				t.Errorf("/%s %s has %d choices", cmd.Name, opt.Name, len(opt.Choices))
+			}
+		}
+	}
+
+	choices, ok := suggest(router, nil, "c1", "image", "category", "")
+	if !ok || len(choices) != 25 {
+		t.Errorf("Expected 25 category suggestions, got %d", len(choices))
+	}
+}
+
+// suggest returns the router's suggestions for option of the slash command name, as
+// member types value in channelID.
+func suggest(router *commands.Router, member *discordgo.Member, channelID, name, option, value string) ([]*discordgo.ApplicationCommandOptionChoice, bool) {
+	return router.Autocomplete(&discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:      discordgo.InteractionApplicationCommandAutocomplete,
+		GuildID:   "g1",
+		ChannelID: channelID,
+		Member:    member,
+		Data: discordgo.ApplicationCommandInteractionData{Name: name, Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: option, Type: discordgo.ApplicationCommandOptionString, Value: value, Focused: true},
+		}},
+	}})
+}
+
+// uploadResponder fails the first uploads with err and records what was sent.
+type uploadResponder struct {
+	failures  int
+	err       error
+	files     []string
+	responses []*commands.Response
+}
+
+func (u *uploadResponder) Defer(ephemeral bool) error { return nil }
+
+func (u *uploadResponder) Respond(r *commands.Response) error {
+	if len(r.Files) > 0 {
+		u.files = append(u.files, r.Files[0].Name)
+		if len(u.files) <= u.failures {
+			return u.err
+		}
+	}
+	u.responses = append(u.responses, r)
+	r.Sent = &discordgo.Message{ID: fmt.Sprintf("m%d", len(u.responses))}
+	return nil
+}
+
+func imageContext(responder commands.Responder) *commands.Context {
+	settings := services.DefaultGuildSettings()
+	settings.DefaultCategory = "wooper"
+	return &commands.Context{
+		Responder: responder,
+		Command:   &commands.Command{Name: "image"},
+		Transport: commands.TransportSlash,
+		User:      &discordgo.User{ID: "u1", Username: "user"},
+		Settings:  settings,
+	}
+}
+
+// TestImageHandler_NoCategory tests answers to text commands without a category when the
+// server has no default category.
+func TestImageHandler_NoCategory(t *testing.T) {
+	_, imageService := setupTestHandler(t)
+	handler := NewImageHandler(imageService, nil, nil)
+
+	tests := []struct {
+		name     string
+		content  string
+		expected int
+	}{
+		{"image command", "!image", 1},
+		{"lone prefix", "!", 0},
+		{"lone prefix with spaces", " ! ", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responder := &uploadResponder{}
+			c := imageContext(responder)
+			c.Settings.DefaultCategory = ""
+			c.Transport = commands.TransportText
+			c.Prefix = "!"
+			c.Message = &discordgo.MessageCreate{Message: &discordgo.Message{Content: tt.content}}
+
+			if err := handler.handleImage(c); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(responder.responses) != tt.expected {
+				t.Fatalf("Expected %d replies, got %+v", tt.expected, responder.responses)
+			}
+			if tt.expected > 0 && !strings.HasPrefix(responder.responses[0].Content, "Please choose a category.") {
+				t.Errorf("Expected the category list, got %q", responder.responses[0].Content)
+			}
+		})
+	}
+}
+
+// TestImageHandler_UploadFallback tests that rejected files are swapped for another image and
+// counted towards quarantine, while other errors are returned without trying more images.
+func TestImageHandler_UploadFallback(t *testing.T) {
+	tooLarge := &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusRequestEntityTooLarge}}
+
+	tests := []struct {
+		name          string
+		failures      int
+		err           error
+		expectedFiles int
+		expectError   bool
+	}{
+		{name: "success", expectedFiles: 1},
+		{name: "file rejected then other image", failures: 1, err: tooLarge, expectedFiles: 2},
+		{name: "every image rejected", failures: 5, err: tooLarge, expectedFiles: 2, expectError: true},
+		{name: "other error", failures: 1, err: errors.New("connection reset"), expectedFiles: 1, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, imageService := setupTestHandler(t)
+			handler := NewImageHandler(imageService, nil, nil)
+
+			responder := &uploadResponder{failures: tt.failures, err: tt.err}
+			err := handler.handleImage(imageContext(responder))
+
+			if len(responder.files) != tt.expectedFiles {
+				t.Fatalf("Expected %d uploads, got %v", tt.expectedFiles, responder.files)
+			}
+			if tt.expectedFiles == 2 && responder.files[0] == responder.files[1] {
+				t.Errorf("Expected a different image on retry, got %v", responder.files)
+			}
+			if !tt.expectError {
+				if err != nil || len(responder.responses) != 1 {
+					t.Errorf("Expected one image reply, got %v and %d replies", err, len(responder.responses))
+				}
+				return
+			}
+			// The router answers failures from the error catalog
+			if code := services.CodeOf(err); code != services.ErrCodeImageUpload {
+				t.Errorf("Expected an %s error, got %v", services.ErrCodeImageUpload, err)
+			}
+			if len(responder.responses) != 0 {
+				t.Errorf("Expected no reply from the handler, got %+v", responder.responses)
+			}
+		})
+	}
+}
+
+// TestImageHandler_Quarantine tests that an image failing repeatedly is no longer picked.
+func TestImageHandler_Quarantine(t *testing.T) {
+	_, imageService := setupTestHandler(t)
+	handler := NewImageHandler(imageService, nil, nil)
+
+	var quarantined []string
+	imageService.OnQuarantine(func(path string, reason error) {
+		quarantined = append(quarantined, path)
+	})
+
+	tooLarge := &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusRequestEntityTooLarge}}
+	for i := 0; i < services.QuarantineThreshold; i++ {
+		_ = handler.handleImage(imageContext(&uploadResponder{failures: 2, err: tooLarge}))
+	}
+
+	if len(quarantined) != 2 || len(imageService.Quarantined()) != 2 {
+		t.Fatalf("Expected both wooper images quarantined, got %v", quarantined)
+	}
+	if image := imageService.GetRandomImage("wooper"); image != "" {
+		t.Errorf("Expected no image left to pick, got %s", image)
+	}
+}
+
+// TestImageHandler_Embed tests that images are sent in an embed with buttons carrying the image ID.
+func TestImageHandler_Embed(t *testing.T) {
+	_, imageService := setupTestHandler(t)
+	handler := NewImageHandler(imageService, nil, nil)
+
+	responder := &uploadResponder{}
+	if err := handler.handleImage(imageContext(responder)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(responder.responses) != 1 {
+		t.Fatalf("Expected one reply, got %d", len(responder.responses))
+	}
+	response := responder.responses[0]
+
+	if len(response.Embeds) != 1 || response.Embeds[0].Title != "wooper" {
+		t.Fatalf("Expected a wooper embed, got %+v", response.Embeds)
+	}
+	file := response.Files[0].Name
+	if response.Embeds[0].Image.URL != "attachment://"+file {
+		t.Errorf("Expected the embed to show %s, got %s", file, response.Embeds[0].Image.URL)
+	}
+
+	id := strings.TrimSuffix(file, ".jpg")
+	if _, category, ok := imageService.ImageByID(id); !ok || category != "wooper" {
+		t.Fatalf("Expected the attachment to be named after a wooper image ID, got %s", file)
+	}
+
+	row := response.Components[0].(discordgo.ActionsRow)
+	var customIDs []string
+	for _, component := range row.Components {
+		customIDs = append(customIDs, component.(discordgo.Button).CustomID)
+	}
+	expected := []string{"another:" + id, "favorite:" + id, "report:" + id}
+	if strings.Join(customIDs, " ") != strings.Join(expected, " ") {
+		t.Errorf("Expected buttons %v, got %v", expected, customIDs)
+	}
+}
+
+// pressButton runs the component of customID through router, answering with responder.
+func pressButton(router *commands.Router, responder commands.Responder, customID string) {
+	router.Use(func(next commands.HandlerFunc) commands.HandlerFunc {
+		return func(c *commands.Context) error {
+			c.Responder = responder
+			return next(c)
+		}
+	})
+	router.HandleComponent(nil, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:      discordgo.InteractionMessageComponent,
+		GuildID:   "g1",
+		ChannelID: "c1",
+		Member:    &discordgo.Member{User: &discordgo.User{ID: "u1", Username: "user"}},
+		Data:      discordgo.MessageComponentInteractionData{CustomID: customID, ComponentType: discordgo.ButtonComponent},
+	}})
+}
+
+// TestImageHandler_Buttons tests the another, favorite and report buttons.
+func TestImageHandler_Buttons(t *testing.T) {
+	_, imageService := setupTestHandler(t)
+	store, err := storage.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	id := imageService.ImageID(imageService.GetRandomImage("wooper"))
+
+	t.Run("another sends a different image", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			router := commands.NewRouter(nil, nil)
+			NewImageHandler(imageService, store, nil).Register(router)
+
+			responder := &uploadResponder{}
+			pressButton(router, responder, commands.CustomID("another", id))
+			if len(responder.files) != 1 || responder.files[0] == id+".jpg" {
+				t.Fatalf("Expected another image than %s, got %v", id, responder.files)
+			}
+		}
+	})
+
+	t.Run("favorite once", func(t *testing.T) {
+		router := commands.NewRouter(nil, nil)
+		NewImageHandler(imageService, store, nil).Register(router)
+
+		expected := []string{"Added to your favorites.", "That image is already in your favorites."}
+		for _, message := range expected {
+			responder := &uploadResponder{}
+			pressButton(router, responder, commands.CustomID("favorite", id))
+			if len(responder.responses) != 1 || responder.responses[0].Content != message || !responder.responses[0].Ephemeral {
+				t.Errorf("Expected the ephemeral reply %q, got %+v", message, responder.responses)
+			}
+		}
+	})
+
+	t.Run("unknown image", func(t *testing.T) {
+		router := commands.NewRouter(nil, nil)
+		NewImageHandler(imageService, store, nil).Register(router)
+
+		responder := &uploadResponder{}
+		pressButton(router, responder, commands.CustomID("report", "0000000000"))
+		if len(responder.responses) != 1 || responder.responses[0].Content != "That image is no longer available." {
+			t.Errorf("Expected the image to be gone, got %+v", responder.responses)
+		}
+	})
+}
+
+// setupManyCategories creates an image service with count categories of one image each.
+func setupManyCategories(t *testing.T, count int) *services.ImageService {
+	t.Helper()
+	setupTestHandler(t)
+
+	dir := t.TempDir()
+	for i := 0; i < count; i++ {
+		category := filepath.Join(dir, fmt.Sprintf("category%02d", i))
+		if err := os.MkdirAll(category, 0755); err != nil {
+			t.Fatalf("Failed to create category: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(category, "image.png"), pngHeader, 0644); err != nil {
+			t.Fatalf("Failed to create image: %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "category00", services.DescriptionFile), []byte("The first one"), 0644); err != nil {
+		t.Fatalf("Failed to create description: %v", err)
+	}
+
+	imageService, err := services.NewImageService(dir)
+	if err != nil {
+		t.Fatalf("Failed to create image service: %v", err)
+	}
+	return imageService
+}
+
+// TestImageHandler_HelpPages tests that help lists categories on pages turned with buttons.
+func TestImageHandler_HelpPages(t *testing.T) {
+	imageService := setupManyCategories(t, helpPageSize+2)
+	handler := NewImageHandler(imageService, nil, nil)
+
+	responder := &uploadResponder{}
+	if err := handler.handleHelp(imageContext(responder)); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	first := responder.responses[0]
+	fields := first.Embeds[0].Fields
+	if len(fields) != helpPageSize || fields[0].Name != "category00 · 1 images" {
+		t.Fatalf("Expected %d sorted categories on the first page, got %+v", helpPageSize, fields)
+	}
+	if fields[0].Value != "The first one\nExample: `/image category:category00`" {
+		t.Errorf("Expected the description and an example, got %q", fields[0].Value)
+	}
+	buttons := first.Components[0].(discordgo.ActionsRow).Components
+	previous, next := buttons[0].(discordgo.Button), buttons[1].(discordgo.Button)
+	if !previous.Disabled || next.Disabled {
+		t.Errorf("Expected only the next button enabled, got %+v and %+v", previous, next)
+	}
+
+	router := commands.NewRouter(nil, nil)
+	handler.Register(router)
+	responder = &uploadResponder{}
+	pressButton(router, responder, next.CustomID)
+
+	second := responder.responses[0]
+	if !second.Update || len(second.Embeds[0].Fields) != 2 || second.Embeds[0].Footer.Text != "Page 2 of 2" {
+		t.Fatalf("Expected the message updated to the last page, got %+v", second)
+	}
+	buttons = second.Components[0].(discordgo.ActionsRow).Components
+	if buttons[0].(discordgo.Button).Disabled || !buttons[1].(discordgo.Button).Disabled {
+		t.Errorf("Expected only the previous button enabled on the last page")
+	}
+}
+
+// TestImageHandler_HelpWithoutEmbeds tests the plain text help, split to fit in messages.
+func TestImageHandler_HelpWithoutEmbeds(t *testing.T) {
+	imageService := setupManyCategories(t, 100)
+	handler := NewImageHandler(imageService, nil, nil)
+
+	responder := &uploadResponder{}
+	c := imageContext(responder)
+	c.GuildID = "g1"
+	c.Interaction = &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{AppPermissions: discordgo.PermissionSendMessages}}
+	if err := handler.handleHelp(c); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(responder.responses) < 2 {
+		t.Fatalf("Expected the help split in several messages, got %d", len(responder.responses))
+	}
+	var text strings.Builder
+	for _, response := range responder.responses {
+		if len(response.Embeds) != 0 || utf8.RuneCountInString(response.Content) > commands.MaxMessageLength {
+			t.Errorf("Expected plain messages within the limit, got %d characters", utf8.RuneCountInString(response.Content))
+		}
+		text.WriteString(response.Content)
+	}
+	if !strings.Contains(text.String(), "category99") {
+		t.Errorf("Expected every category in the help")
+	}
+}
@@ -5,12 +5,18 @@ import (
 	"path/filepath"
 	"testing"
 
+	"wooper-bot/internal/commands"
 	"wooper-bot/internal/logger"
 	"wooper-bot/internal/services"
+
+	"github.com/bwmarrin/discordgo"
 )
 
+// pngHeader is enough of a PNG for the content check of GetImageFile.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
 // setupTestHandler creates a message handler with test image service
-func setupTestHandler(t *testing.T) *MessageHandler {
+func setupTestHandler(t *testing.T) (*MessageHandler, *services.ImageService) {
 	// Initialize logger for tests
 	err := logger.Init()
 	if err != nil {
@@ -36,11 +42,9 @@ func setupTestHandler(t *testing.T) *MessageHandler {
 		// Create test image files
 		for i := 1; i <= 2; i++ {
 			filename := filepath.Join(categoryDir, category+"_"+string(rune('0'+i))+".jpg")
-			file, err := os.Create(filename)
-			if err != nil {
+			if err := os.WriteFile(filename, pngHeader, 0644); err != nil {
 				t.Fatalf("Failed to create test file: %v", err)
 			}
-			file.Close()
 		}
 	}
 
@@ -50,48 +54,61 @@ func setupTestHandler(t *testing.T) *MessageHandler {
 		t.Fatalf("Failed to create image service: %v", err)
 	}
 
-	// Create message handler
-	handler := NewMessageHandler(imageService)
+	// Create message handler with the image commands registered
+	router := commands.NewRouter(nil, nil)
+	NewImageHandler(imageService, nil, nil).Register(router)
+	handler := NewMessageHandler(router, nil)
 
-	return handler
+	return handler, imageService
 }
 
 // TestNewMessageHandler tests the NewMessageHandler constructor function.
 func TestNewMessageHandler(t *testing.T) {
-	// Create a mock image service
-	imageService := &services.ImageService{}
+	router := commands.NewRouter(nil, nil)
 
-	handler := NewMessageHandler(imageService)
+	handler := NewMessageHandler(router, nil)
 
 	if handler == nil {
 		t.Errorf("Expected handler but got nil")
 	}
 
-	if handler.ImageService != imageService {
-		t.Errorf("Expected image service %v but got %v", imageService, handler.ImageService)
+	if handler.Router != router {
+		t.Errorf("Expected router %v but got %v", router, handler.Router)
 	}
 }
 
 // TestMessageHandler_ImageServiceIntegration tests the integration between MessageHandler and ImageService.
 func TestMessageHandler_ImageServiceIntegration(t *testing.T) {
-	handler := setupTestHandler(t)
-
-	// Test that the handler has access to the image service
-	if handler.ImageService == nil {
-		t.Errorf("Expected image service but got nil")
-	}
+	handler, imageService := setupTestHandler(t)
 
 	// Test that the image service has categories
-	categories := handler.ImageService.GetAvailableCategories()
+	categories := imageService.GetAvailableCategories()
 	if len(categories) == 0 {
 		t.Errorf("Expected categories but got none")
 	}
 
-	// Test that we can get random images
+	// Test that every category resolves to the image command and has images
 	for _, category := range categories {
-		imagePath := handler.ImageService.GetRandomImage(category)
+		cmd, args := handler.Router.Resolve(commands.Invocation{Name: category})
+		if cmd == nil || cmd.Name != "image" || len(args) != 1 || args[0] != category {
+			t.Errorf("Expected %s to resolve to the image command, got %v %v", category, cmd, args)
+		}
+
+		imagePath := imageService.GetRandomImage(category)
 		if imagePath == "" {
 			t.Errorf("Expected image path for category %s but got empty", category)
 		}
 	}
 }
+
+// TestMessageHandler_IgnoresBots tests that messages from bots never reach the router.
+func TestMessageHandler_IgnoresBots(t *testing.T) {
+	handler, _ := setupTestHandler(t)
+
+	// A nil session would panic if the message were routed
+	handler.OnMessageCreate(nil, &discordgo.MessageCreate{Message: &discordgo.Message{
+		Content: "!wooper",
+		Author:  &discordgo.User{ID: "b1", Bot: true},
+	}})
+	handler.OnMessageCreate(nil, &discordgo.MessageCreate{Message: &discordgo.Message{Content: "!wooper"}})
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/services"
+	"wooper-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// PermissionHandler manages the rules restricting commands and categories to roles and channels.
+// The rules are enforced by the commands.RequireAccess middleware.
+type PermissionHandler struct {
+	Router       *commands.Router
+	ImageService *services.ImageService
+	Store        *storage.Store
+}
+
+func NewPermissionHandler(router *commands.Router, imageService *services.ImageService, store *storage.Store) *PermissionHandler {
+	return &PermissionHandler{Router: router, ImageService: imageService, Store: store}
+}
+
+// Register adds the permissions command.
+func (h *PermissionHandler) Register(r *commands.Router) {
+	r.Register(&commands.Command{
+		Name:        "permissions",
+		Description: "Restrict commands and categories to roles and channels",
+		Permissions: discordgo.PermissionManageServer,
+		GuildOnly:   true,
+		Subcommands: []*commands.Command{
+			{
+				Name:        "allow",
+				Description: "Only allow a command or category for a role, in a channel, or both",
+				Options: []*commands.Option{
+					{
+						Name:         "command",
+						Description:  "Command to restrict",
+						Type:         discordgo.ApplicationCommandOptionString,
+						Autocomplete: h.autocompleteCommand,
+					},
+					{
+						Name:         "category",
+						Description:  "Image category to restrict",
+						Type:         discordgo.ApplicationCommandOptionString,
+						Autocomplete: categoryAutocomplete(h.ImageService),
+					},
+					{
+						Name:        "role",
+						Description: "Role allowed to use it",
+						Type:        discordgo.ApplicationCommandOptionRole,
+					},
+					{
+						Name:         "channel",
+						Description:  "Channel it can be used in",
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					},
+				},
+				Handler: h.handleAllow,
+			},
+			{
+				Name:        "list",
+				Description: "List this server's permission rules",
+				Handler:     h.handleList,
+			},
+			{
+				Name:        "remove",
+				Description: "Remove a permission rule",
+				Options: []*commands.Option{
+					{
+						Name:        "id",
+						Description: "ID shown by /permissions list",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+					},
+				},
+				Handler: h.handleRemove,
+			},
+		},
+	})
+}
+
+// restrictable returns the named command if permission rules can restrict it. Commands
+// requiring permissions are for server managers, whom rules never restrict.
+func (h *PermissionHandler) restrictable(name string) *commands.Command {
+	for _, cmd := range h.Router.Commands() {
+		if cmd.Name == name && cmd.Permissions == 0 {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// autocompleteCommand suggests the commands that can be restricted.
+func (h *PermissionHandler) autocompleteCommand(c *commands.Context, value string) []*discordgo.ApplicationCommandOptionChoice {
+	value = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(value), "/"))
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, cmd := range h.Router.Commands() {
+		if cmd.Permissions == 0 && strings.HasPrefix(cmd.Name, value) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: "/" + cmd.Name, Value: cmd.Name})
+		}
+	}
+	return choices
+}
+
+func (h *PermissionHandler) handleAllow(c *commands.Context) error {
+	rule := storage.PermissionRule{
+		GuildID:   c.GuildID,
+		RoleID:    c.String("role"),
+		ChannelID: c.String("channel"),
+		CreatedBy: c.User.ID,
+	}
+	if c.Has("command") == c.Has("category") {
+		return c.ReplyEphemeral(c.T("permissions.one_target"))
+	}
+	if rule.RoleID == "" && rule.ChannelID == "" {
+		return c.ReplyEphemeral(c.T("permissions.no_scope"))
+	}
+
+	if c.Has("command") {
+		rule.Target = storage.PermissionCommand
+		rule.Name = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(c.String("command")), "/"))
+		if h.restrictable(rule.Name) == nil {
+			return c.ReplyEphemeral(c.T("permissions.unknown_command", rule.Name))
+		}
+	} else {
+		rule.Target = storage.PermissionCategory
+		rule.Name = c.String("category")
+		if !h.ImageService.HasCategory(rule.Name) {
+			return c.ReplyEphemeral(c.T("image.category_not_found", rule.Name, strings.Join(h.ImageService.GetAvailableCategories(), ", ")))
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	existing, err := h.Store.PermissionRules(ctx, c.GuildID)
+	if err != nil {
+		return services.NewError(services.ErrCodeStorage, err)
+	}
+	if len(existing) >= services.MaxPermissionRules {
+		return c.ReplyEphemeral(c.T("permissions.too_many", services.MaxPermissionRules))
+	}
+	saved, err := h.Store.AddPermissionRule(ctx, rule)
+	if err != nil {
+		return services.NewError(services.ErrCodeStorage, err)
+	}
+
+	c.LogFields(zap.Int64("rule_id", saved.ID), zap.String("target", string(saved.Target)), zap.String("name", saved.Name))
+	return c.ReplyEphemeral(c.T("permissions.added", describeRule(c, saved)))
+}
+
+func (h *PermissionHandler) handleList(c *commands.Context) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rules, err := h.Store.PermissionRules(ctx, c.GuildID)
+	if err != nil {
+		return services.NewError(services.ErrCodeStorage, err)
+	}
+	if len(rules) == 0 {
+		return c.ReplyEphemeral(c.T("permissions.none"))
+	}
+
+	var b strings.Builder
+	b.WriteString(c.T("permissions.title") + "\n")
+	for _, rule := range rules {
+		b.WriteString("• " + describeRule(c, rule) + "\n")
+	}
+	b.WriteString(c.T("permissions.note"))
+	return c.ReplyEphemeral(b.String())
+}
+
+func (h *PermissionHandler) handleRemove(c *commands.Context) error {
+	input := strings.TrimPrefix(strings.TrimSpace(c.String("id")), "#")
+	id, err := strconv.ParseInt(input, 10, 64)
+	if err != nil {
+		return c.ReplyEphemeral(c.T("permissions.not_found", input))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	removed, err := h.Store.RemovePermissionRule(ctx, c.GuildID, id)
+	if err != nil {
+		return services.NewError(services.ErrCodeStorage, err)
+	}
+	if !removed {
+		return c.ReplyEphemeral(c.T("permissions.not_found", input))
+	}
+	return c.ReplyEphemeral(c.T("permissions.removed", id))
+}
+
+// describeRule renders a rule for the add confirmation and the list.
+func describeRule(c *commands.Context, rule storage.PermissionRule) string {
+	target := c.T("permissions.command", rule.Name)
+	if rule.Target == storage.PermissionCategory {
+		target = c.T("permissions.category", rule.Name)
+	}
+
+	role := "<@&" + rule.RoleID + ">"
+	if rule.RoleID == rule.GuildID {
+		role = "@everyone"
+	}
+	var scope string
+	switch {
+	case rule.RoleID != "" && rule.ChannelID != "":
+		scope = c.T("permissions.role_in_channel", role, rule.ChannelID)
+	case rule.RoleID != "":
+		scope = c.T("permissions.role", role)
+	default:
+		scope = c.T("permissions.channel", rule.ChannelID)
+	}
+	return c.T("permissions.entry", rule.ID, target, scope)
+}
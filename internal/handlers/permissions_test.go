@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/services"
+	"wooper-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// TestPermissionHandler tests managing permission rules and enforcing them on commands and categories.
+func TestPermissionHandler(t *testing.T) {
+	_, imageService := setupTestHandler(t)
+	store, err := storage.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	rules := services.NewPermissionService(store).Rules
+	router := commands.NewRouter(nil, nil)
+	router.SetPermissionRules(rules)
+	responder := &uploadResponder{}
+	router.Use(func(next commands.HandlerFunc) commands.HandlerFunc {
+		return func(c *commands.Context) error {
+			c.Responder = responder
+			return next(c)
+		}
+	}, commands.RequireAccess(rules))
+	NewImageHandler(imageService, store, nil).Register(router)
+	NewPermissionHandler(router, imageService, store).Register(router)
+
+	interact := func(member *discordgo.Member, channelID string, name string, options []*discordgo.ApplicationCommandInteractionDataOption) {
+		responder.responses, responder.files = nil, nil
+		router.HandleInteraction(nil, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			Type:      discordgo.InteractionApplicationCommand,
+			GuildID:   "g1",
+			ChannelID: channelID,
+			Member:    member,
+			Data:      discordgo.ApplicationCommandInteractionData{Name: name, Options: options},
+		}})
+	}
+	manager := &discordgo.Member{User: &discordgo.User{ID: "admin"}, Permissions: discordgo.PermissionManageServer}
+	run := func(sub string, options map[string]string) string {
+		var data []*discordgo.ApplicationCommandInteractionDataOption
+		for name, value := range options {
+			data = append(data, &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionString, Value: value})
+		}
+		interact(manager, "c1", "permissions", []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: sub, Type: discordgo.ApplicationCommandOptionSubCommand, Options: data},
+		})
+		if len(responder.responses) != 1 {
+			t.Fatalf("Expected one answer to /permissions %s, got %+v", sub, responder.responses)
+		}
+		return responder.responses[0].Content
+	}
+
+	tests := []struct {
+		name    string
+		options map[string]string
+		want    string
+	}{
+		{"no target", map[string]string{"role": "r1"}, "Choose either a command or a category."},
+		{"two targets", map[string]string{"command": "image", "category": "cats", "role": "r1"}, "Choose either a command or a category."},
+		{"no scope", map[string]string{"command": "image"}, "Choose a role, a channel, or both."},
+		{"unknown command", map[string]string{"command": "nope", "role": "r1"}, "'nope' isn't a command that can be restricted."},
+		{"manager command", map[string]string{"command": "permissions", "role": "r1"}, "'permissions' isn't a command that can be restricted."},
+		{"unknown category", map[string]string{"category": "dogs", "channel": "c2"}, "Category 'dogs'"},
+		{"category case", map[string]string{"category": "Cats", "channel": "c2"}, "Category 'Cats'"}, // directory names are matched exactly
+		{"command", map[string]string{"command": "/image", "role": "r1"}, "Rule added: `#1` `/image` for <@&r1>."},
+		{"category", map[string]string{"category": "cats", "role": "g1", "channel": "c2"}, "Rule added: `#2` the cats category for @everyone in <#c2>."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := run("allow", tt.options); !strings.HasPrefix(got, tt.want) {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+	if got := run("list", nil); strings.Count(got, "• ") != 2 {
+		t.Errorf("Expected both rules listed, got %q", got)
+	}
+
+	image := func(roles []string, channelID string, category string) {
+		member := &discordgo.Member{User: &discordgo.User{ID: "u1"}, Roles: roles}
+		interact(member, channelID, "image", []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: "category", Type: discordgo.ApplicationCommandOptionString, Value: category},
+		})
+	}
+	image(nil, "c2", "wooper")
+	if len(responder.files) != 0 || len(responder.responses) != 1 || responder.responses[0].Content != "You can't use this command here." {
+		t.Errorf("Expected /image to be refused without the role, got %+v", responder.responses)
+	}
+	image([]string{"r1"}, "c2", "cats")
+	if len(responder.files) != 1 {
+		t.Errorf("Expected a cats image in the allowed channel, got %+v", responder.responses)
+	}
+	image([]string{"r1"}, "c1", "cats")
+	if len(responder.files) != 0 {
+		t.Errorf("Expected the cats category to be refused outside its channel, got %v", responder.files)
+	}
+	interact(manager, "c1", "image", []*discordgo.ApplicationCommandInteractionDataOption{
+		{Name: "category", Type: discordgo.ApplicationCommandOptionString, Value: "cats"},
+	})
+	if len(responder.files) != 1 {
+		t.Errorf("Expected server managers to bypass the rules, got %+v", responder.responses)
+	}
+
+	// Suggestions leave out what the rules would refuse
+	categories := func(member *discordgo.Member, channelID string) string {
+		choices, ok := suggest(router, member, channelID, "image", "category", "")
+		if !ok {
+			t.Fatalf("Expected category suggestions")
+		}
+		var names []string
+		for _, choice := range choices {
+			names = append(names, choice.Name)
+		}
+		return strings.Join(names, ",")
+	}
+	suggestions := []struct {
+		name      string
+		member    *discordgo.Member
+		channelID string
+		want      string
+	}{
+		{"restricted command", &discordgo.Member{User: &discordgo.User{ID: "u1"}}, "c2", ""},
+		{"allowed channel", &discordgo.Member{User: &discordgo.User{ID: "u1"}, Roles: []string{"r1"}}, "c2", "cats,wooper"},
+		{"other channel", &discordgo.Member{User: &discordgo.User{ID: "u1"}, Roles: []string{"r1"}}, "c1", "wooper"},
+		{"manager", manager, "c1", "cats,wooper"},
+	}
+	for _, tt := range suggestions {
+		if got := categories(tt.member, tt.channelID); got != tt.want {
+			t.Errorf("%s: expected %q suggested, got %q", tt.name, tt.want, got)
+		}
+	}
+
+	// The buttons on an earlier image don't get around the /image rule
+	press := func(button string, roles []string) {
+		responder.responses, responder.files = nil, nil
+		router.HandleComponent(nil, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			Type:      discordgo.InteractionMessageComponent,
+			GuildID:   "g1",
+			ChannelID: "c2",
+			Member:    &discordgo.Member{User: &discordgo.User{ID: "u1"}, Roles: roles},
+			Data:      discordgo.MessageComponentInteractionData{CustomID: commands.CustomID(button, imageService.ImageID(imageService.GetRandomImage("wooper"))), ComponentType: discordgo.ButtonComponent},
+		}})
+	}
+	for _, button := range []string{componentAnother, componentReport} {
+		press(button, nil)
+		if len(responder.files) != 0 || len(responder.responses) != 1 || responder.responses[0].Content != "You can't use this command here." {
+			t.Errorf("Expected the %s button to be refused without the role, got %+v", button, responder.responses)
+		}
+	}
+	press(componentAnother, []string{"r1"})
+	if len(responder.files) != 1 {
+		t.Errorf("Expected the another button to work with the role, got %+v", responder.responses)
+	}
+
+	if got := run("remove", map[string]string{"id": "#3"}); got != "No permission rule #3 in this server." {
+		t.Errorf("Unexpected answer %q", got)
+	}
+	if got := run("remove", map[string]string{"id": "#1"}); got != "Permission rule #1 removed." {
+		t.Errorf("Unexpected answer %q", got)
+	}
+	image(nil, "c1", "wooper")
+	if len(responder.files) != 1 {
+		t.Errorf("Expected /image to be open again, got %+v", responder.responses)
+	}
+}
+
+// TestPermissionAutocomplete tests that only restrictable commands are suggested.
+func TestPermissionAutocomplete(t *testing.T) {
+	_, imageService := setupTestHandler(t)
+	router := commands.NewRouter(nil, nil)
+	NewImageHandler(imageService, nil, nil).Register(router)
+	permissions := NewPermissionHandler(router, imageService, nil)
+	permissions.Register(router)
+
+	var names []string
+	for _, choice := range permissions.autocompleteCommand(nil, "") {
+		names = append(names, choice.Value.(string))
+	}
+	if got := strings.Join(names, ","); got != "image,help,browse" {
+		t.Errorf("Expected image, help and browse, got %s", got)
+	}
+	if got := permissions.autocompleteCommand(nil, "/he"); len(got) != 1 || got[0].Name != "/help" {
+		t.Errorf("Expected /help, got %+v", got)
+	}
+}
@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/logger"
+	"wooper-bot/internal/services"
+	"wooper-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+const (
+	// quizDuration is how long members have to guess a silhouette.
+	quizDuration = 30 * time.Second
+	// quizLeaderboardSize is how many members /quiz leaderboard shows.
+	quizLeaderboardSize = 10
+)
+
+// quiz is a silhouette waiting for the right guess in a channel.
+type quiz struct {
+	c         *commands.Context // the start command, replied to on reveal
+	category  string
+	imagePath string
+	answer    string   // shown on reveal
+	answers   []string // normalized
+	started   time.Time
+	timer     *time.Timer
+}
+
+// QuizHandler runs "who's that" quizzes: it posts the silhouette of an image with quiz answers,
+// takes guesses from the channel's messages and reveals the image when someone finds it or
+// time runs out.
+type QuizHandler struct {
+	ImageService *services.ImageService
+	Store        *storage.Store
+	// Duration is how long a quiz lasts, quizDuration unless changed
+	Duration time.Duration
+
+	mu     sync.Mutex
+	active map[string]*quiz // by channel ID
+}
+
+func NewQuizHandler(imageService *services.ImageService, store *storage.Store) *QuizHandler {
+	return &QuizHandler{ImageService: imageService, Store: store, Duration: quizDuration, active: make(map[string]*quiz)}
+}
+
+// Register adds the quiz command.
+func (h *QuizHandler) Register(r *commands.Router) {
+	r.Register(&commands.Command{
+		Name:        "quiz",
+		Description: "Guess images from their silhouette",
+		Subcommands: []*commands.Command{
+			{
+				Name:        "start",
+				Description: "Post a silhouette to guess in this channel",
+				Options: []*commands.Option{
+					{
+						Name:         "category",
+						Description:  "Category of the image, a random one if not set",
+						Type:         discordgo.ApplicationCommandOptionString,
+						Autocomplete: categoryAutocomplete(h.ImageService),
+					},
+				},
+				Handler: h.handleStart,
+			},
+			{
+				Name:        "leaderboard",
+				Description: "Show the best quiz players of this server",
+				Handler:     h.handleLeaderboard,
+			},
+		},
+		GuildOnly: true,
+		RateLimits: []commands.RateLimit{
+			{Scope: commands.ScopeChannel, Burst: 2, Every: 10 * time.Second},
+		},
+	})
+}
+
+func (h *QuizHandler) handleStart(c *commands.Context) error {
+	availableCategories := c.Settings.FilterCategories(h.ImageService.GetAvailableCategories())
+	category := c.String("category")
+	if category != "" && (!h.ImageService.HasCategory(category) || !c.Settings.CategoryEnabled(category)) {
+		return c.Error(c.T("image.category_not_found", category, strings.Join(availableCategories, ", ")))
+	}
+
+	imagePath := ""
+	if category != "" {
+		imagePath = h.ImageService.GetRandomQuizImage(category)
+	} else {
+		// Any enabled category with quiz images
+		for _, i := range rand.Perm(len(availableCategories)) {
+			if imagePath = h.ImageService.GetRandomQuizImage(availableCategories[i]); imagePath != "" {
+				category = availableCategories[i]
+				break
+			}
+		}
+	}
+	metadata, ok := h.ImageService.GetImageMetadata(imagePath)
+	if imagePath == "" || !ok || len(metadata.Answers) == 0 {
+		return c.Error(c.T("quiz.no_images"))
+	}
+
+	h.mu.Lock()
+	_, running := h.active[c.ChannelID]
+	h.mu.Unlock()
+	if running {
+		return c.ReplyEphemeral(c.T("quiz.running"))
+	}
+
+	if err := c.Defer(false); err != nil {
+		logger.Logger.Warn("Failed to acknowledge command", zap.Error(err))
+	}
+	silhouette, err := h.silhouette(imagePath)
+	if err != nil {
+		return services.NewError(services.ErrCodeImageLoad, err)
+	}
+
+	q := &quiz{c: c, category: category, imagePath: imagePath, answer: metadata.Answers[0]}
+	for _, answer := range metadata.Answers {
+		q.answers = append(q.answers, services.NormalizeAnswer(answer))
+	}
+
+	// Claim the channel before posting, so two quizzes started at once don't both run
+	h.mu.Lock()
+	if _, running := h.active[c.ChannelID]; running {
+		h.mu.Unlock()
+		return c.ReplyEphemeral(c.T("quiz.running"))
+	}
+	h.active[c.ChannelID] = q
+	h.mu.Unlock()
+
+	err = c.Respond(&commands.Response{
+		Files: []*discordgo.File{{Name: "quiz.png", ContentType: "image/png", Reader: bytes.NewReader(silhouette)}},
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:       c.T("quiz.title"),
+			Description: c.T("quiz.question", category, int(h.Duration.Seconds())),
+			Color:       embedColor,
+			Image:       &discordgo.MessageEmbedImage{URL: "attachment://quiz.png"},
+		}},
+	})
+	if err != nil {
+		h.mu.Lock()
+		delete(h.active, c.ChannelID)
+		h.mu.Unlock()
+		return services.NewError(services.ErrCodeImageUpload, err)
+	}
+
+	h.mu.Lock()
+	q.started = time.Now()
+	q.timer = time.AfterFunc(h.Duration, func() { h.end(c.ChannelID, q, nil) })
+	h.mu.Unlock()
+	c.LogFields(zap.String("category", category), zap.String("image_id", h.ImageService.ImageID(imagePath)))
+	return nil
+}
+
+// silhouette renders the silhouette of an image as a PNG.
+func (h *QuizHandler) silhouette(imagePath string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	reader, _, err := h.ImageService.GetImageFile(ctx, imagePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var buf bytes.Buffer
+	if err := services.RenderSilhouette(reader, &buf); err != nil {
+		return nil, fmt.Errorf("render silhouette of %s: %w", imagePath, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// OnMessageCreate checks messages sent in channels with a running quiz against its answers.
+func (h *QuizHandler) OnMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
+	defer recoverMessage(s, m)
+
+	if m.Author == nil || m.Author.Bot {
+		return
+	}
+	guess := services.NormalizeAnswer(m.Content)
+	if guess == "" {
+		return
+	}
+
+	h.mu.Lock()
+	q, ok := h.active[m.ChannelID]
+	ok = ok && !q.started.IsZero() // not posted yet
+	h.mu.Unlock()
+	if !ok {
+		return
+	}
+	for _, answer := range q.answers {
+		if guess == answer {
+			h.end(m.ChannelID, q, m.Author)
+			return
+		}
+	}
+}
+
+// end closes the quiz of a channel unless it already ended, awards points to winner if any,
+// and reveals the image.
+func (h *QuizHandler) end(channelID string, q *quiz, winner *discordgo.User) {
+	h.mu.Lock()
+	if h.active[channelID] != q {
+		h.mu.Unlock()
+		return
+	}
+	delete(h.active, channelID)
+	q.timer.Stop()
+	h.mu.Unlock()
+
+	c := q.c
+	description := c.T("quiz.nobody")
+	if winner != nil {
+		elapsed := time.Since(q.started) // set before the quiz can be won
+		points := quizPoints(elapsed, h.Duration)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		total, err := h.Store.AddQuizWin(ctx, c.GuildID, winner.ID, points)
+		cancel()
+		if err != nil {
+			logger.Logger.Warn("Failed to record quiz win",
+				zap.String("guild_id", c.GuildID),
+				zap.String("user_id", winner.ID),
+				zap.Error(err))
+		}
+		description = c.T("quiz.winner", winner.ID, elapsed.Seconds(), points, total)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	reader, fileName, err := h.ImageService.GetImageFile(ctx, q.imagePath)
+	if err != nil {
+		logger.Logger.Warn("Failed to load quiz image", zap.String("image_path", q.imagePath), zap.Error(err))
+		return
+	}
+	defer reader.Close()
+
+	file := imageAttachment(h.ImageService.ImageID(q.imagePath), fileName, reader)
+	err = c.Respond(&commands.Response{
+		Files: []*discordgo.File{file},
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:       c.T("quiz.answer", q.answer),
+			Description: description,
+			Color:       embedColor,
+			Image:       &discordgo.MessageEmbedImage{URL: "attachment://" + file.Name},
+		}},
+	})
+	if err != nil {
+		logger.Logger.Warn("Failed to reveal quiz image",
+			zap.String("channel_id", channelID),
+			zap.String("image_path", q.imagePath),
+			zap.Error(err))
+	}
+}
+
+// quizPoints awards 3 points to guesses in the first third of the quiz, 2 in the second and 1 after.
+func quizPoints(elapsed, duration time.Duration) int {
+	switch {
+	case elapsed < duration/3:
+		return 3
+	case elapsed < 2*duration/3:
+		return 2
+	}
+	return 1
+}
+
+func (h *QuizHandler) handleLeaderboard(c *commands.Context) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	scores, err := h.Store.QuizLeaderboard(ctx, c.GuildID, quizLeaderboardSize)
+	if err != nil {
+		return services.NewError(services.ErrCodeStorage, err)
+	}
+	if len(scores) == 0 {
+		return c.Reply(c.T("quiz.no_scores"))
+	}
+
+	lines := make([]string, len(scores))
+	for i, score := range scores {
+		lines[i] = c.T("quiz.score", i+1, score.UserID, score.Points, score.Wins)
+	}
+	return c.Respond(&commands.Response{Embeds: []*discordgo.MessageEmbed{{
+		Title:       c.T("quiz.leaderboard"),
+		Description: strings.Join(lines, "\n"),
+		Color:       embedColor,
+	}}})
+}
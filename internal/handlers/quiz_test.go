@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/services"
+	"wooper-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// quizResponder records responses, which the quiz also sends from its timer.
+type quizResponder struct {
+	mu        sync.Mutex
+	responses []*commands.Response
+	revealed  chan struct{}
+}
+
+func (q *quizResponder) Defer(ephemeral bool) error { return nil }
+
+func (q *quizResponder) Respond(r *commands.Response) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.responses = append(q.responses, r)
+	if len(q.responses) == 2 {
+		close(q.revealed)
+	}
+	return nil
+}
+
+// setupQuiz registers the quiz on a router backed by a test store, with one wooper image
+// that has answers.
+func setupQuiz(t *testing.T, duration time.Duration) (*QuizHandler, *commands.Router, *storage.Store) {
+	t.Helper()
+	setupTestHandler(t)
+
+	dir := filepath.Join(t.TempDir(), "wooper")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create category: %v", err)
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, 16, 16))
+	for y := 4; y < 12; y++ {
+		for x := 4; x < 12; x++ {
+			img.Set(x, y, color.NRGBA{R: 0x40, G: 0x90, B: 0xd0, A: 0xff})
+		}
+	}
+	file, err := os.Create(filepath.Join(dir, "wooper.png"))
+	if err != nil {
+		t.Fatalf("Failed to create image: %v", err)
+	}
+	if err := png.Encode(file, img); err != nil {
+		t.Fatalf("Failed to encode image: %v", err)
+	}
+	file.Close()
+	metadata := `{"wooper.png": {"answers": ["Wooper", "Axoloto"]}}`
+	if err := os.WriteFile(filepath.Join(dir, services.MetadataFile), []byte(metadata), 0644); err != nil {
+		t.Fatalf("Failed to write metadata: %v", err)
+	}
+
+	imageService, err := services.NewImageService(filepath.Dir(dir))
+	if err != nil {
+		t.Fatalf("Failed to create image service: %v", err)
+	}
+	store, err := storage.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	handler := NewQuizHandler(imageService, store)
+	handler.Duration = duration
+	router := commands.NewRouter(nil, nil)
+	handler.Register(router)
+	return handler, router, store
+}
+
+// startQuiz runs /quiz start in channel c1 of guild g1, answering with a new responder.
+func startQuiz(router *commands.Router) *quizResponder {
+	responder := &quizResponder{revealed: make(chan struct{})}
+	router.Use(func(next commands.HandlerFunc) commands.HandlerFunc {
+		return func(c *commands.Context) error {
+			c.Responder = responder
+			return next(c)
+		}
+	})
+	router.HandleInteraction(nil, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:      discordgo.InteractionApplicationCommand,
+		GuildID:   "g1",
+		ChannelID: "c1",
+		Member:    &discordgo.Member{User: &discordgo.User{ID: "u1"}},
+		Data: discordgo.ApplicationCommandInteractionData{Name: "quiz", Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: "start", Type: discordgo.ApplicationCommandOptionSubCommand},
+		}},
+	}})
+	return responder
+}
+
+// guess sends content as a message from u2 in channel c1.
+func guess(handler *QuizHandler, content string) {
+	handler.OnMessageCreate(nil, &discordgo.MessageCreate{Message: &discordgo.Message{
+		ChannelID: "c1",
+		GuildID:   "g1",
+		Content:   content,
+		Author:    &discordgo.User{ID: "u2"},
+	}})
+}
+
+// TestQuizHandler_Win tests that the right guess reveals the image and awards points.
+func TestQuizHandler_Win(t *testing.T) {
+	handler, router, store := setupQuiz(t, time.Minute)
+
+	responder := startQuiz(router)
+	if len(responder.responses) != 1 || len(responder.responses[0].Files) != 1 {
+		t.Fatalf("Expected the silhouette, got %+v", responder.responses)
+	}
+	if name := responder.responses[0].Files[0].Name; name != "quiz.png" {
+		t.Errorf("Expected the silhouette as quiz.png, got %s", name)
+	}
+
+	second := startQuiz(router)
+	if len(second.responses) != 1 || !strings.Contains(second.responses[0].Content, "already running") {
+		t.Errorf("Expected a second quiz to be refused, got %+v", second.responses)
+	}
+
+	guess(handler, "Psyduck")
+	guess(handler, "  axolôto! ")
+	select {
+	case <-responder.revealed:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the image to be revealed")
+	}
+	reveal := responder.responses[1].Embeds[0]
+	if reveal.Title != "It was Wooper!" || !strings.HasPrefix(reveal.Description, "<@u2> found it") || !strings.HasSuffix(reveal.Description, "wins 3 points, 3 in total.") {
+		t.Errorf("Unexpected reveal %q: %q", reveal.Title, reveal.Description)
+	}
+
+	scores, err := store.QuizLeaderboard(context.Background(), "g1", 10)
+	if err != nil {
+		t.Fatalf("Failed to get leaderboard: %v", err)
+	}
+	if len(scores) != 1 || scores[0] != (storage.QuizScore{UserID: "u2", Points: 3, Wins: 1}) {
+		t.Errorf("Expected u2 to have won 3 points, got %+v", scores)
+	}
+
+	// Late guesses are ignored
+	guess(handler, "wooper")
+	if len(responder.responses) != 2 {
+		t.Errorf("Expected no answer to a late guess, got %+v", responder.responses)
+	}
+}
+
+// TestQuizHandler_Timeout tests that the image is revealed when nobody finds it in time.
+func TestQuizHandler_Timeout(t *testing.T) {
+	_, router, store := setupQuiz(t, 10*time.Millisecond)
+
+	responder := startQuiz(router)
+	select {
+	case <-responder.revealed:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the image to be revealed")
+	}
+	responder.mu.Lock()
+	reveal := responder.responses[1].Embeds[0]
+	responder.mu.Unlock()
+	if reveal.Description != "Nobody found it in time." {
+		t.Errorf("Expected nobody to win, got %q", reveal.Description)
+	}
+
+	scores, err := store.QuizLeaderboard(context.Background(), "g1", 10)
+	if err != nil {
+		t.Fatalf("Failed to get leaderboard: %v", err)
+	}
+	if len(scores) != 0 {
+		t.Errorf("Expected no scores, got %+v", scores)
+	}
+}
+
+// TestQuizPoints tests that faster guesses earn more points.
+func TestQuizPoints(t *testing.T) {
+	tests := []struct {
+		elapsed time.Duration
+		want    int
+	}{
+		{0, 3},
+		{9 * time.Second, 3},
+		{10 * time.Second, 2},
+		{19 * time.Second, 2},
+		{20 * time.Second, 1},
+		{30 * time.Second, 1},
+	}
+	for _, tt := range tests {
+		if got := quizPoints(tt.elapsed, 30*time.Second); got != tt.want {
+			t.Errorf("quizPoints(%v) = %d, want %d", tt.elapsed, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/logger"
+	"wooper-bot/internal/services"
+	"wooper-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// Reactions counted as votes on the images the bot sends.
+const (
+	voteUp   = "👍"
+	voteDown = "👎"
+)
+
+// topSize is how many images /top shows.
+const topSize = 10
+
+// RatingHandler turns 👍 and 👎 reactions on the bot's images into votes, and shows the best
+// rated images with /top.
+type RatingHandler struct {
+	ImageService *services.ImageService
+	Store        *storage.Store
+}
+
+func NewRatingHandler(imageService *services.ImageService, store *storage.Store) *RatingHandler {
+	return &RatingHandler{ImageService: imageService, Store: store}
+}
+
+// Register adds the top command.
+func (h *RatingHandler) Register(r *commands.Router) {
+	r.Register(&commands.Command{
+		Name:        "top",
+		Description: "Show the best rated images",
+		Options: []*commands.Option{
+			{
+				Name:         "category",
+				Description:  "Only show images of this category",
+				Type:         discordgo.ApplicationCommandOptionString,
+				Autocomplete: categoryAutocomplete(h.ImageService),
+			},
+		},
+		Handler: h.handleTop,
+	})
+}
+
+func (h *RatingHandler) handleTop(c *commands.Context) error {
+	category := c.String("category")
+	if category != "" && (!h.ImageService.HasCategory(category) || !c.Settings.CategoryEnabled(category)) {
+		availableCategories := c.Settings.FilterCategories(h.ImageService.GetAvailableCategories())
+		return c.Error(c.T("image.category_not_found", category, strings.Join(availableCategories, ", ")))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Ask for more than shown, images may be gone or disabled in this server
+	scores, err := h.Store.TopImages(ctx, category, 3*topSize)
+	if err != nil {
+		return services.NewError(services.ErrCodeStorage, err)
+	}
+
+	var lines []string
+	for _, score := range scores {
+		if _, _, ok := h.ImageService.ImageByID(score.ImageID); !ok || !c.Settings.CategoryEnabled(score.Category) {
+			continue
+		}
+		lines = append(lines, c.T("top.entry", len(lines)+1, score.ImageID, score.Category, score.Score, score.Votes))
+		if len(lines) == topSize {
+			break
+		}
+	}
+	c.LogFields(zap.String("category", category), zap.Int("images", len(lines)))
+	if len(lines) == 0 {
+		return c.Reply(c.T("top.none", voteUp, voteDown))
+	}
+
+	title := c.T("top.title")
+	if category != "" {
+		title = c.T("top.title_category", category)
+	}
+	return c.Respond(&commands.Response{Embeds: []*discordgo.MessageEmbed{{
+		Title:       title,
+		Description: strings.Join(lines, "\n"),
+		Color:       embedColor,
+	}}})
+}
+
+// voteValue returns the vote a reaction stands for, or 0 for other reactions.
+func voteValue(emoji discordgo.Emoji) int {
+	switch emoji.Name {
+	case voteUp:
+		return 1
+	case voteDown:
+		return -1
+	}
+	return 0
+}
+
+func (h *RatingHandler) OnMessageReactionAdd(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
+	defer recoverReaction(r.MessageReaction)
+
+	value := voteValue(r.Emoji)
+	if value == 0 || (s.State.User != nil && r.UserID == s.State.User.ID) {
+		return
+	}
+	if r.Member != nil && r.Member.User != nil && r.Member.User.Bot {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Only the bot's image messages are recorded, other messages cost a lookup, not a fetch
+	imageID, category, ok, err := h.Store.PostedImage(ctx, r.MessageID)
+	if err != nil {
+		logger.Logger.Warn("Failed to look up reacted message",
+			zap.String("message_id", r.MessageID),
+			zap.Error(err))
+		return
+	}
+	if !ok {
+		return
+	}
+	if _, _, ok := h.ImageService.ImageByID(imageID); !ok {
+		return
+	}
+
+	err = h.Store.SetVote(ctx, storage.Vote{
+		ImageID:   imageID,
+		UserID:    r.UserID,
+		Category:  category,
+		MessageID: r.MessageID,
+		Value:     value,
+	})
+	if err != nil {
+		logger.Logger.Warn("Failed to record vote",
+			zap.String("image_id", imageID),
+			zap.String("user_id", r.UserID),
+			zap.Error(err))
+		return
+	}
+	logger.Logger.Debug("Image voted",
+		zap.String("image_id", imageID),
+		zap.String("user_id", r.UserID),
+		zap.Int("vote", value))
+}
+
+func (h *RatingHandler) OnMessageReactionRemove(s *discordgo.Session, r *discordgo.MessageReactionRemove) {
+	defer recoverReaction(r.MessageReaction)
+
+	value := voteValue(r.Emoji)
+	if value == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Votes are looked up by message, so nothing needs to be fetched
+	if err := h.Store.RemoveVote(ctx, r.MessageID, r.UserID, value); err != nil {
+		logger.Logger.Warn("Failed to remove vote",
+			zap.String("message_id", r.MessageID),
+			zap.String("user_id", r.UserID),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/services"
+	"wooper-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// messageTransport answers every Discord API request with message, counting the requests
+// and, of those, the POSTs.
+type messageTransport struct {
+	mu       sync.Mutex
+	message  string
+	requests int
+	posts    int
+}
+
+func (mt *messageTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	mt.mu.Lock()
+	mt.requests++
+	if req.Method == http.MethodPost {
+		mt.posts++
+	}
+	mt.mu.Unlock()
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(mt.message)),
+		Request:    req,
+	}, nil
+}
+
+// setupRatings returns a rating handler backed by a test store.
+func setupRatings(t *testing.T) (*RatingHandler, *services.ImageService, *storage.Store) {
+	t.Helper()
+	_, imageService := setupTestHandler(t)
+
+	store, err := storage.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	return NewRatingHandler(imageService, store), imageService, store
+}
+
+// TestVoteValue tests which reactions count as votes.
+func TestVoteValue(t *testing.T) {
+	tests := map[string]int{voteUp: 1, voteDown: -1, "🎉": 0, "": 0}
+	for emoji, expected := range tests {
+		if got := voteValue(discordgo.Emoji{Name: emoji}); got != expected {
+			t.Errorf("Expected %d for %q, got %d", expected, emoji, got)
+		}
+	}
+}
+
+// TestRatingHandler_Reactions tests that reactions on the bot's images are recorded as votes.
+func TestRatingHandler_Reactions(t *testing.T) {
+	h, imageService, store := setupRatings(t)
+	ctx := context.Background()
+
+	// Post an image, which records the message showing it
+	router := commands.NewRouter(nil, nil)
+	NewImageHandler(imageService, store, nil).Register(router)
+	responder := &uploadResponder{}
+	router.Use(func(next commands.HandlerFunc) commands.HandlerFunc {
+		return func(c *commands.Context) error {
+			c.Responder = responder
+			return next(c)
+		}
+	})
+	router.HandleInteraction(nil, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:      discordgo.InteractionApplicationCommand,
+		ChannelID: "c1",
+		User:      &discordgo.User{ID: "u1"},
+		Data: discordgo.ApplicationCommandInteractionData{Name: "image", Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: "category", Type: discordgo.ApplicationCommandOptionString, Value: "wooper"},
+		}},
+	}})
+	if len(responder.responses) != 1 || responder.responses[0].Sent == nil {
+		t.Fatalf("Expected the image sent, got %+v", responder.responses)
+	}
+	messageID := responder.responses[0].Sent.ID
+	id := strings.TrimSuffix(responder.files[0], filepath.Ext(responder.files[0]))
+
+	transport := &messageTransport{message: "{}"}
+	s, err := discordgo.New("Bot test")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	s.Client = &http.Client{Transport: transport}
+	s.State.User = &discordgo.User{ID: "bot"}
+
+	react := func(userID, messageID, emoji string) {
+		h.OnMessageReactionAdd(s, &discordgo.MessageReactionAdd{MessageReaction: &discordgo.MessageReaction{
+			UserID: userID, MessageID: messageID, ChannelID: "c1", Emoji: discordgo.Emoji{Name: emoji},
+		}})
+	}
+	react("u1", messageID, voteUp)
+	react("u2", messageID, voteUp)
+	react("u3", messageID, voteDown)
+	react("u4", messageID, "🎉")
+	react("bot", messageID, voteUp)
+	// Other messages, the bot's or not, are never votes
+	react("u5", "other", voteUp)
+
+	scores, err := store.ImageScores(ctx, "wooper")
+	if err != nil {
+		t.Fatalf("Failed to load scores: %v", err)
+	}
+	if scores[id] != 1 || len(scores) != 1 {
+		t.Errorf("Expected a score of 1 for %s, got %v", id, scores)
+	}
+	if transport.requests != 0 {
+		t.Errorf("Expected votes to need no API request, got %d", transport.requests)
+	}
+
+	h.OnMessageReactionRemove(s, &discordgo.MessageReactionRemove{MessageReaction: &discordgo.MessageReaction{
+		UserID: "u3", MessageID: messageID, ChannelID: "c1", Emoji: discordgo.Emoji{Name: voteDown},
+	}})
+	if scores, _ = store.ImageScores(ctx, "wooper"); scores[id] != 2 {
+		t.Errorf("Expected a score of 2 once the down vote is removed, got %v", scores)
+	}
+}
+
+// TestRatingHandler_Top tests the leaderboard, which skips images that are gone.
+func TestRatingHandler_Top(t *testing.T) {
+	h, imageService, store := setupRatings(t)
+	ctx := context.Background()
+
+	router := commands.NewRouter(nil, nil)
+	h.Register(router)
+	run := func(responder commands.Responder, options ...*discordgo.ApplicationCommandInteractionDataOption) {
+		router.Use(func(next commands.HandlerFunc) commands.HandlerFunc {
+			return func(c *commands.Context) error {
+				c.Responder = responder
+				return next(c)
+			}
+		})
+		router.HandleInteraction(nil, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			Type:      discordgo.InteractionApplicationCommand,
+			ChannelID: "c1",
+			User:      &discordgo.User{ID: "u1"},
+			Data:      discordgo.ApplicationCommandInteractionData{Name: "top", Options: options},
+		}})
+	}
+
+	responder := &uploadResponder{}
+	run(responder)
+	if len(responder.responses) != 1 || !strings.Contains(responder.responses[0].Content, "No image has been rated") {
+		t.Fatalf("Expected the empty leaderboard message, got %+v", responder.responses)
+	}
+
+	best := imageService.ImageID(imageService.GetCategoryImages("cats")[0])
+	second := imageService.ImageID(imageService.GetCategoryImages("wooper")[0])
+	votes := []storage.Vote{
+		{ImageID: best, UserID: "u1", Category: "cats", MessageID: "m1", Value: 1},
+		{ImageID: best, UserID: "u2", Category: "cats", MessageID: "m1", Value: 1},
+		{ImageID: second, UserID: "u1", Category: "wooper", MessageID: "m2", Value: 1},
+		{ImageID: "0000000000", UserID: "u1", Category: "wooper", MessageID: "m3", Value: 1},
+	}
+	for _, v := range votes {
+		if err := store.SetVote(ctx, v); err != nil {
+			t.Fatalf("Failed to vote: %v", err)
+		}
+	}
+
+	responder = &uploadResponder{}
+	run(responder)
+	embed := responder.responses[0].Embeds[0]
+	expected := "1. `" + best + "` · cats · +2 (2 votes)\n2. `" + second + "` · wooper · +1 (1 votes)"
+	if embed.Description != expected {
+		t.Errorf("Expected %q, got %q", expected, embed.Description)
+	}
+
+	responder = &uploadResponder{}
+	run(responder, &discordgo.ApplicationCommandInteractionDataOption{
+		Name: "category", Type: discordgo.ApplicationCommandOptionString, Value: "wooper",
+	})
+	embed = responder.responses[0].Embeds[0]
+	if embed.Title != "Best rated wooper images" || strings.Contains(embed.Description, best) {
+		t.Errorf("Expected only wooper images, got %q: %q", embed.Title, embed.Description)
+	}
+}
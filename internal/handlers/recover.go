@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"wooper-bot/internal/logger"
+	"wooper-bot/internal/metrics"
+	"wooper-bot/internal/services"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// recoverMessage is deferred by message handlers. discordgo runs handlers in their own
+// goroutines without recovery, so a panic would otherwise crash the whole bot.
+func recoverMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
+	p := recover()
+	if p == nil {
+		return
+	}
+
+	correlationID := services.NewCorrelationID()
+	metrics.Inc(metrics.HandlerPanics)
+	logger.Logger.Error("Message handler panicked",
+		zap.String("correlation_id", correlationID),
+		zap.String("message_id", m.ID),
+		zap.String("channel_id", m.ChannelID),
+		zap.String("guild_id", m.GuildID),
+		zap.Any("panic", p),
+		zap.Stack("stack"))
+
+	if _, err := s.ChannelMessageSend(m.ChannelID, services.UserMessage(nil, "", correlationID)); err != nil {
+		logger.Logger.Error("Failed to send error reply",
+			zap.String("message_id", m.ID),
+			zap.Error(err))
+	}
+}
+
+// recoverInteraction is deferred by interaction handlers, see recoverMessage.
+func recoverInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	p := recover()
+	if p == nil {
+		return
+	}
+
+	correlationID := services.NewCorrelationID()
+	metrics.Inc(metrics.HandlerPanics)
+	logger.Logger.Error("Interaction handler panicked",
+		zap.String("correlation_id", correlationID),
+		zap.String("interaction_id", i.ID),
+		zap.String("channel_id", i.ChannelID),
+		zap.String("guild_id", i.GuildID),
+		zap.Any("panic", p),
+		zap.Stack("stack"))
+
+	message := services.UserMessage(nil, string(i.Locale), correlationID)
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: message,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		// The interaction was probably acknowledged before the panic
+		_, err = s.FollowupMessageCreate(i.Interaction, false, &discordgo.WebhookParams{
+			Content: message,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+	}
+	if err != nil {
+		logger.Logger.Error("Failed to send error reply",
+			zap.String("interaction_id", i.ID),
+			zap.Error(err))
+	}
+}
+
+// recoverReaction is deferred by reaction handlers, see recoverMessage. Reactions get no reply.
+func recoverReaction(r *discordgo.MessageReaction) {
+	p := recover()
+	if p == nil {
+		return
+	}
+
+	metrics.Inc(metrics.HandlerPanics)
+	logger.Logger.Error("Reaction handler panicked",
+		zap.String("message_id", r.MessageID),
+		zap.String("channel_id", r.ChannelID),
+		zap.String("guild_id", r.GuildID),
+		zap.String("user_id", r.UserID),
+		zap.Any("panic", p),
+		zap.Stack("stack"))
+}
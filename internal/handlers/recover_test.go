@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/metrics"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// recordingTransport answers every Discord API request with an empty JSON object.
+type recordingTransport struct {
+	mu     sync.Mutex
+	bodies []string
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+	}
+	rt.mu.Lock()
+	rt.bodies = append(rt.bodies, string(body))
+	rt.mu.Unlock()
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader("{}")),
+		Request:    req,
+	}, nil
+}
+
+func setupTestSession(t *testing.T) (*discordgo.Session, *recordingTransport) {
+	s, err := discordgo.New("Bot test")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	transport := &recordingTransport{}
+	s.Client = &http.Client{Transport: transport}
+	return s, transport
+}
+
+func panickingRouter() *commands.Router {
+	router := commands.NewRouter(nil, nil)
+	router.Register(&commands.Command{Name: "boom", Description: "Panics", Handler: func(c *commands.Context) error {
+		var member *discordgo.Member
+		_ = member.User // a nil member, as in DM interactions
+		return nil
+	}})
+	return router
+}
+
+// TestMessageHandler_RecoversPanics tests that a panicking command is logged, counted and answered.
+func TestMessageHandler_RecoversPanics(t *testing.T) {
+	setupTestHandler(t)
+	s, transport := setupTestSession(t)
+	before := metrics.Default.Counter(metrics.HandlerPanics).Value()
+
+	handler := NewMessageHandler(panickingRouter(), nil)
+	handler.OnMessageCreate(s, &discordgo.MessageCreate{Message: &discordgo.Message{
+		ID:        "m1",
+		ChannelID: "c1",
+		Content:   "!boom",
+		Author:    &discordgo.User{ID: "u1"},
+	}})
+
+	if got := metrics.Default.Counter(metrics.HandlerPanics).Value(); got != before+1 {
+		t.Errorf("Expected panic counter %d, got %d", before+1, got)
+	}
+	if len(transport.bodies) != 1 || !strings.Contains(transport.bodies[0], "Something went wrong, please try again. (error ID: ") {
+		t.Errorf("Expected a generic error reply, got %v", transport.bodies)
+	}
+}
+
+// TestInteractionHandler_RecoversPanics tests recovery for slash commands.
+func TestInteractionHandler_RecoversPanics(t *testing.T) {
+	setupTestHandler(t)
+	s, transport := setupTestSession(t)
+	before := metrics.Default.Counter(metrics.HandlerPanics).Value()
+
+	handler := NewInteractionHandler(panickingRouter())
+	handler.OnInteractionCreate(s, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:        "i1",
+		AppID:     "a1",
+		Token:     "token",
+		Type:      discordgo.InteractionApplicationCommand,
+		ChannelID: "c1",
+		User:      &discordgo.User{ID: "u1"},
+		Data:      discordgo.ApplicationCommandInteractionData{Name: "boom"},
+	}})
+
+	if got := metrics.Default.Counter(metrics.HandlerPanics).Value(); got != before+1 {
+		t.Errorf("Expected panic counter %d, got %d", before+1, got)
+	}
+	if len(transport.bodies) != 1 || !strings.Contains(transport.bodies[0], "Something went wrong, please try again. (error ID: ") {
+		t.Errorf("Expected a generic error reply, got %v", transport.bodies)
+	}
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/logger"
+	"wooper-bot/internal/scheduler"
+	"wooper-bot/internal/services"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// ScheduleHandler handles the schedule command used to manage cron-style image posts.
+type ScheduleHandler struct {
+	ImageService *services.ImageService
+	Scheduler    *scheduler.Scheduler
+}
+
+func NewScheduleHandler(imageService *services.ImageService, sched *scheduler.Scheduler) *ScheduleHandler {
+	return &ScheduleHandler{ImageService: imageService, Scheduler: sched}
+}
+
+// Register adds the schedule command.
+func (h *ScheduleHandler) Register(r *commands.Router) {
+	r.Register(&commands.Command{
+		Name:        "schedule",
+		Description: "Manage scheduled image posts for this server",
+		Permissions: discordgo.PermissionManageServer,
+		GuildOnly:   true,
+		Subcommands: []*commands.Command{
+			{
+				Name:        "add",
+				Description: "Post a random image on a cron schedule",
+				Options: []*commands.Option{
+					{
+						Name:        "cron",
+						Description: "Cron expression, e.g. \"0 */6 * * *\" or @daily",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+					},
+					{
+						Name:         "category",
+						Description:  "Image category to post",
+						Type:         discordgo.ApplicationCommandOptionString,
+						Required:     true,
+						Autocomplete: categoryAutocomplete(h.ImageService),
+					},
+					{
+						Name:         "channel",
+						Description:  "Channel to post in",
+						Type:         discordgo.ApplicationCommandOptionChannel,
+						Required:     true,
+						ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+					},
+					{
+						Name:        "timezone",
+						Description: "IANA time zone the expression uses (default UTC)",
+						Type:        discordgo.ApplicationCommandOptionString,
+					},
+					{
+						Name:        "catchup",
+						Description: "What to do with runs missed while the bot was offline (default once)",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Choices: []*discordgo.ApplicationCommandOptionChoice{
+							{Name: "post once", Value: string(scheduler.CatchUpOnce)},
+							{Name: "skip", Value: string(scheduler.CatchUpSkip)},
+						},
+					},
+				},
+				Handler: h.handleAdd,
+			},
+			{
+				Name:        "list",
+				Description: "List scheduled posts",
+				Handler:     h.handleList,
+			},
+			{
+				Name:        "remove",
+				Description: "Remove a scheduled post",
+				Options: []*commands.Option{
+					{
+						Name:        "id",
+						Description: "ID shown by /schedule list",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+					},
+				},
+				Handler: h.handleRemove,
+			},
+		},
+	})
+}
+
+func (h *ScheduleHandler) handleAdd(c *commands.Context) error {
+	job := scheduler.Job{
+		GuildID:   c.GuildID,
+		ChannelID: c.String("channel"),
+		Category:  c.String("category"),
+		Spec:      strings.TrimSpace(c.String("cron")),
+		Timezone:  "UTC",
+		CatchUp:   scheduler.CatchUpOnce,
+		CreatedBy: c.User.ID,
+	}
+	if c.Has("timezone") {
+		job.Timezone = strings.TrimSpace(c.String("timezone"))
+	}
+	if c.Has("catchup") {
+		job.CatchUp = scheduler.CatchUp(c.String("catchup"))
+	}
+
+	saved, err := h.Scheduler.AddJob(job)
+	if err != nil {
+		logger.Logger.Warn("Invalid scheduled job",
+			zap.String("guild_id", c.GuildID),
+			zap.String("spec", job.Spec),
+			zap.Error(err))
+		if errors.Is(err, scheduler.ErrTooManyJobs) {
+			return c.ReplyEphemeral(c.T("schedule.too_many", scheduler.MaxJobsPerGuild))
+		}
+		if services.CodeOf(err) != "" {
+			return err
+		}
+		return c.ReplyEphemeral(c.T("schedule.invalid", err))
+	}
+
+	return c.ReplyEphemeral(c.T("schedule.added",
+		saved.ID, saved.Category, saved.ChannelID, saved.Spec, saved.Timezone, saved.NextRun.Unix()))
+}
+
+func (h *ScheduleHandler) handleList(c *commands.Context) error {
+	jobs := h.Scheduler.Jobs(c.GuildID)
+	if len(jobs) == 0 {
+		return c.ReplyEphemeral(c.T("schedule.none"))
+	}
+
+	var b strings.Builder
+	b.WriteString(c.T("schedule.title") + "\n")
+	for _, job := range jobs {
+		b.WriteString(c.T("schedule.job",
+			job.ID, job.Category, job.ChannelID, job.Spec, job.Timezone, job.CatchUp, job.NextRun.Unix()) + "\n")
+	}
+	return c.ReplyEphemeral(b.String())
+}
+
+func (h *ScheduleHandler) handleRemove(c *commands.Context) error {
+	id := strings.TrimPrefix(strings.TrimSpace(c.String("id")), "#")
+
+	removed, err := h.Scheduler.RemoveJob(c.GuildID, id)
+	if err != nil {
+		c.LogFields(zap.String("job_id", id))
+		return fmt.Errorf("remove scheduled job: %w", err)
+	}
+	if !removed {
+		return c.ReplyEphemeral(c.T("schedule.not_found", id))
+	}
+	return c.ReplyEphemeral(c.T("schedule.removed", id))
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/services"
+	"wooper-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// statsSize is how many categories, images and users each /stats ranking shows.
+const statsSize = 5
+
+// StatsHandler handles the stats command, built from the recorded usage history.
+type StatsHandler struct {
+	ImageService *services.ImageService
+	Store        *storage.Store
+}
+
+func NewStatsHandler(imageService *services.ImageService, store *storage.Store) *StatsHandler {
+	return &StatsHandler{ImageService: imageService, Store: store}
+}
+
+// Register adds the stats command.
+func (h *StatsHandler) Register(r *commands.Router) {
+	r.Register(&commands.Command{
+		Name:        "stats",
+		Description: "Image request statistics",
+		Subcommands: []*commands.Command{
+			{
+				Name:        "server",
+				Description: "Show the most requested categories and images of this server and its top users",
+				Options: []*commands.Option{
+					{
+						Name:         "category",
+						Description:  "Only count requests for this category",
+						Type:         discordgo.ApplicationCommandOptionString,
+						Autocomplete: categoryAutocomplete(h.ImageService),
+					},
+				},
+				Handler: h.handleServer,
+			},
+			{
+				Name:        "privacy",
+				Description: "Choose whether your name appears in statistics",
+				Options: []*commands.Option{
+					{
+						Name:        "listed",
+						Description: "Whether you can appear among the top users",
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Required:    true,
+					},
+				},
+				Handler: h.handlePrivacy,
+			},
+		},
+		RateLimits: []commands.RateLimit{
+			{Scope: commands.ScopeUser, Burst: 3, Every: 10 * time.Second},
+		},
+	})
+}
+
+func (h *StatsHandler) handleServer(c *commands.Context) error {
+	// Not a guild-only command, privacy works everywhere
+	if c.GuildID == "" {
+		return c.ReplyEphemeral(c.T("command.guild_only"))
+	}
+	category := c.String("category")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stats, err := h.Store.UsageStats(ctx, storage.UsageFilter{GuildID: c.GuildID, Category: category}, statsSize)
+	if err != nil {
+		return services.NewError(services.ErrCodeStorage, err)
+	}
+	c.LogFields(zap.String("category", category), zap.Int("requests", stats.Requests))
+	if stats.Requests == 0 {
+		return c.Reply(c.T("stats.none"))
+	}
+
+	title := c.T("stats.title")
+	if category != "" {
+		title = c.T("stats.title_category", category)
+	}
+	embed := &discordgo.MessageEmbed{
+		Title: title,
+		Description: c.T("stats.requests", stats.Requests, stats.AverageLatency.Round(time.Millisecond)) + "\n" +
+			c.T("stats.transports", stats.Transports[string(commands.TransportSlash)],
+				stats.Transports[string(commands.TransportText)], stats.Transports[string(commands.TransportComponent)]),
+		Color: embedColor,
+	}
+
+	if category == "" && len(stats.Categories) > 0 {
+		embed.Fields = append(embed.Fields, statsField(c.T("stats.categories"), stats.Categories, func(key string) string {
+			return key
+		}))
+	}
+	if len(stats.Images) > 0 {
+		embed.Fields = append(embed.Fields, statsField(c.T("stats.images"), stats.Images, func(key string) string {
+			if _, imageCategory, ok := h.ImageService.ImageByID(key); ok {
+				return c.T("stats.image", key, imageCategory)
+			}
+			return c.T("stats.image_gone", key)
+		}))
+	}
+	if len(stats.Users) > 0 {
+		embed.Fields = append(embed.Fields, statsField(c.T("stats.users"), stats.Users, func(key string) string {
+			return "<@" + key + ">"
+		}))
+	}
+	return c.Respond(&commands.Response{Embeds: []*discordgo.MessageEmbed{embed}})
+}
+
+// statsField renders a ranking as an inline embed field, naming each entry with name.
+func statsField(title string, counts []storage.UsageCount, name func(key string) string) *discordgo.MessageEmbedField {
+	lines := make([]string, len(counts))
+	for i, count := range counts {
+		lines[i] = strconv.Itoa(i+1) + ". " + name(count.Key) + " · " + strconv.Itoa(count.Count)
+	}
+	return &discordgo.MessageEmbedField{Name: title, Value: strings.Join(lines, "\n"), Inline: true}
+}
+
+func (h *StatsHandler) handlePrivacy(c *commands.Context) error {
+	listed := c.Bool("listed")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := h.Store.SetStatsOptOut(ctx, c.User.ID, !listed); err != nil {
+		return services.NewError(services.ErrCodeStorage, err)
+	}
+	c.LogFields(zap.Bool("listed", listed))
+	if listed {
+		return c.ReplyEphemeral(c.T("stats.listed"))
+	}
+	return c.ReplyEphemeral(c.T("stats.unlisted"))
+}
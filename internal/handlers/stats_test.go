@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// TestStatsHandler tests that served images show up in /stats, and that opting out hides the user.
+func TestStatsHandler(t *testing.T) {
+	_, imageService := setupTestHandler(t)
+	store, err := storage.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	router := commands.NewRouter(nil, nil)
+	NewImageHandler(imageService, store, nil).Register(router)
+	NewStatsHandler(imageService, store).Register(router)
+
+	var responder commands.Responder
+	router.Use(func(next commands.HandlerFunc) commands.HandlerFunc {
+		return func(c *commands.Context) error {
+			c.Responder = responder
+			return next(c)
+		}
+	})
+	run := func(guildID, name string, options ...*discordgo.ApplicationCommandInteractionDataOption) *uploadResponder {
+		recorder := &uploadResponder{}
+		responder = recorder
+		router.HandleInteraction(nil, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			Type:      discordgo.InteractionApplicationCommand,
+			GuildID:   guildID,
+			ChannelID: "c1",
+			User:      &discordgo.User{ID: "u1"},
+			Data:      discordgo.ApplicationCommandInteractionData{Name: name, Options: options},
+		}})
+		return recorder
+	}
+	subcommand := func(name string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.ApplicationCommandInteractionDataOption {
+		return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionSubCommand, Options: options}
+	}
+	category := &discordgo.ApplicationCommandInteractionDataOption{Name: "category", Type: discordgo.ApplicationCommandOptionString, Value: "wooper"}
+
+	if got := run("g1", "stats", subcommand("server")); len(got.responses) != 1 || !strings.Contains(got.responses[0].Content, "No images") {
+		t.Fatalf("Expected the empty stats message, got %+v", got.responses)
+	}
+
+	run("g1", "image", category)
+	run("g1", "image", category)
+	run("g2", "image", category)
+
+	embed := run("g1", "stats", subcommand("server")).responses[0].Embeds[0]
+	if !strings.HasPrefix(embed.Description, "2 images served") || !strings.Contains(embed.Description, "Slash commands: 2") {
+		t.Errorf("Expected 2 slash requests in g1, got %q", embed.Description)
+	}
+	if len(embed.Fields) != 3 || embed.Fields[0].Value != "1. wooper · 2" || embed.Fields[2].Value != "1. <@u1> · 2" {
+		t.Errorf("Unexpected fields %+v", embed.Fields)
+	}
+
+	listed := &discordgo.ApplicationCommandInteractionDataOption{Name: "listed", Type: discordgo.ApplicationCommandOptionBoolean, Value: false}
+	if got := run("", "stats", subcommand("privacy", listed)); !got.responses[0].Ephemeral {
+		t.Errorf("Expected an ephemeral confirmation, got %+v", got.responses[0])
+	}
+	embed = run("g1", "stats", subcommand("server", category)).responses[0].Embeds[0]
+	if embed.Title != "Server statistics · wooper" || len(embed.Fields) != 1 {
+		t.Errorf("Expected only the images of wooper without users, got %q with %+v", embed.Title, embed.Fields)
+	}
+
+	if got := run("", "stats", subcommand("server")); !got.responses[0].Ephemeral {
+		t.Errorf("Expected server stats to be refused in DMs, got %+v", got.responses[0])
+	}
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/services"
+	"wooper-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+const (
+	// tradeExpiry is how long a trade offer can be accepted.
+	tradeExpiry = 15 * time.Minute
+	// tradeHistorySize is how many trades /trade history lists.
+	tradeHistorySize = 10
+
+	componentTradeAccept  = "trade-accept"
+	componentTradeDecline = "trade-decline"
+)
+
+// TradeHandler handles trades of caught images between users.
+type TradeHandler struct {
+	ImageService *services.ImageService
+	Store        *storage.Store
+}
+
+func NewTradeHandler(imageService *services.ImageService, store *storage.Store) *TradeHandler {
+	return &TradeHandler{ImageService: imageService, Store: store}
+}
+
+// Register adds the trade command and the accept and decline buttons of offers.
+func (h *TradeHandler) Register(r *commands.Router) {
+	r.Register(&commands.Command{
+		Name:        "trade",
+		Description: "Swap caught images with other members",
+		Subcommands: []*commands.Command{
+			{
+				Name:        "offer",
+				Description: "Offer one of your images for one of another member's",
+				Options: []*commands.Option{
+					{
+						Name:        "user",
+						Description: "Member to trade with",
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Required:    true,
+					},
+					{
+						Name:        "give",
+						Description: "ID of the image you give, as shown by /inventory",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+						MaxLength:   20,
+					},
+					{
+						Name:        "want",
+						Description: "ID of the image you want in return",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+						MaxLength:   20,
+					},
+				},
+				Handler: h.handleOffer,
+			},
+			{
+				Name:        "history",
+				Description: "List your latest trades",
+				Handler:     h.handleHistory,
+			},
+		},
+		GuildOnly: true,
+		RateLimits: []commands.RateLimit{
+			{Scope: commands.ScopeUser, Burst: 3, Every: 10 * time.Second},
+		},
+	})
+
+	tradeOption := []*commands.Option{{Name: "trade", Type: discordgo.ApplicationCommandOptionInteger, Required: true}}
+	r.RegisterComponent(
+		// Declining stays open so a member who may not trade can still turn an offer down
+		&commands.Command{Name: componentTradeAccept, Options: tradeOption, Parent: "trade", Handler: h.handleAccept},
+		&commands.Command{Name: componentTradeDecline, Options: tradeOption, Handler: h.handleDecline},
+	)
+}
+
+func (h *TradeHandler) handleOffer(c *commands.Context) error {
+	to := c.String("user")
+	give := strings.ToLower(strings.Trim(c.String("give"), "` "))
+	want := strings.ToLower(strings.Trim(c.String("want"), "` "))
+	if to == c.User.ID {
+		return c.ReplyEphemeral(c.T("trade.self"))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, ok, err := h.Store.CatchOf(ctx, c.User.ID, give); err != nil {
+		return services.NewError(services.ErrCodeStorage, err)
+	} else if !ok {
+		return c.ReplyEphemeral(c.T("trade.not_owned", give))
+	}
+	if _, ok, err := h.Store.CatchOf(ctx, to, want); err != nil {
+		return services.NewError(services.ErrCodeStorage, err)
+	} else if !ok {
+		return c.ReplyEphemeral(c.T("trade.not_owned_by", to, want))
+	}
+
+	trade := storage.Trade{
+		GuildID:    c.GuildID,
+		FromUserID: c.User.ID,
+		ToUserID:   to,
+		GiveID:     give,
+		WantID:     want,
+		Status:     storage.TradePending,
+		CreatedAt:  time.Now(),
+		ExpiresAt:  time.Now().Add(tradeExpiry),
+	}
+	id, err := h.Store.CreateTrade(ctx, trade)
+	if err != nil {
+		return services.NewError(services.ErrCodeStorage, err)
+	}
+	trade.ID = id
+
+	c.LogFields(zap.Int64("trade_id", id), zap.String("to_user_id", to), zap.String("give", give), zap.String("want", want))
+	response := h.tradeResponse(c, trade)
+	response.Content = c.T("trade.ping", to)
+	return c.Respond(response)
+}
+
+// handleAccept swaps the images of the trade whose accept button was pressed.
+func (h *TradeHandler) handleAccept(c *commands.Context) error {
+	return h.resolve(c, h.Store.AcceptTrade)
+}
+
+// handleDecline declines or cancels the trade whose decline button was pressed.
+func (h *TradeHandler) handleDecline(c *commands.Context) error {
+	return h.resolve(c, h.Store.DeclineTrade)
+}
+
+// resolve closes the trade of a pressed button with closeTrade, then shows its new state on the
+// offer. Buttons of closed trades show why they are closed instead.
+func (h *TradeHandler) resolve(c *commands.Context, closeTrade func(ctx context.Context, id int64, userID string) (storage.Trade, error)) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := h.Store.ExpireTrades(ctx); err != nil {
+		return services.NewError(services.ErrCodeStorage, err)
+	}
+	id := c.Int("trade", 0)
+	trade, err := closeTrade(ctx, id, c.User.ID)
+	switch {
+	case errors.Is(err, storage.ErrTradeClosed) && trade.ID == 0:
+		return c.ReplyEphemeral(c.T("trade.gone"))
+	case errors.Is(err, storage.ErrTradeClosed) && trade.Status == storage.TradePending:
+		return c.ReplyEphemeral(c.T("trade.not_yours"))
+	case err != nil && !errors.Is(err, storage.ErrTradeClosed) && !errors.Is(err, storage.ErrNotOwned):
+		return services.NewError(services.ErrCodeStorage, err)
+	}
+
+	c.LogFields(zap.Int64("trade_id", id), zap.String("status", string(trade.Status)))
+	response := h.tradeResponse(c, trade)
+	response.Update = true
+	return c.Respond(response)
+}
+
+// tradeResponse shows a trade with its state, and the accept and decline buttons while it is pending.
+func (h *TradeHandler) tradeResponse(c *commands.Context, trade storage.Trade) *commands.Response {
+	status := c.T("trade.status." + string(trade.Status))
+	color := 0x9e9e9e
+	switch trade.Status {
+	case storage.TradePending:
+		status = c.T("trade.expires", trade.ExpiresAt.Unix())
+		color = embedColor
+	case storage.TradeAccepted:
+		color = 0x4caf50
+	}
+
+	response := &commands.Response{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title: c.T("trade.title", trade.ID),
+			Description: c.T("trade.gives", trade.FromUserID, h.describe(c, trade.GiveID)) + "\n" +
+				c.T("trade.gives", trade.ToUserID, h.describe(c, trade.WantID)),
+			Color:  color,
+			Fields: []*discordgo.MessageEmbedField{{Name: c.T("trade.state"), Value: status}},
+		}},
+		// An empty list removes the buttons of closed trades
+		Components: []discordgo.MessageComponent{},
+	}
+	if trade.Status == storage.TradePending {
+		id := strconv.FormatInt(trade.ID, 10)
+		response.Components = []discordgo.MessageComponent{discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    c.T("trade.accept"),
+				Style:    discordgo.SuccessButton,
+				Emoji:    &discordgo.ComponentEmoji{Name: "✅"},
+				CustomID: commands.CustomID(componentTradeAccept, id),
+			},
+			discordgo.Button{
+				Label:    c.T("trade.decline"),
+				Style:    discordgo.DangerButton,
+				Emoji:    &discordgo.ComponentEmoji{Name: "✖️"},
+				CustomID: commands.CustomID(componentTradeDecline, id),
+			},
+		}}}
+	}
+	return response
+}
+
+// describe names a traded image with its category and rarity, or just its ID once it is gone.
+func (h *TradeHandler) describe(c *commands.Context, imageID string) string {
+	if _, category, ok := h.ImageService.ImageByID(imageID); ok {
+		return c.T("trade.image", imageID, category, c.T("rarity."+services.RarityOf(imageID).String()))
+	}
+	return "`" + imageID + "`"
+}
+
+func (h *TradeHandler) handleHistory(c *commands.Context) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := h.Store.ExpireTrades(ctx); err != nil {
+		return services.NewError(services.ErrCodeStorage, err)
+	}
+	trades, err := h.Store.Trades(ctx, c.User.ID, tradeHistorySize)
+	if err != nil {
+		return services.NewError(services.ErrCodeStorage, err)
+	}
+	if len(trades) == 0 {
+		return c.ReplyEphemeral(c.T("trade.no_history"))
+	}
+
+	lines := make([]string, len(trades))
+	for i, t := range trades {
+		lines[i] = c.T("trade.history_entry", t.ID, t.FromUserID, t.GiveID, t.ToUserID, t.WantID,
+			c.T("trade.status."+string(t.Status)), t.CreatedAt.Unix())
+	}
+	return c.Respond(&commands.Response{
+		Embeds: []*discordgo.MessageEmbed{{
+			Title:       c.T("trade.history"),
+			Description: strings.Join(lines, "\n"),
+			Color:       embedColor,
+		}},
+		Ephemeral: true,
+	})
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// TestTradeHandler tests offering a trade and accepting it once with its button.
+func TestTradeHandler(t *testing.T) {
+	_, imageService := setupTestHandler(t)
+	store, err := storage.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	router := commands.NewRouter(nil, nil)
+	NewTradeHandler(imageService, store).Register(router)
+
+	ctx := context.Background()
+	wooper := imageService.ImageID(imageService.GetCategoryImages("wooper")[0])
+	cat := imageService.ImageID(imageService.GetCategoryImages("cats")[0])
+	if _, err := store.AddCatch(ctx, "u2", wooper, "wooper", 0); err != nil {
+		t.Fatalf("Failed to add catch: %v", err)
+	}
+	if _, err := store.AddCatch(ctx, "u1", cat, "cats", 0); err != nil {
+		t.Fatalf("Failed to add catch: %v", err)
+	}
+
+	// u2 offers to u1, who presses the buttons
+	offer := func(give, want string) *uploadResponder {
+		responder := &uploadResponder{}
+		router.Use(func(next commands.HandlerFunc) commands.HandlerFunc {
+			return func(c *commands.Context) error {
+				c.Responder = responder
+				return next(c)
+			}
+		})
+		option := func(name, value string, optionType discordgo.ApplicationCommandOptionType) *discordgo.ApplicationCommandInteractionDataOption {
+			return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: optionType, Value: value}
+		}
+		router.HandleInteraction(nil, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			Type:      discordgo.InteractionApplicationCommand,
+			GuildID:   "g1",
+			ChannelID: "c1",
+			Member:    &discordgo.Member{User: &discordgo.User{ID: "u2"}},
+			Data: discordgo.ApplicationCommandInteractionData{Name: "trade", Options: []*discordgo.ApplicationCommandInteractionDataOption{{
+				Name: "offer",
+				Type: discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandInteractionDataOption{
+					option("user", "u1", discordgo.ApplicationCommandOptionUser),
+					option("give", give, discordgo.ApplicationCommandOptionString),
+					option("want", want, discordgo.ApplicationCommandOptionString),
+				},
+			}}},
+		}})
+		return responder
+	}
+
+	if got := offer(cat, wooper); !got.responses[0].Ephemeral || !strings.Contains(got.responses[0].Content, "not in your inventory") {
+		t.Errorf("Expected an offer of an image u2 doesn't have to be refused, got %+v", got.responses[0])
+	}
+
+	got := offer(wooper, "`"+cat+"`")
+	response := got.responses[0]
+	if response.Content != "<@u1>, you have a trade offer!" || !strings.Contains(response.Embeds[0].Description, "<@u2> gives `"+wooper+"` · wooper") {
+		t.Fatalf("Expected the offer pinging u1, got %+v", response)
+	}
+	accept := response.Components[0].(discordgo.ActionsRow).Components[0].(discordgo.Button)
+
+	for i := 0; i < 2; i++ {
+		responder := &uploadResponder{}
+		pressButton(router, responder, accept.CustomID)
+		embed := responder.responses[0].Embeds[0]
+		if !responder.responses[0].Update || !strings.HasPrefix(embed.Fields[0].Value, "accepted") || len(responder.responses[0].Components) != 0 {
+			t.Errorf("Expected the offer to show the accepted trade without buttons, got %+v", responder.responses[0])
+		}
+	}
+
+	if c, ok, _ := store.CatchOf(ctx, "u1", wooper); !ok || c.Count != 1 {
+		t.Errorf("Expected u1 to have received the image once, got %+v", c)
+	}
+	if _, ok, _ := store.CatchOf(ctx, "u1", cat); ok {
+		t.Errorf("Expected u1 to have given their image away")
+	}
+}
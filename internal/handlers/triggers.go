@@ -0,0 +1,462 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/logger"
+	"wooper-bot/internal/outbound"
+	"wooper-bot/internal/services"
+	"wooper-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxTriggers is how many triggers a guild can have.
+	maxTriggers = 25
+	// defaultTriggerCooldown is how often a trigger may fire in a channel unless set, in seconds.
+	defaultTriggerCooldown = 60
+)
+
+// triggerImageLimits cap the images all of a guild's triggers post, on top of each trigger's
+// cooldown. Images over the limit are dropped silently, chat never gets a "slow down".
+var triggerImageLimits = []commands.RateLimit{{Scope: commands.ScopeGuild, Burst: 5, Every: 20 * time.Second}}
+
+// customEmoji matches a server emoji as typed in a message, e.g. <:wooper:123456789012345678>.
+var customEmoji = regexp.MustCompile(`^<a?:(\w{2,32}):(\d{17,20})>$`)
+
+// trigger is a stored trigger with its compiled pattern.
+type trigger struct {
+	storage.Trigger
+	matches services.TriggerMatcher
+}
+
+// TriggerHandler answers chat messages matching a guild's triggers with a reaction or an
+// image, and manages triggers with the trigger command. Answers don't go through the command
+// pipeline, so chat doesn't use up anyone's command rate limits or get error replies.
+type TriggerHandler struct {
+	Router       *commands.Router // for guild settings
+	ImageService *services.ImageService
+	Store        *storage.Store
+	Dispatcher   *outbound.Dispatcher
+
+	limiter *commands.Limiter
+	mu      sync.Mutex
+	guilds  map[string][]trigger // cached triggers by guild ID
+}
+
+func NewTriggerHandler(router *commands.Router, imageService *services.ImageService, store *storage.Store, dispatcher *outbound.Dispatcher) *TriggerHandler {
+	return &TriggerHandler{
+		Router:       router,
+		ImageService: imageService,
+		Store:        store,
+		Dispatcher:   dispatcher,
+		limiter:      commands.NewLimiter(),
+		guilds:       make(map[string][]trigger),
+	}
+}
+
+// Register adds the trigger command.
+func (h *TriggerHandler) Register(r *commands.Router) {
+	minCooldown := 5.0
+	options := func(value *commands.Option) []*commands.Option {
+		return []*commands.Option{
+			{
+				Name:        "match",
+				Description: "How the pattern is looked for in messages",
+				Type:        discordgo.ApplicationCommandOptionString,
+				Required:    true,
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "keyword", Value: string(storage.TriggerKeyword)},
+					{Name: "word", Value: string(storage.TriggerWord)},
+					{Name: "regex", Value: string(storage.TriggerRegex)},
+				},
+			},
+			{
+				Name:        "pattern",
+				Description: "Keyword, words or regular expression to look for, case is ignored",
+				Type:        discordgo.ApplicationCommandOptionString,
+				Required:    true,
+				MaxLength:   services.MaxTriggerPatternLength,
+			},
+			value,
+			{
+				Name:        "cooldown",
+				Description: "Seconds before the trigger can fire again in a channel (default 60)",
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				MinValue:    &minCooldown,
+				MaxValue:    86400,
+			},
+		}
+	}
+
+	r.Register(&commands.Command{
+		Name:        "trigger",
+		Description: "Manage automatic answers to chat messages in this server",
+		Permissions: discordgo.PermissionManageServer,
+		GuildOnly:   true,
+		Subcommands: []*commands.Command{
+			{
+				Name:        "react",
+				Description: "React with an emoji to messages matching a pattern",
+				Options: options(&commands.Option{
+					Name:        "emoji",
+					Description: "Emoji to react with",
+					Type:        discordgo.ApplicationCommandOptionString,
+					Required:    true,
+				}),
+				Handler: h.handleAdd(storage.TriggerReact),
+			},
+			{
+				Name:        "image",
+				Description: "Send a random image to messages matching a pattern",
+				Options: options(&commands.Option{
+					Name:         "category",
+					Description:  "Image category to send",
+					Type:         discordgo.ApplicationCommandOptionString,
+					Required:     true,
+					Autocomplete: categoryAutocomplete(h.ImageService),
+				}),
+				Handler: h.handleAdd(storage.TriggerImage),
+			},
+			{
+				Name:        "list",
+				Description: "List this server's triggers",
+				Handler:     h.handleList,
+			},
+			{
+				Name:        "remove",
+				Description: "Remove a trigger",
+				Options: []*commands.Option{
+					{
+						Name:        "id",
+						Description: "ID shown by /trigger list",
+						Type:        discordgo.ApplicationCommandOptionString,
+						Required:    true,
+					},
+				},
+				Handler: h.handleRemove,
+			},
+		},
+	})
+}
+
+// handleAdd returns the handler adding a trigger with the given action.
+func (h *TriggerHandler) handleAdd(action storage.TriggerAction) commands.HandlerFunc {
+	return func(c *commands.Context) error {
+		t := storage.Trigger{
+			GuildID:   c.GuildID,
+			Match:     storage.TriggerMatch(c.String("match")),
+			Pattern:   strings.TrimSpace(c.String("pattern")),
+			Action:    action,
+			Cooldown:  time.Duration(c.Int("cooldown", defaultTriggerCooldown)) * time.Second,
+			CreatedBy: c.User.ID,
+		}
+		if _, err := services.CompileTrigger(t.Match, t.Pattern); err != nil {
+			return c.ReplyEphemeral(c.T("trigger.invalid_pattern", err))
+		}
+
+		switch action {
+		case storage.TriggerReact:
+			t.Value = strings.TrimSpace(c.String("emoji"))
+			if !validEmoji(t.Value) {
+				return c.ReplyEphemeral(c.T("trigger.invalid_emoji", t.Value))
+			}
+		case storage.TriggerImage:
+			t.Value = c.String("category")
+			if !h.ImageService.HasCategory(t.Value) {
+				available := c.Settings.FilterCategories(h.ImageService.GetAvailableCategories())
+				return c.ReplyEphemeral(c.T("image.category_not_found", t.Value, strings.Join(available, ", ")))
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		existing, err := h.Store.Triggers(ctx, c.GuildID)
+		if err != nil {
+			return services.NewError(services.ErrCodeStorage, err)
+		}
+		if len(existing) >= maxTriggers {
+			return c.ReplyEphemeral(c.T("trigger.too_many", maxTriggers))
+		}
+		saved, err := h.Store.AddTrigger(ctx, t)
+		if err != nil {
+			return services.NewError(services.ErrCodeStorage, err)
+		}
+		h.forget(c.GuildID)
+
+		c.LogFields(zap.Int64("trigger_id", saved.ID))
+		return c.ReplyEphemeral(c.T("trigger.added", describeTrigger(c, saved)))
+	}
+}
+
+func (h *TriggerHandler) handleList(c *commands.Context) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	triggers, err := h.Store.Triggers(ctx, c.GuildID)
+	if err != nil {
+		return services.NewError(services.ErrCodeStorage, err)
+	}
+	if len(triggers) == 0 {
+		return c.ReplyEphemeral(c.T("trigger.none"))
+	}
+
+	var b strings.Builder
+	b.WriteString(c.T("trigger.title") + "\n")
+	for _, t := range triggers {
+		b.WriteString("• " + describeTrigger(c, t) + "\n")
+	}
+	return c.ReplyEphemeral(b.String())
+}
+
+func (h *TriggerHandler) handleRemove(c *commands.Context) error {
+	input := strings.TrimPrefix(strings.TrimSpace(c.String("id")), "#")
+	id, err := strconv.ParseInt(input, 10, 64)
+	if err != nil {
+		return c.ReplyEphemeral(c.T("trigger.not_found", input))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	removed, err := h.Store.RemoveTrigger(ctx, c.GuildID, id)
+	if err != nil {
+		return services.NewError(services.ErrCodeStorage, err)
+	}
+	if !removed {
+		return c.ReplyEphemeral(c.T("trigger.not_found", input))
+	}
+	h.forget(c.GuildID)
+	return c.ReplyEphemeral(c.T("trigger.removed", id))
+}
+
+// describeTrigger renders a trigger for the add confirmation and the list.
+func describeTrigger(c *commands.Context, t storage.Trigger) string {
+	answer := c.T("trigger.react", t.Value)
+	if t.Action == storage.TriggerImage {
+		answer = c.T("trigger.image", t.Value)
+	}
+	return c.T("trigger.entry", t.ID, c.T("trigger.match."+string(t.Match), t.Pattern), answer, int(t.Cooldown.Seconds()))
+}
+
+// validEmoji reports whether s looks like an emoji Discord can react with: a server emoji,
+// or a short text without letters, digits or spaces of the ASCII range.
+func validEmoji(s string) bool {
+	if customEmoji.MatchString(s) {
+		return true
+	}
+	if s == "" || len(s) > 32 {
+		return false
+	}
+	for _, r := range s {
+		if r < unicode.MaxASCII || unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// reactionEmoji converts an emoji as typed to the form the reaction API takes, name:id for
+// server emojis.
+func reactionEmoji(s string) string {
+	if m := customEmoji.FindStringSubmatch(s); m != nil {
+		return m[1] + ":" + m[2]
+	}
+	return s
+}
+
+// OnMessageCreate fires the first trigger of the guild matching m that isn't cooling down
+// in its channel. MessageHandler calls it for messages that aren't commands.
+func (h *TriggerHandler) OnMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if m.GuildID == "" || m.Author == nil || m.Author.Bot || m.Content == "" {
+		return
+	}
+
+	for _, t := range h.triggers(m.GuildID) {
+		if !t.matches(m.Content) {
+			continue
+		}
+		limit := []commands.RateLimit{{Scope: commands.ScopeChannel, Burst: 1, Every: t.Cooldown}}
+		if ok, _ := h.limiter.Allow([]string{fmt.Sprintf("trigger/%d:%s", t.ID, m.ChannelID)}, limit); !ok {
+			continue
+		}
+
+		logger.Logger.Info("Trigger fired",
+			zap.Int64("trigger_id", t.ID),
+			zap.String("guild_id", m.GuildID),
+			zap.String("channel_id", m.ChannelID),
+			zap.String("user_id", m.Author.ID))
+		switch t.Action {
+		case storage.TriggerReact:
+			if err := s.MessageReactionAdd(m.ChannelID, m.ID, reactionEmoji(t.Value)); err != nil {
+				logger.Logger.Warn("Failed to react to a trigger",
+					zap.Int64("trigger_id", t.ID),
+					zap.String("emoji", t.Value),
+					zap.Error(err))
+			}
+		case storage.TriggerImage:
+			h.sendImage(s, m, t)
+		}
+		return
+	}
+}
+
+// deniedCategories returns the categories the guild's permission rules deny to m's author in
+// m's channel, as RequireAccess does for commands. It reports false when the rules can't be
+// loaded, so triggers stay quiet rather than ignore restrictions.
+func (h *TriggerHandler) deniedCategories(s *discordgo.Session, m *discordgo.MessageCreate) ([]string, bool) {
+	if h.Store == nil {
+		return nil, true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	rules, err := h.Store.PermissionRules(ctx, m.GuildID)
+	if err != nil {
+		logger.Logger.Warn("Failed to load permission rules for a trigger",
+			zap.String("guild_id", m.GuildID),
+			zap.Error(err))
+		return nil, false
+	}
+	if len(rules) == 0 {
+		return nil, true
+	}
+	// Server managers are never restricted
+	if permissions, err := s.State.MessagePermissions(m.Message); err == nil &&
+		permissions&(discordgo.PermissionAdministrator|discordgo.PermissionManageServer) != 0 {
+		return nil, true
+	}
+
+	var roles []string
+	if m.Member != nil {
+		roles = m.Member.Roles
+	}
+	return services.PermissionRules(rules).DeniedCategories(roles, m.ChannelID), true
+}
+
+// sendImage answers m with a random image of the trigger's category. Failures are only logged.
+func (h *TriggerHandler) sendImage(s *discordgo.Session, m *discordgo.MessageCreate, t trigger) {
+	category := t.Value
+	settings := h.Router.Settings(m.GuildID)
+	denied, ok := h.deniedCategories(s, m)
+	if !ok {
+		return
+	}
+	settings.DeniedCategories = denied
+	if !h.ImageService.HasCategory(category) || !settings.CategoryEnabled(category) {
+		return
+	}
+	if ok, _ := h.limiter.Allow([]string{"trigger-images:" + m.GuildID}, triggerImageLimits); !ok {
+		return
+	}
+	imagePath := h.ImageService.GetRandomImage(category)
+	if imagePath == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	reader, fileName, err := h.ImageService.GetImageFile(ctx, imagePath)
+	if err != nil {
+		logger.Logger.Warn("Failed to load trigger image",
+			zap.Int64("trigger_id", t.ID),
+			zap.String("image_path", imagePath),
+			zap.Error(err))
+		h.ImageService.ReportFailure(imagePath, err)
+		return
+	}
+	defer reader.Close()
+
+	id := h.ImageService.ImageID(imagePath)
+	data := &discordgo.MessageSend{Files: []*discordgo.File{imageAttachment(id, fileName, reader)}}
+	if settings.ReplyStyle == services.ReplyStyleReply {
+		data.Reference = m.Reference()
+	}
+	err = h.Dispatcher.Do(ctx, outbound.ChannelRoute(m.ChannelID), outbound.Resendable(data.Files, func(options ...discordgo.RequestOption) error {
+		_, err := s.ChannelMessageSendComplex(m.ChannelID, data, options...)
+		return err
+	}))
+	if err != nil {
+		logger.Logger.Warn("Failed to send trigger image",
+			zap.Int64("trigger_id", t.ID),
+			zap.String("filename", fileName),
+			zap.Error(err))
+		if outbound.IsFileRejected(err) {
+			h.ImageService.ReportFailure(imagePath, err)
+		}
+		return
+	}
+	h.ImageService.ReportSuccess(imagePath)
+
+	if h.Store == nil {
+		return
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = h.Store.RecordUsage(ctx, storage.Usage{
+		GuildID:   m.GuildID,
+		ChannelID: m.ChannelID,
+		UserID:    m.Author.ID,
+		Command:   "trigger",
+		Category:  category,
+		Image:     fileName,
+		ImageID:   id,
+		Transport: string(commands.TransportText),
+	})
+	if err != nil {
+		logger.Logger.Warn("Failed to record usage", zap.String("category", category), zap.Error(err))
+	}
+}
+
+// triggers returns a guild's compiled triggers, loading them on first use.
+func (h *TriggerHandler) triggers(guildID string) []trigger {
+	h.mu.Lock()
+	cached, ok := h.guilds[guildID]
+	h.mu.Unlock()
+	if ok {
+		return cached
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	stored, err := h.Store.Triggers(ctx, guildID)
+	if err != nil {
+		// Not cached, so the next message tries again
+		logger.Logger.Warn("Failed to load triggers", zap.String("guild_id", guildID), zap.Error(err))
+		return nil
+	}
+	compiled := make([]trigger, 0, len(stored))
+	for _, t := range stored {
+		matches, err := services.CompileTrigger(t.Match, t.Pattern)
+		if err != nil {
+			logger.Logger.Warn("Invalid stored trigger", zap.Int64("trigger_id", t.ID), zap.Error(err))
+			continue
+		}
+		compiled = append(compiled, trigger{Trigger: t, matches: matches})
+	}
+
+	h.mu.Lock()
+	h.guilds[guildID] = compiled
+	h.mu.Unlock()
+	return compiled
+}
+
+// forget drops a guild's cached triggers after they changed.
+func (h *TriggerHandler) forget(guildID string) {
+	h.mu.Lock()
+	delete(h.guilds, guildID)
+	h.mu.Unlock()
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// TestTriggerHandler tests managing triggers and answering chat messages with them.
+func TestTriggerHandler(t *testing.T) {
+	_, imageService := setupTestHandler(t)
+	store, err := storage.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	router := commands.NewRouter(nil, nil)
+	NewImageHandler(imageService, store, nil).Register(router)
+	triggers := NewTriggerHandler(router, imageService, store, nil)
+	triggers.Register(router)
+	messages := NewMessageHandler(router, triggers)
+
+	responder := &uploadResponder{}
+	router.Use(func(next commands.HandlerFunc) commands.HandlerFunc {
+		return func(c *commands.Context) error {
+			c.Responder = responder
+			return next(c)
+		}
+	})
+	run := func(sub string, options map[string]any) string {
+		responder.responses = nil
+		var data []*discordgo.ApplicationCommandInteractionDataOption
+		for name, value := range options {
+			optionType := discordgo.ApplicationCommandOptionString
+			if _, ok := value.(float64); ok {
+				optionType = discordgo.ApplicationCommandOptionInteger
+			}
+			data = append(data, &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: optionType, Value: value})
+		}
+		router.HandleInteraction(nil, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			Type:      discordgo.InteractionApplicationCommand,
+			GuildID:   "g1",
+			ChannelID: "c1",
+			Member:    &discordgo.Member{User: &discordgo.User{ID: "u1"}},
+			Data: discordgo.ApplicationCommandInteractionData{Name: "trigger", Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: sub, Type: discordgo.ApplicationCommandOptionSubCommand, Options: data},
+			}},
+		}})
+		if len(responder.responses) != 1 {
+			t.Fatalf("Expected one answer to /trigger %s, got %+v", sub, responder.responses)
+		}
+		return responder.responses[0].Content
+	}
+
+	if got := run("react", map[string]any{"match": "regex", "pattern": "(quag", "emoji": "🐸"}); !strings.HasPrefix(got, "That pattern can't be used") {
+		t.Errorf("Expected the invalid regex to be refused, got %q", got)
+	}
+	if got := run("react", map[string]any{"match": "keyword", "pattern": "quag", "emoji": "frog"}); !strings.Contains(got, "isn't an emoji") {
+		t.Errorf("Expected the invalid emoji to be refused, got %q", got)
+	}
+	// Categories are directory names, matched exactly like /image does
+	if got := run("image", map[string]any{"match": "word", "pattern": "wooper", "category": "Wooper"}); !strings.Contains(got, "Wooper") || strings.HasPrefix(got, "Trigger added") {
+		t.Errorf("Expected the misspelled category to be refused, got %q", got)
+	}
+	if got := run("image", map[string]any{"match": "word", "pattern": "wooper", "category": "wooper", "cooldown": float64(60)}); got != "Trigger added: `#1` the words `wooper` → send a wooper image, at most every 60 seconds in each channel" {
+		t.Errorf("Unexpected confirmation %q", got)
+	}
+	if got := run("react", map[string]any{"match": "keyword", "pattern": "quag", "emoji": "<:quagsire:123456789012345678>"}); !strings.Contains(got, "`#2` messages containing `quag` → react with <:quagsire:123456789012345678>, at most every 60 seconds") {
+		t.Errorf("Unexpected confirmation %q", got)
+	}
+	if got := run("list", nil); strings.Count(got, "• ") != 2 {
+		t.Errorf("Expected both triggers listed, got %q", got)
+	}
+
+	transport := &messageTransport{message: "{}"}
+	s, err := discordgo.New("Bot test")
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+	s.Client = &http.Client{Transport: transport}
+	s.State.User = &discordgo.User{ID: "bot"}
+	say := func(channelID, content string) {
+		responder.responses = nil
+		messages.OnMessageCreate(s, &discordgo.MessageCreate{Message: &discordgo.Message{
+			ID: "m1", GuildID: "g1", ChannelID: channelID, Content: content, Author: &discordgo.User{ID: "u2"},
+		}})
+	}
+
+	tests := []struct {
+		name      string
+		channelID string
+		content   string
+		images    int
+		reactions int
+	}{
+		{"image trigger", "c1", "Look, a wooper!", 1, 0},
+		{"cooling down", "c1", "another wooper", 0, 0},
+		{"other channel", "c2", "WOOPER", 1, 0},
+		{"not a whole word", "c3", "woopers everywhere", 0, 0},
+		{"reaction trigger", "c3", "so quaggy", 0, 1},
+		{"commands don't trigger", "c4", "!wooper", 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests, posts := transport.requests, transport.posts
+			say(tt.channelID, tt.content)
+			// Trigger images are posted to the channel, command answers go to the responder.
+			images := transport.posts - posts
+			for _, response := range responder.responses {
+				if len(response.Files) > 0 {
+					images++
+				}
+			}
+			reactions := transport.requests - requests - (transport.posts - posts)
+			if images != tt.images || reactions != tt.reactions {
+				t.Errorf("Expected %d images and %d reactions, got %d and %d", tt.images, tt.reactions, images, reactions)
+			}
+		})
+	}
+
+	// Category rules apply to trigger images like they do to /image
+	rule, err := store.AddPermissionRule(context.Background(), storage.PermissionRule{
+		GuildID: "g1", Target: storage.PermissionCategory, Name: "wooper", ChannelID: "c9", CreatedBy: "u1",
+	})
+	if err != nil {
+		t.Fatalf("Failed to add permission rule: %v", err)
+	}
+	for _, tt := range []struct {
+		channelID string
+		images    int
+	}{{"c8", 0}, {"c9", 1}} {
+		posts := transport.posts
+		say(tt.channelID, "wooper")
+		if got := transport.posts - posts; got != tt.images {
+			t.Errorf("Expected %d images in %s with wooper restricted to c9, got %d", tt.images, tt.channelID, got)
+		}
+	}
+	if _, err := store.RemovePermissionRule(context.Background(), "g1", rule.ID); err != nil {
+		t.Fatalf("Failed to remove permission rule: %v", err)
+	}
+
+	// Triggers share a quiet guild-wide limit and stay out of the command pipeline: chat gets
+	// no replies and doesn't use up anyone's /image rate limit.
+	posts := transport.posts
+	for i := range 10 {
+		say(fmt.Sprintf("flood%d", i), "wooper")
+		if len(responder.responses) != 0 {
+			t.Fatalf("Expected triggers not to reply, got %+v", responder.responses)
+		}
+	}
+	if got := transport.posts - posts; got != triggerImageLimits[0].Burst-3 {
+		t.Errorf("Expected the guild limit to cap trigger images at %d, got %d", triggerImageLimits[0].Burst-3, got)
+	}
+	responder.responses = nil
+	router.HandleInteraction(nil, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:    discordgo.InteractionApplicationCommand,
+		GuildID: "g1",
+		Member:  &discordgo.Member{User: &discordgo.User{ID: "u2"}},
+		Data: discordgo.ApplicationCommandInteractionData{Name: "image", Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: "category", Type: discordgo.ApplicationCommandOptionString, Value: "wooper"},
+		}},
+	}})
+	if len(responder.responses) != 1 || len(responder.responses[0].Files) != 1 {
+		t.Errorf("Expected /image to still answer with an image, got %+v", responder.responses)
+	}
+
+	if got := run("remove", map[string]any{"id": "#1"}); got != "Trigger #1 removed." {
+		t.Errorf("Unexpected removal answer %q", got)
+	}
+	if got := run("remove", map[string]any{"id": "1"}); got != "No trigger #1 in this server." {
+		t.Errorf("Expected the trigger to be gone, got %q", got)
+	}
+	say("c5", "wooper")
+	if len(responder.responses) != 0 {
+		t.Errorf("Expected removed triggers not to fire, got %+v", responder.responses)
+	}
+}
+
+// TestValidEmoji tests which reaction emojis triggers accept and how they are sent.
+func TestValidEmoji(t *testing.T) {
+	tests := []struct {
+		emoji    string
+		valid    bool
+		reaction string
+	}{
+		{"🐸", true, "🐸"},
+		{"❤️", true, "❤️"},
+		{"<:quagsire:123456789012345678>", true, "quagsire:123456789012345678"},
+		{"<a:dance:123456789012345678>", true, "dance:123456789012345678"},
+		{"frog", false, ""},
+		{"🐸 🐸", false, ""},
+		{"", false, ""},
+		{"<:broken:12>", false, ""},
+	}
+	for _, tt := range tests {
+		if got := validEmoji(tt.emoji); got != tt.valid {
+			t.Errorf("validEmoji(%q) = %v, want %v", tt.emoji, got, tt.valid)
+		}
+		if tt.valid && reactionEmoji(tt.emoji) != tt.reaction {
+			t.Errorf("reactionEmoji(%q) = %q, want %q", tt.emoji, reactionEmoji(tt.emoji), tt.reaction)
+		}
+	}
+}
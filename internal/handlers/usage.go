@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"wooper-bot/internal/commands"
+	"wooper-bot/internal/logger"
+	"wooper-bot/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+// recordUsage stores a request served by c, filling in where it came from, its transport and
+// latency. Failures are logged and never reach the user.
+func recordUsage(c *commands.Context, store *storage.Store, usage storage.Usage) {
+	if store == nil {
+		return
+	}
+	usage.GuildID = c.GuildID
+	usage.ChannelID = c.ChannelID
+	usage.UserID = c.User.ID
+	usage.Transport = string(c.Transport)
+	if !c.Received.IsZero() {
+		usage.Latency = time.Since(c.Received)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := store.RecordUsage(ctx, usage); err != nil {
+		logger.Logger.Warn("Failed to record usage",
+			zap.String("user_id", usage.UserID),
+			zap.String("category", usage.Category),
+			zap.Error(err))
+	}
+}
@@ -0,0 +1,140 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// DefaultLanguage is the language messages fall back to. Its catalog holds every key.
+const DefaultLanguage = "en"
+
+//go:embed locales/*.json
+var files embed.FS
+
+// catalogs maps a language to its messages by key.
+var catalogs = mustLoad()
+
+// discordLocales lists the Discord locales served by each language, for slash command localizations.
+var discordLocales = map[string][]discordgo.Locale{
+	"en": {discordgo.EnglishUS, discordgo.EnglishGB},
+	"fr": {discordgo.French},
+}
+
+func mustLoad() map[string]map[string]string {
+	entries, err := files.ReadDir("locales")
+	if err != nil {
+		panic(fmt.Sprintf("read locales: %v", err))
+	}
+
+	loaded := make(map[string]map[string]string, len(entries))
+	for _, entry := range entries {
+		data, err := files.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("read locale %s: %v", entry.Name(), err))
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("decode locale %s: %v", entry.Name(), err))
+		}
+		loaded[strings.TrimSuffix(entry.Name(), ".json")] = messages
+	}
+	return loaded
+}
+
+// Languages returns the supported languages, sorted.
+func Languages() []string {
+	languages := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Supported reports whether lang has a catalog.
+func Supported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// Language maps a Discord locale like "en-US" or "fr" to a supported language.
+func Language(locale string) string {
+	lang, _, _ := strings.Cut(strings.ToLower(locale), "-")
+	if Supported(lang) {
+		return lang
+	}
+	return DefaultLanguage
+}
+
+// T returns the message for key in the language of locale, formatted with args.
+// Missing translations fall back to the default language, then to the key itself.
+// Args that are errors wrapping an Error are replaced by its message in the same language.
+func T(locale, key string, args ...any) string {
+	message, ok := catalogs[Language(locale)][key]
+	if !ok {
+		message, ok = catalogs[DefaultLanguage][key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, localizeArgs(locale, args)...)
+}
+
+// Error is an error meant for users, whose message is a catalog entry so it can be shown
+// in their language. Error() is the default language message, for logs.
+type Error struct {
+	Key  string
+	Args []any
+}
+
+// Errorf returns an Error with the message for key formatted with args.
+func Errorf(key string, args ...any) error {
+	return &Error{Key: key, Args: args}
+}
+
+func (e *Error) Error() string {
+	return T(DefaultLanguage, e.Key, e.Args...)
+}
+
+// localizeArgs returns args with the errors wrapping an Error replaced by its message in locale.
+func localizeArgs(locale string, args []any) []any {
+	localized := make([]any, len(args))
+	for i, arg := range args {
+		localized[i] = arg
+		var e *Error
+		if err, ok := arg.(error); ok && errors.As(err, &e) {
+			localized[i] = T(locale, e.Key, e.Args...)
+		}
+	}
+	return localized
+}
+
+// Localizations returns the translations of key for every Discord locale, as slash commands
+// expect them. The default language is left out since it is the command's own text.
+// It returns nil when nothing is translated.
+func Localizations(key string) map[discordgo.Locale]string {
+	var localized map[discordgo.Locale]string
+	for lang, messages := range catalogs {
+		message, ok := messages[key]
+		if !ok || lang == DefaultLanguage {
+			continue
+		}
+		if localized == nil {
+			localized = make(map[discordgo.Locale]string)
+		}
+		for _, locale := range discordLocales[lang] {
+			localized[locale] = message
+		}
+	}
+	return localized
+}
@@ -0,0 +1,106 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var verb = regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z%]`)
+
+// TestCatalogs_Complete tests that every language translates every message with the same
+// format verbs. Slash command texts are only found in translations, the default language
+// has them in the command declarations.
+func TestCatalogs_Complete(t *testing.T) {
+	defaults := catalogs[DefaultLanguage]
+	if len(defaults) == 0 {
+		t.Fatalf("Expected a %s catalog", DefaultLanguage)
+	}
+
+	for lang, messages := range catalogs {
+		if _, ok := discordLocales[lang]; !ok {
+			t.Errorf("Expected Discord locales for %s", lang)
+		}
+		for key, message := range defaults {
+			translated, ok := messages[key]
+			if !ok {
+				t.Errorf("Missing %s translation of %s", lang, key)
+				continue
+			}
+			if !slices.Equal(verb.FindAllString(message, -1), verb.FindAllString(translated, -1)) {
+				t.Errorf("Expected the %s translation of %s to use the verbs of %q, got %q", lang, key, message, translated)
+			}
+		}
+		for key := range messages {
+			if _, ok := defaults[key]; !ok && !strings.HasPrefix(key, "command.") {
+				t.Errorf("Unknown key %s in %s", key, lang)
+			}
+		}
+	}
+}
+
+// TestLanguage tests mapping Discord locales to supported languages.
+func TestLanguage(t *testing.T) {
+	tests := map[string]string{
+		"fr":    "fr",
+		"en-US": "en",
+		"EN-GB": "en",
+		"ja":    DefaultLanguage,
+		"":      DefaultLanguage,
+	}
+	for locale, expected := range tests {
+		if got := Language(locale); got != expected {
+			t.Errorf("Expected %q for %q, got %q", expected, locale, got)
+		}
+	}
+
+	if languages := Languages(); !slices.Equal(languages, []string{"en", "fr"}) {
+		t.Errorf("Expected en and fr, got %v", languages)
+	}
+}
+
+// TestT tests formatting and fallbacks.
+func TestT(t *testing.T) {
+	if got := T("fr", "command.rate_limited", 3); got != "Doucement ! Réessayez dans 3 secondes." {
+		t.Errorf("Unexpected french message %q", got)
+	}
+	if got := T("de", "command.rate_limited", 3); got != "Slow down! Try again in 3 seconds." {
+		t.Errorf("Expected english for unsupported locales, got %q", got)
+	}
+	if got := T("fr", "no.such.key"); got != "no.such.key" {
+		t.Errorf("Expected the key for missing messages, got %q", got)
+	}
+}
+
+// TestError tests that errors carrying a catalog message are shown in the message's language.
+func TestError(t *testing.T) {
+	err := fmt.Errorf("set language: %w", Errorf("config.error.language", "auto", "en, fr"))
+	if got := err.Error(); got != "set language: language must be \"auto\" or one of: en, fr" {
+		t.Errorf("Expected the english message in logs, got %q", got)
+	}
+	if got := T("fr", "config.invalid", err); got != "Impossible de modifier le paramètre : la langue doit être \"auto\" ou l'une de : en, fr" {
+		t.Errorf("Expected the french message, got %q", got)
+	}
+	if got := T("fr", "config.invalid", errors.New("disk full")); got != "Impossible de modifier le paramètre : disk full" {
+		t.Errorf("Expected other errors unchanged, got %q", got)
+	}
+}
+
+// TestLocalizations tests the slash command localizations of a key.
+func TestLocalizations(t *testing.T) {
+	localized := Localizations("command.help.name")
+	if localized[discordgo.French] != "aide" {
+		t.Errorf("Expected the french name, got %v", localized)
+	}
+	if _, ok := localized[discordgo.EnglishUS]; ok {
+		t.Errorf("Expected no localization for the default language, got %v", localized)
+	}
+	if Localizations("command.nothing.description") != nil {
+		t.Errorf("Expected nil without translations")
+	}
+}
@@ -0,0 +1,130 @@
+package metrics
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"wooper-bot/internal/logger"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// Counter names used across the bot.
+const (
+	HandlerPanics = "handler_panics"
+	CommandErrors = "command_errors"
+
+	OutboundSent    = "outbound_sent"
+	OutboundRetries = "outbound_retries"
+	OutboundFailed  = "outbound_failed"
+	OutboundDropped = "outbound_dropped"
+)
+
+// Counter is a monotonically increasing value safe for concurrent use.
+type Counter struct {
+	value atomic.Int64
+}
+
+func (c *Counter) Inc() {
+	c.value.Add(1)
+}
+
+func (c *Counter) Add(n int64) {
+	c.value.Add(n)
+}
+
+func (c *Counter) Value() int64 {
+	return c.value.Load()
+}
+
+// Registry holds named counters.
+type Registry struct {
+	mu       sync.Mutex
+	counters map[string]*Counter
+}
+
+func NewRegistry() *Registry {
+	return &Registry{counters: make(map[string]*Counter)}
+}
+
+// Default is the registry the bot reports from.
+var Default = NewRegistry()
+
+// Counter returns the named counter, creating it on first use.
+func (r *Registry) Counter(name string) *Counter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.counters[name]
+	if !ok {
+		c = &Counter{}
+		r.counters[name] = c
+	}
+	return c
+}
+
+// Snapshot returns the current value of every counter.
+func (r *Registry) Snapshot() map[string]int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	values := make(map[string]int64, len(r.counters))
+	for name, c := range r.counters {
+		values[name] = c.Value()
+	}
+	return values
+}
+
+// Inc increments a counter of the default registry.
+func Inc(name string) {
+	Default.Counter(name).Inc()
+}
+
+// Reporter periodically logs the counters of a registry.
+type Reporter struct {
+	registry *Registry
+	interval time.Duration
+}
+
+func NewReporter(registry *Registry, interval time.Duration) *Reporter {
+	return &Reporter{registry: registry, interval: interval}
+}
+
+// Run logs the counters every interval until ctx is cancelled, and once more on shutdown.
+func (r *Reporter) Run(ctx context.Context, _ *discordgo.Session) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.report()
+			return nil
+		case <-ticker.C:
+			r.report()
+		}
+	}
+}
+
+func (r *Reporter) report() {
+	snapshot := r.registry.Snapshot()
+	if len(snapshot) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(snapshot))
+	for name := range snapshot {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := make([]zap.Field, 0, len(names))
+	for _, name := range names {
+		fields = append(fields, zap.Int64(name, snapshot[name]))
+	}
+	logger.Logger.Info("Metrics", fields...)
+}
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"wooper-bot/internal/logger"
+)
+
+// TestRegistry_Counter tests that counters are shared by name and safe for concurrent use.
+func TestRegistry_Counter(t *testing.T) {
+	r := NewRegistry()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Counter("errors").Inc()
+		}()
+	}
+	wg.Wait()
+	r.Counter("bytes").Add(10)
+
+	snapshot := r.Snapshot()
+	if snapshot["errors"] != 50 {
+		t.Errorf("Expected 50 errors, got %d", snapshot["errors"])
+	}
+	if snapshot["bytes"] != 10 {
+		t.Errorf("Expected 10 bytes, got %d", snapshot["bytes"])
+	}
+	if len(snapshot) != 2 {
+		t.Errorf("Expected 2 counters, got %d", len(snapshot))
+	}
+}
+
+// TestReporter_Run tests that the reporter stops with its context.
+func TestReporter_Run(t *testing.T) {
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+	t.Cleanup(logger.Close)
+
+	r := NewRegistry()
+	r.Counter("errors").Inc()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	if err := NewReporter(r, 10*time.Millisecond).Run(ctx, nil); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
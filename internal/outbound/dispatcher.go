@@ -0,0 +1,272 @@
+package outbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"wooper-bot/internal/logger"
+	"wooper-bot/internal/metrics"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultConcurrency is how many Discord requests may be in flight at once.
+	DefaultConcurrency = 4
+	// DefaultMaxRetries is how often a request is retried after a 429 or 5xx.
+	DefaultMaxRetries = 4
+	// DefaultQueueSize is how many requests may wait on one route before new ones are rejected.
+	DefaultQueueSize = 20
+)
+
+// ErrQueueFull is returned when a route already has too many requests waiting.
+var ErrQueueFull = errors.New("outbound queue full")
+
+// SendFunc performs one attempt of a request, passing options to the discordgo call.
+type SendFunc func(options ...discordgo.RequestOption) error
+
+// Dispatcher sends Discord requests through per-route queues. Requests of a route run one
+// at a time in arrival order, at most a fixed number run at once overall, and rate limited
+// or failed requests are retried with exponential backoff.
+//
+// A nil Dispatcher sends directly, which keeps tests and callers without one simple.
+type Dispatcher struct {
+	slots      chan struct{}
+	maxRetries int
+	queueSize  int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	sleep      func(ctx context.Context, d time.Duration) error
+
+	mu     sync.Mutex
+	routes map[string]*route
+}
+
+type route struct {
+	turn    chan struct{} // holds a token while a request of the route is being sent
+	pending int
+}
+
+func New(concurrency int) *Dispatcher {
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	return &Dispatcher{
+		slots:      make(chan struct{}, concurrency),
+		maxRetries: DefaultMaxRetries,
+		queueSize:  DefaultQueueSize,
+		baseDelay:  500 * time.Millisecond,
+		maxDelay:   10 * time.Second,
+		sleep:      sleepContext,
+		routes:     make(map[string]*route),
+	}
+}
+
+// ChannelRoute is the route of messages posted in a channel.
+func ChannelRoute(channelID string) string {
+	return "channel:" + channelID
+}
+
+// InteractionRoute is the route of the responses and follow-ups of an interaction.
+func InteractionRoute(i *discordgo.Interaction) string {
+	return "interaction:" + i.ID
+}
+
+// Do sends a request on a route and returns the error of its last attempt.
+func (d *Dispatcher) Do(ctx context.Context, routeKey string, send SendFunc) error {
+	if d == nil {
+		return send(discordgo.WithContext(ctx))
+	}
+
+	r, err := d.enqueue(routeKey)
+	if err != nil {
+		metrics.Inc(metrics.OutboundDropped)
+		logger.Logger.Warn("Dropping Discord request, queue full", zap.String("route", routeKey))
+		return err
+	}
+	defer d.dequeue(routeKey, r)
+
+	select {
+	case r.turn <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-r.turn }()
+
+	options := []discordgo.RequestOption{
+		discordgo.WithContext(ctx),
+		// Rate limits are retried here so the wait doesn't hold a concurrency slot
+		discordgo.WithRetryOnRatelimit(false),
+	}
+	for attempt := 0; ; attempt++ {
+		select {
+		case d.slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		err := send(options...)
+		<-d.slots
+
+		if err == nil {
+			metrics.Inc(metrics.OutboundSent)
+			return nil
+		}
+
+		delay, retry := d.retryDelay(err, attempt)
+		if !retry || attempt >= d.maxRetries || ctx.Err() != nil {
+			metrics.Inc(metrics.OutboundFailed)
+			return err
+		}
+
+		metrics.Inc(metrics.OutboundRetries)
+		logger.Logger.Warn("Retrying Discord request",
+			zap.String("route", routeKey),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("delay", delay),
+			zap.Error(err))
+		if err := d.sleep(ctx, delay); err != nil {
+			metrics.Inc(metrics.OutboundFailed)
+			return err
+		}
+	}
+}
+
+func (d *Dispatcher) enqueue(key string) (*route, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	r, ok := d.routes[key]
+	if !ok {
+		r = &route{turn: make(chan struct{}, 1)}
+		d.routes[key] = r
+	}
+	if r.pending >= d.queueSize {
+		return nil, fmt.Errorf("%w for %s", ErrQueueFull, key)
+	}
+	r.pending++
+	return r, nil
+}
+
+func (d *Dispatcher) dequeue(key string, r *route) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	r.pending--
+	if r.pending == 0 {
+		delete(d.routes, key)
+	}
+}
+
+// retryDelay reports whether err is worth retrying and how long to wait first.
+// Rate limits and server errors are; client errors like a missing channel are not.
+func (d *Dispatcher) retryDelay(err error, attempt int) (time.Duration, bool) {
+	var rateLimited *discordgo.RateLimitError
+	if errors.As(err, &rateLimited) && rateLimited.RateLimit != nil && rateLimited.TooManyRequests != nil {
+		return max(rateLimited.RetryAfter, d.backoff(0)), true
+	}
+
+	var restErr *discordgo.RESTError
+	if errors.As(err, &restErr) && restErr.Response != nil {
+		switch status := restErr.Response.StatusCode; {
+		case status == http.StatusTooManyRequests:
+			if after := retryAfterHeader(restErr.Response); after > 0 {
+				return after, true
+			}
+			return d.backoff(attempt), true
+		case status >= 500:
+			return d.backoff(attempt), true
+		}
+		return 0, false
+	}
+
+	// Connection resets and timeouts talking to Discord
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) && !errors.Is(err, context.Canceled) {
+		return d.backoff(attempt), true
+	}
+	return 0, false
+}
+
+// backoff doubles the delay on every attempt up to maxDelay, with jitter so queued
+// requests don't all retry at the same moment.
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.baseDelay << attempt
+	if delay > d.maxDelay || delay <= 0 {
+		delay = d.maxDelay
+	}
+	half := int64(delay / 2)
+	return time.Duration(half + rand.Int64N(half+1))
+}
+
+// IsFileRejected reports whether Discord refused a request because of its attached files,
+// like an upload over the size limit. Sending the same file again would fail the same way.
+func IsFileRejected(err error) bool {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) {
+		return false
+	}
+	if restErr.Message != nil {
+		switch restErr.Message.Code {
+		case discordgo.ErrCodeRequestEntityTooLarge, discordgo.ErrCodeFileUploadedExceedsTheMaximumSize:
+			return true
+		}
+	}
+	return restErr.Response != nil && restErr.Response.StatusCode == http.StatusRequestEntityTooLarge
+}
+
+func retryAfterHeader(resp *http.Response) time.Duration {
+	seconds, err := strconv.ParseFloat(resp.Header.Get("Retry-After"), 64)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Resendable wraps send so file readers are moved back to their start before every retry,
+// otherwise a retried upload would send empty files.
+func Resendable(files []*discordgo.File, send SendFunc) SendFunc {
+	first := true
+	return func(options ...discordgo.RequestOption) error {
+		if !first {
+			if err := rewind(files); err != nil {
+				return err
+			}
+		}
+		first = false
+		return send(options...)
+	}
+}
+
+func rewind(files []*discordgo.File) error {
+	for _, file := range files {
+		seeker, ok := file.Reader.(io.Seeker)
+		if !ok {
+			return fmt.Errorf("file %s can't be resent", file.Name)
+		}
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("rewind %s: %w", file.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,323 @@
+package outbound
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"wooper-bot/internal/logger"
+	"wooper-bot/internal/metrics"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func setupTestDispatcher(t *testing.T, concurrency int) (*Dispatcher, *[]time.Duration) {
+	t.Helper()
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to init logger: %v", err)
+	}
+	t.Cleanup(logger.Close)
+
+	var delays []time.Duration
+	d := New(concurrency)
+	d.sleep = func(ctx context.Context, delay time.Duration) error {
+		delays = append(delays, delay)
+		return ctx.Err()
+	}
+	return d, &delays
+}
+
+func restError(status int, header http.Header) error {
+	return &discordgo.RESTError{Response: &http.Response{StatusCode: status, Header: header}}
+}
+
+// TestDispatcher_Retry tests which errors are retried and how long the dispatcher waits.
+func TestDispatcher_Retry(t *testing.T) {
+	tests := []struct {
+		name          string
+		errs          []error
+		expectErr     bool
+		expectedCalls int
+		checkDelay    func(time.Duration) bool
+	}{
+		{
+			name:          "success",
+			expectedCalls: 1,
+		},
+		{
+			name:          "server error then success",
+			errs:          []error{restError(http.StatusBadGateway, nil)},
+			expectedCalls: 2,
+			checkDelay:    func(d time.Duration) bool { return d >= 250*time.Millisecond && d <= 500*time.Millisecond },
+		},
+		{
+			name:          "429 with Retry-After",
+			errs:          []error{restError(http.StatusTooManyRequests, http.Header{"Retry-After": {"2.5"}})},
+			expectedCalls: 2,
+			checkDelay:    func(d time.Duration) bool { return d == 2500*time.Millisecond },
+		},
+		{
+			name: "rate limit error",
+			errs: []error{&discordgo.RateLimitError{RateLimit: &discordgo.RateLimit{
+				TooManyRequests: &discordgo.TooManyRequests{RetryAfter: 3 * time.Second},
+			}}},
+			expectedCalls: 2,
+			checkDelay:    func(d time.Duration) bool { return d == 3*time.Second },
+		},
+		{
+			name:          "not found is not retried",
+			errs:          []error{restError(http.StatusNotFound, nil)},
+			expectErr:     true,
+			expectedCalls: 1,
+		},
+		{
+			name:          "unknown error is not retried",
+			errs:          []error{errors.New("boom")},
+			expectErr:     true,
+			expectedCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, delays := setupTestDispatcher(t, 1)
+
+			calls := 0
+			err := d.Do(context.Background(), "channel:c1", func(options ...discordgo.RequestOption) error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+
+			if (err != nil) != tt.expectErr {
+				t.Errorf("Expected error %t, got %v", tt.expectErr, err)
+			}
+			if calls != tt.expectedCalls {
+				t.Errorf("Expected %d calls, got %d", tt.expectedCalls, calls)
+			}
+			if tt.checkDelay != nil && (len(*delays) != 1 || !tt.checkDelay((*delays)[0])) {
+				t.Errorf("Unexpected delays %v", *delays)
+			}
+		})
+	}
+}
+
+// TestDispatcher_MaxRetries tests that a request gives up and backs off exponentially.
+func TestDispatcher_MaxRetries(t *testing.T) {
+	d, delays := setupTestDispatcher(t, 1)
+	failedBefore := metrics.Default.Counter(metrics.OutboundFailed).Value()
+
+	calls := 0
+	err := d.Do(context.Background(), "channel:c1", func(options ...discordgo.RequestOption) error {
+		calls++
+		return restError(http.StatusInternalServerError, nil)
+	})
+	if err == nil {
+		t.Fatalf("Expected the last error to be returned")
+	}
+	if calls != DefaultMaxRetries+1 {
+		t.Errorf("Expected %d calls, got %d", DefaultMaxRetries+1, calls)
+	}
+	for i, delay := range *delays {
+		limit := d.baseDelay << i
+		if delay < limit/2 || delay > limit {
+			t.Errorf("Expected delay %d between %v and %v, got %v", i, limit/2, limit, delay)
+		}
+	}
+	if got := metrics.Default.Counter(metrics.OutboundFailed).Value() - failedBefore; got != 1 {
+		t.Errorf("Expected 1 failed request, got %d", got)
+	}
+}
+
+// TestDispatcher_RouteOrder tests that requests of a route are sent one at a time in order.
+func TestDispatcher_RouteOrder(t *testing.T) {
+	d, _ := setupTestDispatcher(t, 4)
+
+	var (
+		mu       sync.Mutex
+		order    []int
+		inFlight atomic.Int32
+		overlap  atomic.Bool
+	)
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = d.Do(context.Background(), "channel:c1", func(options ...discordgo.RequestOption) error {
+				if inFlight.Add(1) > 1 {
+					overlap.Store(true)
+				}
+				time.Sleep(time.Millisecond)
+				mu.Lock()
+				order = append(order, i)
+				mu.Unlock()
+				inFlight.Add(-1)
+				return nil
+			})
+		}()
+		// Give each request time to queue before the next one
+		time.Sleep(5 * time.Millisecond)
+	}
+	wg.Wait()
+
+	if overlap.Load() {
+		t.Errorf("Expected requests of a route not to overlap")
+	}
+	for i, got := range order {
+		if got != i {
+			t.Fatalf("Expected requests in arrival order, got %v", order)
+		}
+	}
+	if len(d.routes) != 0 {
+		t.Errorf("Expected idle routes to be removed, got %d", len(d.routes))
+	}
+}
+
+// TestDispatcher_Concurrency tests that at most concurrency requests run across routes.
+func TestDispatcher_Concurrency(t *testing.T) {
+	d, _ := setupTestDispatcher(t, 2)
+
+	var inFlight, peak atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = d.Do(context.Background(), ChannelRoute(string(rune('a'+i))), func(options ...discordgo.RequestOption) error {
+				n := inFlight.Add(1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+				inFlight.Add(-1)
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	if peak.Load() > 2 {
+		t.Errorf("Expected at most 2 requests in flight, got %d", peak.Load())
+	}
+}
+
+// TestDispatcher_QueueFull tests that a route rejects requests beyond its queue size.
+func TestDispatcher_QueueFull(t *testing.T) {
+	d, _ := setupTestDispatcher(t, 2)
+	d.queueSize = 1
+	droppedBefore := metrics.Default.Counter(metrics.OutboundDropped).Value()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- d.Do(context.Background(), "channel:c1", func(options ...discordgo.RequestOption) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	err := d.Do(context.Background(), "channel:c1", func(options ...discordgo.RequestOption) error { return nil })
+	if !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull, got %v", err)
+	}
+	if got := metrics.Default.Counter(metrics.OutboundDropped).Value() - droppedBefore; got != 1 {
+		t.Errorf("Expected 1 dropped request, got %d", got)
+	}
+
+	// Other routes have their own queue
+	if err := d.Do(context.Background(), "channel:c2", func(options ...discordgo.RequestOption) error { return nil }); err != nil {
+		t.Errorf("Expected another route to be accepted, got %v", err)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+// TestDispatcher_Nil tests that a nil dispatcher sends directly.
+func TestDispatcher_Nil(t *testing.T) {
+	var d *Dispatcher
+	boom := errors.New("boom")
+
+	calls := 0
+	err := d.Do(context.Background(), "channel:c1", func(options ...discordgo.RequestOption) error {
+		calls++
+		if len(options) != 1 {
+			t.Errorf("Expected only the context option, got %d", len(options))
+		}
+		return boom
+	})
+	if !errors.Is(err, boom) || calls != 1 {
+		t.Errorf("Expected a single direct attempt, got %d calls and %v", calls, err)
+	}
+}
+
+// TestResendable tests that files are rewound before every retry.
+func TestResendable(t *testing.T) {
+	d, _ := setupTestDispatcher(t, 1)
+
+	files := []*discordgo.File{{Name: "wooper.png", Reader: bytes.NewReader([]byte("image"))}}
+	var reads []string
+	err := d.Do(context.Background(), "channel:c1", Resendable(files, func(options ...discordgo.RequestOption) error {
+		data, _ := io.ReadAll(files[0].Reader)
+		reads = append(reads, string(data))
+		if len(reads) == 1 {
+			return restError(http.StatusServiceUnavailable, nil)
+		}
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(reads) != 2 || reads[1] != "image" {
+		t.Errorf("Expected the retry to send the whole file, got %q", reads)
+	}
+
+	// Readers that can't seek fail instead of sending an empty file
+	files = []*discordgo.File{{Name: "wooper.png", Reader: io.NopCloser(bytes.NewReader(nil))}}
+	send := Resendable(files, func(options ...discordgo.RequestOption) error { return nil })
+	_ = send()
+	if err := send(); err == nil {
+		t.Errorf("Expected an error resending an unseekable file")
+	}
+}
+
+// TestIsFileRejected tests which errors blame the attached files.
+func TestIsFileRejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "413", err: restError(http.StatusRequestEntityTooLarge, nil), expected: true},
+		{name: "entity too large code", err: &discordgo.RESTError{Message: &discordgo.APIErrorMessage{Code: discordgo.ErrCodeRequestEntityTooLarge}}, expected: true},
+		{name: "file too large code", err: &discordgo.RESTError{Message: &discordgo.APIErrorMessage{Code: discordgo.ErrCodeFileUploadedExceedsTheMaximumSize}}, expected: true},
+		{name: "missing access", err: restError(http.StatusForbidden, nil)},
+		{name: "server error", err: restError(http.StatusBadGateway, nil)},
+		{name: "other error", err: errors.New("boom")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsFileRejected(tt.err); got != tt.expected {
+				t.Errorf("Expected %t, got %t", tt.expected, got)
+			}
+		})
+	}
+}
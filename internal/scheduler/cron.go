@@ -0,0 +1,181 @@
+package scheduler
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"wooper-bot/internal/i18n"
+)
+
+// Cron is a parsed standard five-field cron expression: minute, hour, day of month, month and day of week.
+type Cron struct {
+	minute, hour, dom, month, dow uint64 // bit n set when value n matches
+	domAny, dowAny                bool   // field was "*", used for the day matching rule
+}
+
+type cronField struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = cronField{min: 0, max: 59}
+	hourField   = cronField{min: 0, max: 23}
+	domField    = cronField{min: 1, max: 31}
+	monthField  = cronField{min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 is accepted as an alias for Sunday and folded into 0 after parsing
+	dowField = cronField{min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a cron expression such as "0 */6 * * *" or a macro such as "@daily".
+func ParseCron(expr string) (*Cron, error) {
+	expr = strings.TrimSpace(expr)
+	if macro, ok := cronMacros[strings.ToLower(expr)]; ok {
+		expr = macro
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, i18n.Errorf("schedule.error.cron_fields", expr, len(fields))
+	}
+
+	c := &Cron{
+		domAny: fields[2] == "*" || fields[2] == "?",
+		dowAny: fields[4] == "*" || fields[4] == "?",
+	}
+	var err error
+	if c.minute, err = minuteField.parse(fields[0]); err != nil {
+		return nil, i18n.Errorf("schedule.error.minute", err)
+	}
+	if c.hour, err = hourField.parse(fields[1]); err != nil {
+		return nil, i18n.Errorf("schedule.error.hour", err)
+	}
+	if c.dom, err = domField.parse(fields[2]); err != nil {
+		return nil, i18n.Errorf("schedule.error.day_of_month", err)
+	}
+	if c.month, err = monthField.parse(fields[3]); err != nil {
+		return nil, i18n.Errorf("schedule.error.month", err)
+	}
+	if c.dow, err = dowField.parse(fields[4]); err != nil {
+		return nil, i18n.Errorf("schedule.error.day_of_week", err)
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow = c.dow&^(1<<7) | 1
+	}
+	return c, nil
+}
+
+// parse turns one field ("*", "5", "1-5", "*/15", "mon-fri", "1,15") into a bit set.
+func (f cronField) parse(value string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(value, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, i18n.Errorf("schedule.error.step", part)
+			}
+			rangePart, step = part[:i], n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rangePart == "*" || rangePart == "?":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if lo, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(bounds[1]); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, i18n.Errorf("schedule.error.range", rangePart)
+			}
+		default:
+			n, err := f.value(rangePart)
+			if err != nil {
+				return 0, err
+			}
+			lo = n
+			// "5/10" means starting at 5 up to the maximum
+			if step == 1 {
+				hi = n
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	if n, ok := f.names[strings.ToLower(s)]; ok {
+		return n, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, i18n.Errorf("schedule.error.value", s)
+	}
+	if n < f.min || n > f.max {
+		return 0, i18n.Errorf("schedule.error.out_of_range", n, f.min, f.max)
+	}
+	return n, nil
+}
+
+// Next returns the first matching time strictly after the given time, evaluated in loc.
+// It returns the zero time if nothing matches within five years (e.g. "0 0 30 2 *").
+func (c *Cron) Next(after time.Time, loc *time.Location) time.Time {
+	t := after.In(loc).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches follows the usual cron rule: when both day fields are restricted, either may match.
+func (c *Cron) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,138 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"wooper-bot/internal/i18n"
+)
+
+// TestParseCron tests parsing of valid and invalid cron expressions.
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		name        string
+		expr        string
+		expectError bool
+	}{
+		{name: "every minute", expr: "* * * * *"},
+		{name: "step", expr: "0 */6 * * *"},
+		{name: "ranges and lists", expr: "0,30 9-17 * * 1-5"},
+		{name: "names", expr: "0 12 * jan-mar mon,fri"},
+		{name: "sunday as 7", expr: "0 0 * * 7"},
+		{name: "macro", expr: "@daily"},
+		{name: "too few fields", expr: "0 12 * *", expectError: true},
+		{name: "minute out of range", expr: "60 * * * *", expectError: true},
+		{name: "zero day of month", expr: "0 0 0 * *", expectError: true},
+		{name: "inverted range", expr: "0 17-9 * * *", expectError: true},
+		{name: "bad step", expr: "*/0 * * * *", expectError: true},
+		{name: "garbage", expr: "every day", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCron(tt.expr)
+			if tt.expectError && err == nil {
+				t.Errorf("Expected error but got none")
+			}
+			var localized *i18n.Error
+			if tt.expectError && !errors.As(err, &localized) {
+				t.Errorf("Expected an i18n error, got %v", err)
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+
+	// Field errors are translated along with the field name
+	_, err := ParseCron("60 * * * *")
+	if got := i18n.T("fr", "schedule.invalid", err); got != "Impossible de programmer la publication : minute : valeur 60 hors de l'intervalle 0-59" {
+		t.Errorf("Unexpected french message %q", got)
+	}
+}
+
+// TestCron_Next tests next run computation for common expressions.
+func TestCron_Next(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatalf("Failed to load location: %v", err)
+	}
+
+	// 2024-01-20 is a Saturday
+	after := time.Date(2024, 1, 20, 10, 15, 30, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		expr     string
+		loc      *time.Location
+		expected time.Time
+	}{
+		{
+			name:     "every minute",
+			expr:     "* * * * *",
+			loc:      time.UTC,
+			expected: time.Date(2024, 1, 20, 10, 16, 0, 0, time.UTC),
+		},
+		{
+			name:     "every six hours",
+			expr:     "0 */6 * * *",
+			loc:      time.UTC,
+			expected: time.Date(2024, 1, 20, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "weekdays skip the weekend",
+			expr:     "0 9 * * mon-fri",
+			loc:      time.UTC,
+			expected: time.Date(2024, 1, 22, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "first of next month",
+			expr:     "@monthly",
+			loc:      time.UTC,
+			expected: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "day of month or day of week",
+			expr:     "0 0 25 * sun",
+			loc:      time.UTC,
+			expected: time.Date(2024, 1, 21, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "leap day",
+			expr:     "0 0 29 2 *",
+			loc:      time.UTC,
+			expected: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "evaluated in time zone",
+			expr:     "30 11 * * *",
+			loc:      paris,
+			expected: time.Date(2024, 1, 20, 11, 30, 0, 0, paris),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cron, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			next := cron.Next(after, tt.loc)
+			if !next.Equal(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, next)
+			}
+		})
+	}
+}
+
+// TestCron_NextNeverMatches tests that impossible expressions return the zero time.
+func TestCron_NextNeverMatches(t *testing.T) {
+	cron, err := ParseCron("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if next := cron.Next(time.Now(), time.UTC); !next.IsZero() {
+		t.Errorf("Expected zero time, got %v", next)
+	}
+}
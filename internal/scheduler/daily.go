@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"time"
+
+	"wooper-bot/internal/i18n"
+)
+
+// DefaultWindow is the number of previous daily posts an image is kept out of rotation for.
+const DefaultWindow = 7
+
+// DailyPost is a guild's image of the day configuration.
+type DailyPost struct {
+	GuildID   string
+	ChannelID string
+	Category  string
+	Time      string // local posting time, "HH:MM"
+	Timezone  string // IANA zone name, e.g. "Europe/Paris"
+	Window    int    // number of recent posts that may not repeat
+	Recent    []string
+	NextRun   time.Time
+	LastRun   time.Time
+}
+
+// Validate checks that the post can be scheduled.
+func (p DailyPost) Validate() error {
+	if p.GuildID == "" {
+		return i18n.Errorf("schedule.error.guild")
+	}
+	if p.ChannelID == "" {
+		return i18n.Errorf("schedule.error.channel")
+	}
+	if p.Window < 0 {
+		return i18n.Errorf("schedule.error.window")
+	}
+	if _, _, err := parseClock(p.Time); err != nil {
+		return err
+	}
+	if _, err := loadLocation(p.Timezone); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Next returns the first posting time strictly after the given time.
+func (p DailyPost) Next(after time.Time) (time.Time, error) {
+	hour, minute, err := parseClock(p.Time)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := loadLocation(p.Timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	local := after.In(loc)
+	next := time.Date(local.Year(), local.Month(), local.Day(), hour, minute, 0, 0, loc)
+	if !next.After(after) {
+		// Rebuild from the calendar date rather than adding 24h so DST changes keep the wall clock time
+		next = time.Date(local.Year(), local.Month(), local.Day()+1, hour, minute, 0, 0, loc)
+	}
+	return next, nil
+}
+
+// remember records a posted image, keeping only the last Window entries.
+func (p *DailyPost) remember(image string) {
+	p.Recent = append(p.Recent, image)
+	p.trimRecent()
+}
+
+func (p *DailyPost) trimRecent() {
+	if p.Window <= 0 {
+		p.Recent = nil
+		return
+	}
+	if len(p.Recent) > p.Window {
+		p.Recent = p.Recent[len(p.Recent)-p.Window:]
+	}
+}
+
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, i18n.Errorf("schedule.error.timezone", name)
+	}
+	return loc, nil
+}
+
+func parseClock(value string) (int, int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, 0, i18n.Errorf("schedule.error.time", value)
+	}
+	return t.Hour(), t.Minute(), nil
+}
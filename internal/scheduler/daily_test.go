@@ -0,0 +1,114 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+// TestDailyPost_Next tests next run computation across days and time zones.
+func TestDailyPost_Next(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatalf("Failed to load location: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		post     DailyPost
+		after    time.Time
+		expected time.Time
+	}{
+		{
+			name:     "later today",
+			post:     DailyPost{Time: "09:30", Timezone: "UTC"},
+			after:    time.Date(2024, 1, 20, 8, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, 1, 20, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "already passed today",
+			post:     DailyPost{Time: "09:30", Timezone: "UTC"},
+			after:    time.Date(2024, 1, 20, 9, 30, 0, 0, time.UTC),
+			expected: time.Date(2024, 1, 21, 9, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "empty timezone defaults to UTC",
+			post:     DailyPost{Time: "00:00"},
+			after:    time.Date(2024, 12, 31, 23, 0, 0, 0, time.UTC),
+			expected: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "local time zone",
+			post:     DailyPost{Time: "09:00", Timezone: "Europe/Paris"},
+			after:    time.Date(2024, 1, 20, 7, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, 1, 20, 9, 0, 0, 0, paris),
+		},
+		{
+			name:     "keeps wall clock time across DST change",
+			post:     DailyPost{Time: "09:00", Timezone: "Europe/Paris"},
+			after:    time.Date(2024, 3, 30, 9, 0, 0, 0, paris),
+			expected: time.Date(2024, 3, 31, 9, 0, 0, 0, paris),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, err := tt.post.Next(tt.after)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !next.Equal(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, next)
+			}
+		})
+	}
+}
+
+// TestDailyPost_Validate tests validation of daily post settings.
+func TestDailyPost_Validate(t *testing.T) {
+	valid := DailyPost{GuildID: "g", ChannelID: "c", Time: "12:00", Timezone: "America/New_York"}
+
+	tests := []struct {
+		name        string
+		modify      func(p *DailyPost)
+		expectError bool
+	}{
+		{name: "valid", modify: func(p *DailyPost) {}},
+		{name: "missing guild", modify: func(p *DailyPost) { p.GuildID = "" }, expectError: true},
+		{name: "missing channel", modify: func(p *DailyPost) { p.ChannelID = "" }, expectError: true},
+		{name: "bad time", modify: func(p *DailyPost) { p.Time = "25:00" }, expectError: true},
+		{name: "bad timezone", modify: func(p *DailyPost) { p.Timezone = "Mars/Olympus" }, expectError: true},
+		{name: "negative window", modify: func(p *DailyPost) { p.Window = -1 }, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			post := valid
+			tt.modify(&post)
+			err := post.Validate()
+			if tt.expectError && err == nil {
+				t.Errorf("Expected error but got none")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// TestDailyPost_remember tests that the no-repeat history is bounded by the window.
+func TestDailyPost_remember(t *testing.T) {
+	post := DailyPost{Window: 2}
+	post.remember("a")
+	post.remember("b")
+	post.remember("c")
+
+	if len(post.Recent) != 2 || post.Recent[0] != "b" || post.Recent[1] != "c" {
+		t.Errorf("Expected [b c], got %v", post.Recent)
+	}
+
+	post.Window = 0
+	post.remember("d")
+	if post.Recent != nil {
+		t.Errorf("Expected no history with a zero window, got %v", post.Recent)
+	}
+}
@@ -0,0 +1,87 @@
+package scheduler
+
+import (
+	"time"
+
+	"wooper-bot/internal/i18n"
+	"wooper-bot/internal/logger"
+
+	"go.uber.org/zap"
+)
+
+// CatchUp decides what happens to runs that were missed while the bot was offline.
+type CatchUp string
+
+const (
+	// CatchUpSkip drops missed runs and waits for the next scheduled time.
+	CatchUpSkip CatchUp = "skip"
+	// CatchUpOnce posts a single image for any number of missed runs.
+	CatchUpOnce CatchUp = "once"
+)
+
+// MaxJobsPerGuild bounds how many cron jobs one guild can create.
+const MaxJobsPerGuild = 10
+
+// Job posts a random image from a category on a cron schedule.
+type Job struct {
+	ID        string
+	GuildID   string
+	ChannelID string
+	Category  string
+	Spec      string // cron expression, e.g. "0 */6 * * *"
+	Timezone  string // IANA zone name the expression is evaluated in
+	CatchUp   CatchUp
+	CreatedBy string
+	NextRun   time.Time
+	LastRun   time.Time
+}
+
+// Validate checks that the job can be scheduled.
+func (j Job) Validate() error {
+	if j.GuildID == "" {
+		return i18n.Errorf("schedule.error.guild")
+	}
+	if j.ChannelID == "" {
+		return i18n.Errorf("schedule.error.channel")
+	}
+	switch j.CatchUp {
+	case CatchUpSkip, CatchUpOnce:
+	default:
+		return i18n.Errorf("schedule.error.catch_up", j.CatchUp)
+	}
+	if _, err := ParseCron(j.Spec); err != nil {
+		return err
+	}
+	if _, err := loadLocation(j.Timezone); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Next returns the first run strictly after the given time.
+func (j Job) Next(after time.Time) (time.Time, error) {
+	cron, err := ParseCron(j.Spec)
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := loadLocation(j.Timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	next := cron.Next(after, loc)
+	if next.IsZero() {
+		return time.Time{}, i18n.Errorf("schedule.error.never", j.Spec)
+	}
+	return next, nil
+}
+
+// log returns a logger carrying the job's identifying fields.
+func (j Job) log() *zap.Logger {
+	return logger.Logger.With(
+		zap.String("job_id", j.ID),
+		zap.String("guild_id", j.GuildID),
+		zap.String("channel_id", j.ChannelID),
+		zap.String("category", j.Category),
+		zap.String("spec", j.Spec))
+}
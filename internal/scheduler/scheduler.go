@@ -0,0 +1,483 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"wooper-bot/internal/i18n"
+	"wooper-bot/internal/logger"
+	"wooper-bot/internal/outbound"
+	"wooper-bot/internal/services"
+	"wooper-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+	"go.uber.org/zap"
+)
+
+// DefaultInterval is how often the scheduler checks for due posts.
+const DefaultInterval = 30 * time.Second
+
+// storeTimeout bounds each database write of the scheduler.
+const storeTimeout = 5 * time.Second
+
+// ErrTooManyJobs is returned when a guild already has MaxJobsPerGuild jobs.
+var ErrTooManyJobs = i18n.Errorf("schedule.error.too_many", MaxJobsPerGuild)
+
+// Sender is the part of the Discord session the scheduler posts through.
+type Sender interface {
+	ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error)
+}
+
+type Scheduler struct {
+	images     *services.ImageService
+	store      *storage.Store
+	dispatcher *outbound.Dispatcher
+	interval   time.Duration
+	now        func() time.Time
+	locale     func(guildID string) string
+
+	mu    sync.Mutex
+	daily map[string]*DailyPost // keyed by guild ID
+	jobs  map[string]*Job       // keyed by job ID
+}
+
+// New creates a scheduler and loads the persisted schedules from store. Posts are sent
+// through dispatcher, or directly when it is nil.
+func New(images *services.ImageService, store *storage.Store, dispatcher *outbound.Dispatcher) (*Scheduler, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	posts, err := store.DailyPosts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load schedules: %w", err)
+	}
+	jobs, err := store.ScheduledJobs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("load schedules: %w", err)
+	}
+
+	s := &Scheduler{
+		images:     images,
+		store:      store,
+		dispatcher: dispatcher,
+		interval:   DefaultInterval,
+		now:        time.Now,
+		daily:      make(map[string]*DailyPost),
+		jobs:       make(map[string]*Job),
+	}
+	for _, stored := range posts {
+		post := dailyPostOf(stored)
+		s.daily[post.GuildID] = &post
+	}
+	for _, stored := range jobs {
+		job := jobOf(stored)
+		s.jobs[job.ID] = &job
+	}
+
+	logger.Logger.Info("Scheduler initialized",
+		zap.Int("daily_posts", len(s.daily)),
+		zap.Int("jobs", len(s.jobs)))
+	return s, nil
+}
+
+// SetLocale sets how the language of a guild's posts is found, usually from its language
+// setting. When it is unset or returns "", posts use the server's preferred locale.
+func (s *Scheduler) SetLocale(locale func(guildID string) string) {
+	s.locale = locale
+}
+
+// guildLocale returns the language to post in for a guild, or "" when unknown.
+func (s *Scheduler) guildLocale(sender Sender, guildID string) string {
+	if s.locale != nil {
+		if locale := s.locale(guildID); locale != "" {
+			return locale
+		}
+	}
+	if session, ok := sender.(*discordgo.Session); ok && session.State != nil {
+		if guild, err := session.State.Guild(guildID); err == nil {
+			return guild.PreferredLocale
+		}
+	}
+	return ""
+}
+
+// SetDaily creates or replaces the daily post of a guild and returns it with its next run filled in.
+func (s *Scheduler) SetDaily(post DailyPost) (DailyPost, error) {
+	if err := post.Validate(); err != nil {
+		return DailyPost{}, err
+	}
+	if !s.images.HasCategory(post.Category) {
+		return DailyPost{}, i18n.Errorf("schedule.error.category", post.Category)
+	}
+
+	next, err := post.Next(s.now())
+	if err != nil {
+		return DailyPost{}, err
+	}
+	post.NextRun = next
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Keep the no-repeat history when a guild only moves its post to another time or channel
+	if existing, ok := s.daily[post.GuildID]; ok && existing.Category == post.Category {
+		post.Recent = existing.Recent
+		post.LastRun = existing.LastRun
+	}
+	post.trimRecent()
+
+	err = s.persist("save daily post", func(ctx context.Context) error {
+		return s.store.SetDailyPost(ctx, post.record())
+	})
+	if err != nil {
+		return DailyPost{}, err
+	}
+	s.daily[post.GuildID] = &post
+
+	logger.Logger.Info("Daily post scheduled",
+		zap.String("guild_id", post.GuildID),
+		zap.String("channel_id", post.ChannelID),
+		zap.String("category", post.Category),
+		zap.String("time", post.Time),
+		zap.String("timezone", post.Timezone),
+		zap.Time("next_run", post.NextRun))
+
+	return post, nil
+}
+
+// Daily returns the daily post configured for a guild.
+func (s *Scheduler) Daily(guildID string) (DailyPost, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	post, ok := s.daily[guildID]
+	if !ok {
+		return DailyPost{}, false
+	}
+	return *post, true
+}
+
+// RemoveDaily disables the daily post of a guild. It reports whether one was configured.
+func (s *Scheduler) RemoveDaily(guildID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.daily[guildID]; !ok {
+		return false, nil
+	}
+	err := s.persist("remove daily post", func(ctx context.Context) error {
+		return s.store.RemoveDailyPost(ctx, guildID)
+	})
+	if err != nil {
+		return true, err
+	}
+	delete(s.daily, guildID)
+
+	logger.Logger.Info("Daily post removed", zap.String("guild_id", guildID))
+	return true, nil
+}
+
+// AddJob validates and stores a new cron job, assigning its ID and next run.
+func (s *Scheduler) AddJob(job Job) (Job, error) {
+	if job.CatchUp == "" {
+		job.CatchUp = CatchUpOnce
+	}
+	if err := job.Validate(); err != nil {
+		return Job{}, err
+	}
+	if !s.images.HasCategory(job.Category) {
+		return Job{}, i18n.Errorf("schedule.error.category", job.Category)
+	}
+
+	next, err := job.Next(s.now())
+	if err != nil {
+		return Job{}, err
+	}
+	job.NextRun = next
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, existing := range s.jobs {
+		if existing.GuildID == job.GuildID {
+			count++
+		}
+	}
+	if count >= MaxJobsPerGuild {
+		return Job{}, ErrTooManyJobs
+	}
+
+	err = s.persist("add scheduled job", func(ctx context.Context) error {
+		stored, err := s.store.AddScheduledJob(ctx, job.record())
+		job.ID = strconv.FormatInt(stored.ID, 10)
+		return err
+	})
+	if err != nil {
+		return Job{}, err
+	}
+	s.jobs[job.ID] = &job
+
+	job.log().Info("Scheduled job added",
+		zap.String("timezone", job.Timezone),
+		zap.String("catch_up", string(job.CatchUp)),
+		zap.Time("next_run", job.NextRun))
+	return job, nil
+}
+
+// Jobs returns the cron jobs of a guild ordered by ID.
+func (s *Scheduler) Jobs(guildID string) []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var jobs []Job
+	for _, job := range s.jobs {
+		if job.GuildID == guildID {
+			jobs = append(jobs, *job)
+		}
+	}
+	sortJobs(jobs)
+	return jobs
+}
+
+// RemoveJob deletes a guild's job. It reports whether the job existed in that guild.
+func (s *Scheduler) RemoveJob(guildID, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok || job.GuildID != guildID {
+		return false, nil
+	}
+	err := s.persist("remove scheduled job", func(ctx context.Context) error {
+		return s.store.RemoveScheduledJob(ctx, jobID(id))
+	})
+	if err != nil {
+		return true, err
+	}
+	delete(s.jobs, id)
+
+	job.log().Info("Scheduled job removed")
+	return true, nil
+}
+
+// Run posts due images until ctx is cancelled. It satisfies bot.Service.
+func (s *Scheduler) Run(ctx context.Context, session *discordgo.Session) error {
+	logger.Logger.Info("Scheduler started", zap.Duration("interval", s.interval))
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.runDue(ctx, session)
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Logger.Info("Scheduler stopped")
+			return nil
+		case <-ticker.C:
+			s.runDue(ctx, session)
+		}
+	}
+}
+
+// runDue posts every daily image and cron job whose next run has passed.
+func (s *Scheduler) runDue(ctx context.Context, sender Sender) {
+	now := s.now()
+
+	s.mu.Lock()
+	var dueDaily []DailyPost
+	for _, post := range s.daily {
+		if !now.Before(post.NextRun) {
+			dueDaily = append(dueDaily, *post)
+		}
+	}
+	var dueJobs []Job
+	for _, job := range s.jobs {
+		if !now.Before(job.NextRun) {
+			dueJobs = append(dueJobs, *job)
+		}
+	}
+	s.mu.Unlock()
+
+	// Stable order keeps log output and tests deterministic
+	sort.Slice(dueDaily, func(a, b int) bool { return dueDaily[a].GuildID < dueDaily[b].GuildID })
+	sortJobs(dueJobs)
+
+	for _, post := range dueDaily {
+		if ctx.Err() != nil {
+			return
+		}
+		log := logger.Logger.With(
+			zap.String("guild_id", post.GuildID),
+			zap.String("channel_id", post.ChannelID),
+			zap.String("category", post.Category))
+		imagePath := s.images.GetRandomImageExcluding(post.Category, post.Recent)
+		content := i18n.T(s.guildLocale(sender, post.GuildID), "daily.post", post.Category)
+		image := s.post(ctx, sender, log, post.ChannelID, imagePath, content)
+		s.completeDaily(post, image, now)
+	}
+
+	for _, job := range dueJobs {
+		if ctx.Err() != nil {
+			return
+		}
+		log := job.log()
+		// A run is missed when the bot was offline well past its scheduled time
+		missed := now.Sub(job.NextRun) > 2*s.interval
+		if missed && job.CatchUp == CatchUpSkip {
+			log.Info("Skipping missed scheduled run",
+				zap.Time("scheduled_for", job.NextRun))
+		} else {
+			if missed {
+				log.Info("Catching up missed scheduled run",
+					zap.Time("scheduled_for", job.NextRun))
+			}
+			s.post(ctx, sender, log, job.ChannelID, s.images.GetRandomImage(job.Category), "")
+		}
+		s.completeJob(job, now)
+	}
+}
+
+// post sends one image and returns its path, or "" when nothing was posted.
+func (s *Scheduler) post(ctx context.Context, sender Sender, log *zap.Logger, channelID, imagePath, content string) string {
+	if imagePath == "" {
+		log.Warn("No images available for scheduled post")
+		return ""
+	}
+
+	loadCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	startTime := time.Now()
+
+	reader, fileName, err := s.images.GetImageFile(loadCtx, imagePath)
+	if err != nil {
+		log.Error("Failed to load scheduled image",
+			zap.String("image_path", imagePath),
+			zap.Error(err))
+		s.images.ReportFailure(imagePath, err)
+		return ""
+	}
+	defer reader.Close()
+
+	sendCtx, cancelSend := context.WithTimeout(ctx, 30*time.Second)
+	defer cancelSend()
+
+	data := &discordgo.MessageSend{
+		Content: content,
+		Files: []*discordgo.File{{
+			Name:   fileName,
+			Reader: reader,
+		}},
+	}
+	err = s.dispatcher.Do(sendCtx, outbound.ChannelRoute(channelID), outbound.Resendable(data.Files, func(options ...discordgo.RequestOption) error {
+		_, err := sender.ChannelMessageSendComplex(channelID, data, options...)
+		return err
+	}))
+	if err != nil {
+		log.Error("Failed to send scheduled image",
+			zap.String("filename", fileName),
+			zap.Error(err))
+		if outbound.IsFileRejected(err) {
+			s.images.ReportFailure(imagePath, err)
+		}
+		return ""
+	}
+	s.images.ReportSuccess(imagePath)
+
+	log.Info("Scheduled image posted",
+		zap.String("filename", fileName),
+		zap.Duration("duration", time.Since(startTime)))
+	return imagePath
+}
+
+// completeDaily advances a daily post to its next run. Failed posts are not retried until the
+// next day so a deleted channel doesn't turn into an error on every tick.
+func (s *Scheduler) completeDaily(ran DailyPost, image string, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	post, ok := s.daily[ran.GuildID]
+	if !ok || !post.NextRun.Equal(ran.NextRun) {
+		// Removed or reconfigured while we were posting
+		return
+	}
+
+	next, err := post.Next(now)
+	if err != nil {
+		logger.Logger.Error("Failed to compute next daily run",
+			zap.String("guild_id", post.GuildID),
+			zap.Error(err))
+		delete(s.daily, post.GuildID)
+		err = s.persist("remove daily post", func(ctx context.Context) error {
+			return s.store.RemoveDailyPost(ctx, post.GuildID)
+		})
+	} else {
+		post.NextRun = next
+		post.LastRun = now
+		if image != "" {
+			post.remember(image)
+		}
+		err = s.persist("save daily post", func(ctx context.Context) error {
+			return s.store.SetDailyPost(ctx, post.record())
+		})
+	}
+	if err != nil {
+		// The post still advances in memory, a restart at worst repeats today's post
+		logger.Logger.Error("Failed to save schedules", zap.Error(err))
+	}
+}
+
+// completeJob advances a cron job to its next run, whether or not the post succeeded.
+func (s *Scheduler) completeJob(ran Job, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[ran.ID]
+	if !ok {
+		// Removed while we were posting
+		return
+	}
+
+	next, err := job.Next(now)
+	if err != nil {
+		job.log().Error("Failed to compute next run, removing job", zap.Error(err))
+		delete(s.jobs, job.ID)
+		err = s.persist("remove scheduled job", func(ctx context.Context) error {
+			return s.store.RemoveScheduledJob(ctx, jobID(job.ID))
+		})
+	} else {
+		job.NextRun = next
+		job.LastRun = now
+		err = s.persist("save scheduled job", func(ctx context.Context) error {
+			return s.store.SetScheduledJobRuns(ctx, jobID(job.ID), job.NextRun, job.LastRun)
+		})
+	}
+	if err != nil {
+		logger.Logger.Error("Failed to save schedules", zap.Error(err))
+	}
+}
+
+// persist runs a write to the store, reporting failures as storage errors.
+func (s *Scheduler) persist(action string, write func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), storeTimeout)
+	defer cancel()
+
+	if err := write(ctx); err != nil {
+		return services.NewError(services.ErrCodeStorage, fmt.Errorf("%s: %w", action, err))
+	}
+	return nil
+}
+
+// sortJobs orders jobs by their numeric ID, i.e. creation order.
+func sortJobs(jobs []Job) {
+	sort.Slice(jobs, func(a, b int) bool {
+		ia, _ := strconv.Atoi(jobs[a].ID)
+		ib, _ := strconv.Atoi(jobs[b].ID)
+		return ia < ib
+	})
+}
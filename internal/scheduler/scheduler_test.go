@@ -0,0 +1,371 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"wooper-bot/internal/logger"
+	"wooper-bot/internal/services"
+	"wooper-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type fakeSender struct {
+	sent     []string // channel IDs
+	contents []string
+	err      error
+}
+
+func (f *fakeSender) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.sent = append(f.sent, channelID)
+	f.contents = append(f.contents, data.Content)
+	return &discordgo.Message{ChannelID: channelID}, nil
+}
+
+// setupTestScheduler creates a scheduler over a temporary image directory and database.
+func setupTestScheduler(t *testing.T, now time.Time) (*Scheduler, *storage.Store) {
+	err := logger.Init()
+	if err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+	t.Cleanup(func() {
+		logger.Close()
+	})
+
+	tempDir := t.TempDir()
+	categoryDir := filepath.Join(tempDir, "img", "wooper")
+	if err := os.MkdirAll(categoryDir, 0755); err != nil {
+		t.Fatalf("Failed to create test directory: %v", err)
+	}
+	for i := 1; i <= 3; i++ {
+		filename := filepath.Join(categoryDir, "wooper_"+string(rune('0'+i))+".jpg")
+		if err := os.WriteFile(filename, []byte("\x89PNG\r\n\x1a\n"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	imageService, err := services.NewImageService(filepath.Join(tempDir, "img"))
+	if err != nil {
+		t.Fatalf("Failed to create image service: %v", err)
+	}
+
+	store, err := storage.Open(filepath.Join(tempDir, "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	sched, err := New(imageService, store, nil)
+	if err != nil {
+		t.Fatalf("Failed to create scheduler: %v", err)
+	}
+	sched.now = func() time.Time { return now }
+	return sched, store
+}
+
+// TestScheduler_SetDaily tests scheduling, validation and persistence of daily posts.
+func TestScheduler_SetDaily(t *testing.T) {
+	now := time.Date(2024, 1, 20, 8, 0, 0, 0, time.UTC)
+	sched, store := setupTestScheduler(t, now)
+
+	post, err := sched.SetDaily(DailyPost{GuildID: "g1", ChannelID: "c1", Category: "wooper", Time: "09:00", Window: 2})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !post.NextRun.Equal(time.Date(2024, 1, 20, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected next run: %v", post.NextRun)
+	}
+
+	if _, err := sched.SetDaily(DailyPost{GuildID: "g2", ChannelID: "c2", Category: "cats", Time: "09:00"}); err == nil {
+		t.Errorf("Expected error for unknown category")
+	}
+
+	// A fresh scheduler sees the persisted post
+	reloaded, err := New(sched.images, store, nil)
+	if err != nil {
+		t.Fatalf("Failed to reload scheduler: %v", err)
+	}
+	if _, ok := reloaded.Daily("g1"); !ok {
+		t.Errorf("Expected persisted daily post for g1")
+	}
+}
+
+// TestScheduler_runDue tests that due posts are sent once and rescheduled without repeats.
+func TestScheduler_runDue(t *testing.T) {
+	now := time.Date(2024, 1, 20, 8, 0, 0, 0, time.UTC)
+	sched, store := setupTestScheduler(t, now)
+
+	if _, err := sched.SetDaily(DailyPost{GuildID: "g1", ChannelID: "c1", Category: "wooper", Time: "09:00", Window: 2}); err != nil {
+		t.Fatalf("Failed to schedule: %v", err)
+	}
+
+	sender := &fakeSender{}
+
+	// Not due yet
+	sched.runDue(context.Background(), sender)
+	if len(sender.sent) != 0 {
+		t.Fatalf("Expected no posts before the scheduled time, got %d", len(sender.sent))
+	}
+
+	seen := map[string]bool{}
+	for day := 0; day < 2; day++ {
+		now = time.Date(2024, 1, 20+day, 9, 0, 5, 0, time.UTC)
+		sched.now = func() time.Time { return now }
+		sched.runDue(context.Background(), sender)
+
+		post, _ := sched.Daily("g1")
+		last := post.Recent[len(post.Recent)-1]
+		if seen[last] {
+			t.Errorf("Image %s repeated within the window", last)
+		}
+		seen[last] = true
+		if !post.NextRun.Equal(time.Date(2024, 1, 21+day, 9, 0, 0, 0, time.UTC)) {
+			t.Errorf("Unexpected next run: %v", post.NextRun)
+		}
+	}
+
+	if len(sender.sent) != 2 {
+		t.Errorf("Expected 2 posts, got %d", len(sender.sent))
+	}
+
+	// Runs and the no-repeat history survive a restart
+	reloaded, err := New(sched.images, store, nil)
+	if err != nil {
+		t.Fatalf("Failed to reload scheduler: %v", err)
+	}
+	post, _ := reloaded.Daily("g1")
+	if len(post.Recent) != 2 || !post.NextRun.Equal(time.Date(2024, 1, 22, 9, 0, 0, 0, time.UTC)) || !post.LastRun.Equal(now.Truncate(time.Second)) {
+		t.Errorf("Unexpected reloaded post %+v", post)
+	}
+}
+
+// TestScheduler_runDueLocale tests that daily posts are written in the guild's language.
+func TestScheduler_runDueLocale(t *testing.T) {
+	now := time.Date(2024, 1, 20, 9, 0, 5, 0, time.UTC)
+	sched, _ := setupTestScheduler(t, now)
+	sched.SetLocale(func(guildID string) string {
+		if guildID == "g1" {
+			return "fr"
+		}
+		return ""
+	})
+
+	for _, guildID := range []string{"g1", "g2"} {
+		post := DailyPost{GuildID: guildID, ChannelID: "c" + guildID, Category: "wooper", Time: "09:00"}
+		if _, err := sched.SetDaily(post); err != nil {
+			t.Fatalf("Failed to schedule: %v", err)
+		}
+	}
+	sched.now = func() time.Time { return now.Add(24 * time.Hour) }
+
+	sender := &fakeSender{}
+	sched.runDue(context.Background(), sender)
+	expected := []string{"Votre wooper du jour !", "Your daily wooper!"}
+	if len(sender.contents) != 2 || sender.contents[0] != expected[0] || sender.contents[1] != expected[1] {
+		t.Errorf("Expected %q, got %q", expected, sender.contents)
+	}
+}
+
+// TestScheduler_runDueSendError tests that a failed post still advances to the next day.
+func TestScheduler_runDueSendError(t *testing.T) {
+	now := time.Date(2024, 1, 20, 8, 0, 0, 0, time.UTC)
+	sched, _ := setupTestScheduler(t, now)
+
+	if _, err := sched.SetDaily(DailyPost{GuildID: "g1", ChannelID: "c1", Category: "wooper", Time: "08:00"}); err != nil {
+		t.Fatalf("Failed to schedule: %v", err)
+	}
+
+	now = time.Date(2024, 1, 21, 8, 0, 0, 0, time.UTC)
+	sched.now = func() time.Time { return now }
+	sched.runDue(context.Background(), &fakeSender{err: errors.New("unknown channel")})
+
+	post, _ := sched.Daily("g1")
+	if !post.NextRun.After(now) {
+		t.Errorf("Expected next run after %v, got %v", now, post.NextRun)
+	}
+	if len(post.Recent) != 0 {
+		t.Errorf("Expected failed post not to be remembered, got %v", post.Recent)
+	}
+}
+
+// TestScheduler_RemoveDaily tests disabling a guild's daily post.
+func TestScheduler_RemoveDaily(t *testing.T) {
+	sched, _ := setupTestScheduler(t, time.Now())
+
+	if _, err := sched.SetDaily(DailyPost{GuildID: "g1", ChannelID: "c1", Category: "wooper", Time: "09:00"}); err != nil {
+		t.Fatalf("Failed to schedule: %v", err)
+	}
+
+	removed, err := sched.RemoveDaily("g1")
+	if err != nil || !removed {
+		t.Fatalf("Expected removal, got removed=%v err=%v", removed, err)
+	}
+	removed, err = sched.RemoveDaily("g1")
+	if err != nil || removed {
+		t.Errorf("Expected nothing to remove, got removed=%v err=%v", removed, err)
+	}
+}
+
+// TestScheduler_Run tests that Run stops when its context is cancelled.
+func TestScheduler_Run(t *testing.T) {
+	sched, _ := setupTestScheduler(t, time.Now())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- sched.Run(ctx, nil)
+	}()
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Run did not stop after cancellation")
+	}
+}
+
+// TestScheduler_AddJob tests job validation, IDs and the per-guild limit.
+func TestScheduler_AddJob(t *testing.T) {
+	now := time.Date(2024, 1, 20, 8, 0, 0, 0, time.UTC)
+	sched, store := setupTestScheduler(t, now)
+
+	job, err := sched.AddJob(Job{GuildID: "g1", ChannelID: "c1", Category: "wooper", Spec: "0 */6 * * *"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if job.ID != "1" || job.CatchUp != CatchUpOnce {
+		t.Errorf("Unexpected job: %+v", job)
+	}
+	if !job.NextRun.Equal(time.Date(2024, 1, 20, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected next run: %v", job.NextRun)
+	}
+
+	invalid := []Job{
+		{GuildID: "g1", ChannelID: "c1", Category: "wooper", Spec: "not cron"},
+		{GuildID: "g1", ChannelID: "c1", Category: "cats", Spec: "@daily"},
+		{GuildID: "g1", ChannelID: "c1", Category: "wooper", Spec: "@daily", CatchUp: "always"},
+		{GuildID: "g1", ChannelID: "c1", Category: "wooper", Spec: "0 0 30 2 *"},
+	}
+	for _, j := range invalid {
+		if _, err := sched.AddJob(j); err == nil {
+			t.Errorf("Expected error for job %+v", j)
+		}
+	}
+
+	for i := 1; i < MaxJobsPerGuild; i++ {
+		if _, err := sched.AddJob(Job{GuildID: "g1", ChannelID: "c1", Category: "wooper", Spec: "@hourly"}); err != nil {
+			t.Fatalf("Unexpected error adding job %d: %v", i, err)
+		}
+	}
+	if _, err := sched.AddJob(Job{GuildID: "g1", ChannelID: "c1", Category: "wooper", Spec: "@hourly"}); !errors.Is(err, ErrTooManyJobs) {
+		t.Errorf("Expected ErrTooManyJobs, got %v", err)
+	}
+
+	// IDs keep increasing across restarts
+	reloaded, err := New(sched.images, store, nil)
+	if err != nil {
+		t.Fatalf("Failed to reload scheduler: %v", err)
+	}
+	reloaded.now = sched.now
+	if len(reloaded.Jobs("g1")) != MaxJobsPerGuild {
+		t.Errorf("Expected %d persisted jobs, got %d", MaxJobsPerGuild, len(reloaded.Jobs("g1")))
+	}
+	other, err := reloaded.AddJob(Job{GuildID: "g2", ChannelID: "c2", Category: "wooper", Spec: "@daily"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if other.ID != "11" {
+		t.Errorf("Expected ID 11, got %s", other.ID)
+	}
+}
+
+// TestScheduler_RemoveJob tests that jobs can only be removed from their own guild.
+func TestScheduler_RemoveJob(t *testing.T) {
+	sched, _ := setupTestScheduler(t, time.Now())
+
+	job, err := sched.AddJob(Job{GuildID: "g1", ChannelID: "c1", Category: "wooper", Spec: "@daily"})
+	if err != nil {
+		t.Fatalf("Failed to add job: %v", err)
+	}
+
+	if removed, _ := sched.RemoveJob("g2", job.ID); removed {
+		t.Errorf("Expected job not to be removable from another guild")
+	}
+	if removed, err := sched.RemoveJob("g1", job.ID); !removed || err != nil {
+		t.Errorf("Expected removal, got removed=%v err=%v", removed, err)
+	}
+	if len(sched.Jobs("g1")) != 0 {
+		t.Errorf("Expected no jobs left")
+	}
+}
+
+// TestScheduler_runDueCatchUp tests the missed-run policies after downtime.
+func TestScheduler_runDueCatchUp(t *testing.T) {
+	tests := []struct {
+		name      string
+		catchUp   CatchUp
+		expectRun bool
+	}{
+		{name: "once posts a single catch-up image", catchUp: CatchUpOnce, expectRun: true},
+		{name: "skip drops missed runs", catchUp: CatchUpSkip, expectRun: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2024, 1, 20, 8, 0, 0, 0, time.UTC)
+			sched, _ := setupTestScheduler(t, now)
+
+			if _, err := sched.AddJob(Job{GuildID: "g1", ChannelID: "c1", Category: "wooper", Spec: "0 * * * *", CatchUp: tt.catchUp}); err != nil {
+				t.Fatalf("Failed to add job: %v", err)
+			}
+
+			// The bot comes back ten hours later
+			later := now.Add(10 * time.Hour).Add(5 * time.Minute)
+			sched.now = func() time.Time { return later }
+			sender := &fakeSender{}
+			sched.runDue(context.Background(), sender)
+
+			if tt.expectRun && len(sender.sent) != 1 {
+				t.Errorf("Expected exactly one catch-up post, got %d", len(sender.sent))
+			}
+			if !tt.expectRun && len(sender.sent) != 0 {
+				t.Errorf("Expected no posts, got %d", len(sender.sent))
+			}
+
+			job := sched.Jobs("g1")[0]
+			if !job.NextRun.Equal(time.Date(2024, 1, 20, 19, 0, 0, 0, time.UTC)) {
+				t.Errorf("Unexpected next run: %v", job.NextRun)
+			}
+		})
+	}
+}
+
+// TestScheduler_runDueOnTime tests that a job due within the tick interval is posted regardless of policy.
+func TestScheduler_runDueOnTime(t *testing.T) {
+	now := time.Date(2024, 1, 20, 8, 0, 0, 0, time.UTC)
+	sched, _ := setupTestScheduler(t, now)
+
+	if _, err := sched.AddJob(Job{GuildID: "g1", ChannelID: "c1", Category: "wooper", Spec: "0 9 * * *", CatchUp: CatchUpSkip}); err != nil {
+		t.Fatalf("Failed to add job: %v", err)
+	}
+
+	onTime := time.Date(2024, 1, 20, 9, 0, 20, 0, time.UTC)
+	sched.now = func() time.Time { return onTime }
+	sender := &fakeSender{}
+	sched.runDue(context.Background(), sender)
+
+	if len(sender.sent) != 1 || sender.sent[0] != "c1" {
+		t.Errorf("Expected one post to c1, got %v", sender.sent)
+	}
+}
@@ -0,0 +1,73 @@
+package scheduler
+
+import (
+	"strconv"
+
+	"wooper-bot/internal/storage"
+)
+
+// record converts the post to its stored form.
+func (p DailyPost) record() storage.DailyPost {
+	return storage.DailyPost{
+		GuildID:   p.GuildID,
+		ChannelID: p.ChannelID,
+		Category:  p.Category,
+		Time:      p.Time,
+		Timezone:  p.Timezone,
+		Window:    p.Window,
+		Recent:    p.Recent,
+		NextRun:   p.NextRun,
+		LastRun:   p.LastRun,
+	}
+}
+
+func dailyPostOf(post storage.DailyPost) DailyPost {
+	return DailyPost{
+		GuildID:   post.GuildID,
+		ChannelID: post.ChannelID,
+		Category:  post.Category,
+		Time:      post.Time,
+		Timezone:  post.Timezone,
+		Window:    post.Window,
+		Recent:    post.Recent,
+		NextRun:   post.NextRun,
+		LastRun:   post.LastRun,
+	}
+}
+
+// record converts the job to its stored form. Jobs not added yet have ID 0.
+func (j Job) record() storage.ScheduledJob {
+	return storage.ScheduledJob{
+		ID:        jobID(j.ID),
+		GuildID:   j.GuildID,
+		ChannelID: j.ChannelID,
+		Category:  j.Category,
+		Spec:      j.Spec,
+		Timezone:  j.Timezone,
+		CatchUp:   string(j.CatchUp),
+		CreatedBy: j.CreatedBy,
+		NextRun:   j.NextRun,
+		LastRun:   j.LastRun,
+	}
+}
+
+func jobOf(job storage.ScheduledJob) Job {
+	return Job{
+		ID:        strconv.FormatInt(job.ID, 10),
+		GuildID:   job.GuildID,
+		ChannelID: job.ChannelID,
+		Category:  job.Category,
+		Spec:      job.Spec,
+		Timezone:  job.Timezone,
+		CatchUp:   CatchUp(job.CatchUp),
+		CreatedBy: job.CreatedBy,
+		NextRun:   job.NextRun,
+		LastRun:   job.LastRun,
+	}
+}
+
+// jobID returns the stored ID of a job, or 0 for IDs that aren't numbers.
+func jobID(id string) int64 {
+	n, _ := strconv.ParseInt(id, 10, 64)
+	return n
+}
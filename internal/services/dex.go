@@ -0,0 +1,109 @@
+package services
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed data/pokedex.json
+var pokedexData []byte
+
+// Pokemon is a Pokédex entry of the bundled dataset.
+type Pokemon struct {
+	Number    int       `json:"number"`
+	Name      string    `json:"name"`
+	Types     []string  `json:"types"`
+	Abilities []Ability `json:"abilities"`
+	Stats     BaseStats `json:"stats"`
+	// EvolvesFrom is the name of the previous stage, empty for the first one
+	EvolvesFrom string      `json:"evolves_from"`
+	EvolvesTo   []Evolution `json:"evolves_to"`
+	Flavor      string      `json:"flavor"`
+}
+
+type Ability struct {
+	Name   string `json:"name"`
+	Hidden bool   `json:"hidden"`
+}
+
+type BaseStats struct {
+	HP             int `json:"hp"`
+	Attack         int `json:"attack"`
+	Defense        int `json:"defense"`
+	SpecialAttack  int `json:"special_attack"`
+	SpecialDefense int `json:"special_defense"`
+	Speed          int `json:"speed"`
+}
+
+// Total is the sum of the base stats.
+func (s BaseStats) Total() int {
+	return s.HP + s.Attack + s.Defense + s.SpecialAttack + s.SpecialDefense + s.Speed
+}
+
+// Evolution is a next stage and how it is reached, e.g. "Level 20" or "Thunder Stone".
+type Evolution struct {
+	Name   string `json:"name"`
+	Method string `json:"method"`
+}
+
+// Dex looks Pokémon up in the dataset embedded in the binary, so it needs no network.
+type Dex struct {
+	entries []Pokemon // by number, then name
+	byName  map[string]int
+}
+
+// NewDex loads the embedded dataset.
+func NewDex() (*Dex, error) {
+	var entries []Pokemon
+	if err := json.Unmarshal(pokedexData, &entries); err != nil {
+		return nil, fmt.Errorf("parse pokedex: %w", err)
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Number < entries[j].Number })
+
+	dex := &Dex{entries: entries, byName: make(map[string]int, len(entries))}
+	for i, entry := range entries {
+		dex.byName[NormalizeAnswer(entry.Name)] = i
+	}
+	return dex, nil
+}
+
+// Lookup finds a Pokémon by name, ignoring case, accents and punctuation, or by number.
+// Forms share their number, so a number returns the first one.
+func (d *Dex) Lookup(query string) (Pokemon, bool) {
+	if i, ok := d.byName[NormalizeAnswer(query)]; ok {
+		return d.entries[i], true
+	}
+	if number, err := strconv.Atoi(strings.TrimLeft(strings.TrimSpace(query), "#")); err == nil {
+		for _, entry := range d.entries {
+			if entry.Number == number {
+				return entry, true
+			}
+		}
+	}
+	return Pokemon{}, false
+}
+
+// Search returns up to limit Pokémon whose name contains query, those starting with it first.
+func (d *Dex) Search(query string, limit int) []Pokemon {
+	query = NormalizeAnswer(query)
+	var prefixed, containing []Pokemon
+	for _, entry := range d.entries {
+		name := NormalizeAnswer(entry.Name)
+		switch {
+		case strings.HasPrefix(name, query):
+			prefixed = append(prefixed, entry)
+		case strings.Contains(name, query):
+			containing = append(containing, entry)
+		}
+	}
+
+	results := append(prefixed, containing...)
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
@@ -0,0 +1,96 @@
+package services
+
+import "testing"
+
+// TestNewDex tests that the embedded dataset is complete and its evolutions link both ways.
+func TestNewDex(t *testing.T) {
+	dex, err := NewDex()
+	if err != nil {
+		t.Fatalf("Failed to load dex: %v", err)
+	}
+	if len(dex.entries) == 0 || len(dex.byName) != len(dex.entries) {
+		t.Fatalf("Expected unique names, got %d names for %d entries", len(dex.byName), len(dex.entries))
+	}
+
+	for _, entry := range dex.entries {
+		if entry.Number <= 0 || len(entry.Types) == 0 || len(entry.Abilities) == 0 || entry.Stats.HP == 0 || entry.Flavor == "" {
+			t.Errorf("Incomplete entry %+v", entry)
+		}
+		if entry.EvolvesFrom != "" {
+			previous, ok := dex.Lookup(entry.EvolvesFrom)
+			if !ok || !evolvesInto(previous, entry.Name) {
+				t.Errorf("%s evolves from %s, which doesn't evolve into it", entry.Name, entry.EvolvesFrom)
+			}
+		}
+		for _, evolution := range entry.EvolvesTo {
+			next, ok := dex.Lookup(evolution.Name)
+			if !ok || next.EvolvesFrom != entry.Name || evolution.Method == "" {
+				t.Errorf("%s evolves into %s, which doesn't evolve from it", entry.Name, evolution.Name)
+			}
+		}
+	}
+}
+
+func evolvesInto(entry Pokemon, name string) bool {
+	for _, evolution := range entry.EvolvesTo {
+		if evolution.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// TestDex_Lookup tests finding Pokémon by name or number.
+func TestDex_Lookup(t *testing.T) {
+	dex, err := NewDex()
+	if err != nil {
+		t.Fatalf("Failed to load dex: %v", err)
+	}
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"wooper", "Wooper"},
+		{"  QUAGSIRE ", "Quagsire"},
+		{"paldean-wooper", "Paldean Wooper"},
+		{"195", "Quagsire"},
+		{"#194", "Wooper"},
+		{"missingno", ""},
+		{"0", ""},
+	}
+	for _, tt := range tests {
+		entry, ok := dex.Lookup(tt.query)
+		if ok != (tt.expected != "") || entry.Name != tt.expected {
+			t.Errorf("Lookup(%q) = %q, %v, want %q", tt.query, entry.Name, ok, tt.expected)
+		}
+	}
+
+	wooper, _ := dex.Lookup("wooper")
+	if wooper.Stats.Total() != 210 || len(wooper.EvolvesTo) != 1 || wooper.EvolvesTo[0].Name != "Quagsire" {
+		t.Errorf("Unexpected Wooper entry %+v", wooper)
+	}
+}
+
+// TestDex_Search tests that names starting with the query come before names containing it.
+func TestDex_Search(t *testing.T) {
+	dex, err := NewDex()
+	if err != nil {
+		t.Fatalf("Failed to load dex: %v", err)
+	}
+
+	var names []string
+	for _, entry := range dex.Search("woo", 10) {
+		names = append(names, entry.Name)
+	}
+	if len(names) != 2 || names[0] != "Wooper" || names[1] != "Paldean Wooper" {
+		t.Errorf("Expected Wooper then Paldean Wooper, got %v", names)
+	}
+
+	if got := dex.Search("", 5); len(got) != 5 || got[0].Name != "Bulbasaur" {
+		t.Errorf("Expected the first 5 entries for an empty query, got %d", len(got))
+	}
+	if got := dex.Search("zzz", 5); len(got) != 0 {
+		t.Errorf("Expected no results, got %v", got)
+	}
+}
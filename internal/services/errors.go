@@ -0,0 +1,73 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+
+	"wooper-bot/internal/i18n"
+)
+
+// ErrorCode identifies a kind of failure and selects the message users see for it.
+type ErrorCode string
+
+const (
+	ErrCodeInternal    ErrorCode = "internal"
+	ErrCodeImageLoad   ErrorCode = "image_load"
+	ErrCodeImageUpload ErrorCode = "image_upload"
+	ErrCodeStorage     ErrorCode = "storage"
+)
+
+// Error is a failure whose details are only meant for the logs. Users get the "error.<code>"
+// message of the i18n catalogs instead, so paths and internal state never reach Discord.
+type Error struct {
+	Code ErrorCode
+	Err  error
+}
+
+// NewError wraps err with a code.
+func NewError(code ErrorCode, err error) error {
+	return &Error{Code: code, Err: err}
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return string(e.Code)
+	}
+	return string(e.Code) + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// CodeOf returns the code err was wrapped with, or "" for errors outside the catalog.
+func CodeOf(err error) ErrorCode {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ""
+}
+
+// UserMessage returns the message users see for err in locale, with the correlation ID that
+// finds the full error in the logs. Errors outside the catalog get the internal error message.
+func UserMessage(err error, locale, correlationID string) string {
+	code := CodeOf(err)
+	if code == "" {
+		code = ErrCodeInternal
+	}
+
+	message := i18n.T(locale, "error."+string(code))
+	if correlationID == "" {
+		return message
+	}
+	return i18n.T(locale, "error.with_id", message, correlationID)
+}
+
+// NewCorrelationID returns a short random ID tying a user-visible error to its log lines.
+func NewCorrelationID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
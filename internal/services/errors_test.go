@@ -0,0 +1,92 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestUserMessage tests catalog lookups by code and locale.
+func TestUserMessage(t *testing.T) {
+	leaky := errors.New("open /srv/img/wooper/x.jpg: permission denied")
+
+	tests := []struct {
+		name          string
+		err           error
+		locale        string
+		correlationID string
+		expected      string
+	}{
+		{
+			name:          "cataloged error",
+			err:           NewError(ErrCodeImageLoad, leaky),
+			locale:        "en-US",
+			correlationID: "abc123",
+			expected:      "That image couldn't be loaded, please try again. (error ID: `abc123`)",
+		},
+		{
+			name:          "wrapped cataloged error",
+			err:           fmt.Errorf("send reply: %w", NewError(ErrCodeStorage, leaky)),
+			locale:        "en-GB",
+			correlationID: "abc123",
+			expected:      "The bot's storage is unavailable right now, please try again later. (error ID: `abc123`)",
+		},
+		{
+			name:          "french",
+			err:           NewError(ErrCodeImageUpload, leaky),
+			locale:        "fr",
+			correlationID: "abc123",
+			expected:      "Impossible d'envoyer l'image, veuillez réessayer dans un instant. (ID d'erreur : `abc123`)",
+		},
+		{
+			name:     "unknown locale falls back to english",
+			err:      NewError(ErrCodeImageLoad, leaky),
+			locale:   "ja",
+			expected: "That image couldn't be loaded, please try again.",
+		},
+		{
+			name:     "uncataloged error",
+			err:      leaky,
+			expected: "Something went wrong, please try again.",
+		},
+		{
+			name:     "no error",
+			expected: "Something went wrong, please try again.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := UserMessage(tt.err, tt.locale, tt.correlationID)
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+			if strings.Contains(got, "/srv") {
+				t.Errorf("Expected no error details in %q", got)
+			}
+		})
+	}
+}
+
+// TestCodeOf tests finding the code of wrapped errors.
+func TestCodeOf(t *testing.T) {
+	err := fmt.Errorf("outer: %w", NewError(ErrCodeStorage, errors.New("disk full")))
+	if code := CodeOf(err); code != ErrCodeStorage {
+		t.Errorf("Expected %s, got %s", ErrCodeStorage, code)
+	}
+	if !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("Expected the details to stay in the error for the logs, got %q", err)
+	}
+	if code := CodeOf(errors.New("plain")); code != "" {
+		t.Errorf("Expected no code, got %s", code)
+	}
+}
+
+// TestNewCorrelationID tests the format and uniqueness of correlation IDs.
+func TestNewCorrelationID(t *testing.T) {
+	a, b := NewCorrelationID(), NewCorrelationID()
+	if len(a) != 8 || a == b {
+		t.Errorf("Expected two different 8 character IDs, got %q and %q", a, b)
+	}
+}
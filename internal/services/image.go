@@ -2,12 +2,19 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
+	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"wooper-bot/internal/logger"
@@ -15,17 +22,51 @@ import (
 	"go.uber.org/zap"
 )
 
+const (
+	// MaxUploadSize is the largest file Discord accepts from a bot in any server.
+	MaxUploadSize = 10 << 20
+	// QuarantineThreshold is how many failures in a row take an image out of rotation.
+	QuarantineThreshold = 3
+	// DescriptionFile is the optional file in a category folder describing the category.
+	DescriptionFile = "description.txt"
+	// MetadataFile is the optional file in a category folder describing its images, by file name.
+	MetadataFile = "metadata.json"
+	// maxDescriptionLength bounds category descriptions, in characters, to keep help pages short.
+	maxDescriptionLength = 200
+)
+
+// ErrUnusableImage is returned for image files that can never be uploaded, like files over
+// the upload limit or files that aren't images.
+var ErrUnusableImage = errors.New("unusable image file")
+
 type ImageService struct {
-	categories map[string][]string
+	categories   map[string][]string
+	descriptions map[string]string
+	metadata     map[string]ImageMetadata // by image path
+	ids          map[string]string        // image path by image ID
+	paths        map[string]string        // image ID by image path
+
+	mu           sync.Mutex
+	failures     map[string]int
+	quarantined  map[string]error
+	onQuarantine func(path string, reason error)
 }
 
 func NewImageService(baseDir string) (*ImageService, error) {
 	logger.Logger.Info("Initializing image service", zap.String("base_dir", baseDir))
 
 	service := &ImageService{
-		categories: make(map[string][]string),
+		categories:   make(map[string][]string),
+		descriptions: make(map[string]string),
+		metadata:     make(map[string]ImageMetadata),
+		ids:          make(map[string]string),
+		paths:        make(map[string]string),
+		failures:     make(map[string]int),
+		quarantined:  make(map[string]error),
 	}
 
+	// Metadata files are read once every image is known
+	var metadataFiles []string
 	err := filepath.Walk(baseDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			logger.Logger.Error("Error walking directory", zap.String("path", path), zap.Error(err))
@@ -35,6 +76,21 @@ func NewImageService(baseDir string) (*ImageService, error) {
 			return nil
 		}
 
+		if info.Name() == DescriptionFile && filepath.Dir(filepath.Dir(path)) == filepath.Clean(baseDir) {
+			description, err := readDescription(path)
+			if err != nil {
+				// A missing description only makes help shorter
+				logger.Logger.Warn("Failed to read category description", zap.String("path", path), zap.Error(err))
+				return nil
+			}
+			service.descriptions[filepath.Base(filepath.Dir(path))] = description
+			return nil
+		}
+		if info.Name() == MetadataFile && filepath.Dir(filepath.Dir(path)) == filepath.Clean(baseDir) {
+			metadataFiles = append(metadataFiles, path)
+			return nil
+		}
+
 		ext := strings.ToLower(filepath.Ext(path))
 		if ext == ".png" || ext == ".jpg" || ext == ".jpeg" || ext == ".gif" || ext == ".webp" {
 			// Extract category from path (e.g., img/wooper/image.jpg -> wooper)
@@ -46,7 +102,17 @@ func NewImageService(baseDir string) (*ImageService, error) {
 			parts := strings.Split(relPath, string(filepath.Separator))
 			if len(parts) >= 2 {
 				category := parts[0]
+				id := imageID(relPath)
+				if other, ok := service.ids[id]; ok {
+					logger.Logger.Warn("Skipping image with a duplicate ID",
+						zap.String("path", path),
+						zap.String("other_path", other),
+						zap.String("image_id", id))
+					return nil
+				}
 				service.categories[category] = append(service.categories[category], path)
+				service.ids[id] = path
+				service.paths[path] = id
 				logger.Logger.Debug("Found image",
 					zap.String("category", category),
 					zap.String("file", filepath.Base(path)),
@@ -61,6 +127,10 @@ func NewImageService(baseDir string) (*ImageService, error) {
 		return nil, fmt.Errorf("scan image directory: %w", err)
 	}
 
+	for _, path := range metadataFiles {
+		service.loadMetadata(path)
+	}
+
 	if len(service.categories) == 0 {
 		logger.Logger.Error("No image categories found", zap.String("base_dir", baseDir))
 		return nil, fmt.Errorf("no image categories found in directory: %s", baseDir)
@@ -80,8 +150,8 @@ func NewImageService(baseDir string) (*ImageService, error) {
 }
 
 func (s *ImageService) GetRandomImage(category string) string {
-	images, exists := s.categories[category]
-	if !exists || len(images) == 0 {
+	images := s.available(category)
+	if len(images) == 0 {
 		logger.Logger.Warn("No images found for category", zap.String("category", category))
 		return ""
 	}
@@ -94,6 +164,101 @@ func (s *ImageService) GetRandomImage(category string) string {
 	return selectedImage
 }
 
+// GetRandomImageExcluding returns a random image from category that is not listed in exclude.
+// When every image is excluded it falls back to the whole category, so small categories still post.
+func (s *ImageService) GetRandomImageExcluding(category string, exclude []string) string {
+	images := s.available(category)
+	if len(images) == 0 {
+		logger.Logger.Warn("No images found for category", zap.String("category", category))
+		return ""
+	}
+
+	excluded := make(map[string]bool, len(exclude))
+	for _, image := range exclude {
+		excluded[image] = true
+	}
+
+	candidates := make([]string, 0, len(images))
+	for _, image := range images {
+		if !excluded[image] {
+			candidates = append(candidates, image)
+		}
+	}
+	if len(candidates) == 0 {
+		logger.Logger.Debug("All images excluded, falling back to full category",
+			zap.String("category", category),
+			zap.Int("excluded", len(exclude)))
+		candidates = images
+	}
+
+	selectedImage := candidates[rand.Intn(len(candidates))]
+	logger.Logger.Debug("Selected random image",
+		zap.String("category", category),
+		zap.String("image", filepath.Base(selectedImage)),
+		zap.Int("total_available", len(candidates)))
+	return selectedImage
+}
+
+// Weights of rated images in GetWeightedRandomImage. An unrated image has baseWeight and each
+// net vote adds or removes one, so a well liked image comes up a few times more often than
+// the others but a disliked one is never excluded.
+const (
+	baseWeight = 5
+	maxWeight  = 5 * baseWeight
+)
+
+// GetWeightedRandomImage returns a random image from category, favoring images with a higher
+// score. scores maps image IDs to their score; missing images count as unrated.
+func (s *ImageService) GetWeightedRandomImage(category string, scores map[string]int) string {
+	images := s.available(category)
+	if len(images) == 0 {
+		logger.Logger.Warn("No images found for category", zap.String("category", category))
+		return ""
+	}
+
+	weights := make([]int, len(images))
+	total := 0
+	for i, image := range images {
+		weights[i] = min(max(baseWeight+scores[s.paths[image]], 1), maxWeight)
+		total += weights[i]
+	}
+
+	pick := rand.Intn(total)
+	for i, weight := range weights {
+		if pick < weight {
+			return images[i]
+		}
+		pick -= weight
+	}
+	return images[len(images)-1]
+}
+
+// available returns the images of category that are not quarantined.
+func (s *ImageService) available(category string) []string {
+	images := s.categories[category]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.quarantined) == 0 {
+		return images
+	}
+
+	available := make([]string, 0, len(images))
+	for _, image := range images {
+		if _, ok := s.quarantined[image]; !ok {
+			available = append(available, image)
+		}
+	}
+	return available
+}
+
+// GetCategoryImages returns the images of category in a stable order, without quarantined ones.
+func (s *ImageService) GetCategoryImages(category string) []string {
+	return slices.Clone(s.available(category))
+}
+
+// GetImageFile opens an image for upload. Files over MaxUploadSize or whose content isn't an
+// image fail with ErrUnusableImage, so callers can pick another image instead of retrying.
 func (s *ImageService) GetImageFile(ctx context.Context, imagePath string) (io.ReadCloser, string, error) {
 	logger.Logger.Debug("Opening image file", zap.String("path", imagePath))
 
@@ -103,6 +268,12 @@ func (s *ImageService) GetImageFile(ctx context.Context, imagePath string) (io.R
 		return nil, "", fmt.Errorf("open image file: %w", err)
 	}
 
+	if err := checkImage(file); err != nil {
+		file.Close()
+		logger.Logger.Error("Unusable image file", zap.String("path", imagePath), zap.Error(err))
+		return nil, "", err
+	}
+
 	fileName := filepath.Base(imagePath)
 	logger.Logger.Debug("Successfully opened image file",
 		zap.String("filename", fileName),
@@ -111,6 +282,139 @@ func (s *ImageService) GetImageFile(ctx context.Context, imagePath string) (io.R
 	return file, fileName, nil
 }
 
+// checkImage validates the size and content type of file and leaves it at its start.
+func checkImage(file *os.File) error {
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("stat image file: %w", err)
+	}
+	if info.Size() > MaxUploadSize {
+		return fmt.Errorf("%w: %d bytes is over the %d bytes upload limit", ErrUnusableImage, info.Size(), MaxUploadSize)
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("read image file: %w", err)
+	}
+	if contentType := http.DetectContentType(head[:n]); !strings.HasPrefix(contentType, "image/") {
+		return fmt.Errorf("%w: content is %s", ErrUnusableImage, contentType)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("rewind image file: %w", err)
+	}
+	return nil
+}
+
+// OnQuarantine sets a function called whenever an image is quarantined.
+func (s *ImageService) OnQuarantine(fn func(path string, reason error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onQuarantine = fn
+}
+
+// ReportFailure records that imagePath could not be sent because of the file itself.
+// After QuarantineThreshold failures in a row the image is no longer picked, until restart.
+// It reports whether this failure quarantined the image.
+func (s *ImageService) ReportFailure(imagePath string, reason error) bool {
+	s.mu.Lock()
+	if _, ok := s.quarantined[imagePath]; ok {
+		s.mu.Unlock()
+		return false
+	}
+	s.failures[imagePath]++
+	if s.failures[imagePath] < QuarantineThreshold {
+		s.mu.Unlock()
+		return false
+	}
+	delete(s.failures, imagePath)
+	s.quarantined[imagePath] = reason
+	onQuarantine := s.onQuarantine
+	s.mu.Unlock()
+
+	logger.Logger.Warn("Image quarantined",
+		zap.String("path", imagePath),
+		zap.Int("failures", QuarantineThreshold),
+		zap.Error(reason))
+	if onQuarantine != nil {
+		onQuarantine(imagePath, reason)
+	}
+	return true
+}
+
+// ReportSuccess resets the failure count of imagePath.
+func (s *ImageService) ReportSuccess(imagePath string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.failures, imagePath)
+}
+
+// IsQuarantined reports whether imagePath was taken out of rotation.
+func (s *ImageService) IsQuarantined(imagePath string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.quarantined[imagePath]
+	return ok
+}
+
+// Quarantined lists the quarantined images, sorted.
+func (s *ImageService) Quarantined() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	images := make([]string, 0, len(s.quarantined))
+	for image := range s.quarantined {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+	return images
+}
+
+// imageID derives the short ID of an image from its path relative to the image directory,
+// so IDs stay the same across restarts as long as the file isn't moved or renamed.
+func imageID(relPath string) string {
+	sum := sha256.Sum256([]byte(filepath.ToSlash(relPath)))
+	return hex.EncodeToString(sum[:5])
+}
+
+// ImageID returns the short ID of an image, or "" for unknown paths.
+func (s *ImageService) ImageID(imagePath string) string {
+	return s.paths[imagePath]
+}
+
+// ImageByID returns the path and category of the image with the given ID.
+func (s *ImageService) ImageByID(id string) (imagePath, category string, ok bool) {
+	imagePath, ok = s.ids[id]
+	if !ok {
+		return "", "", false
+	}
+	for category, images := range s.categories {
+		if slices.Contains(images, imagePath) {
+			return imagePath, category, true
+		}
+	}
+	return "", "", false
+}
+
+// readDescription reads a category description file, trimmed to maxDescriptionLength characters.
+func readDescription(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read description: %w", err)
+	}
+	description := []rune(strings.TrimSpace(string(data)))
+	if len(description) > maxDescriptionLength {
+		description = append(description[:maxDescriptionLength-1], '…')
+	}
+	return string(description), nil
+}
+
+// GetCategoryDescription returns the description of a category, or "" when it has none.
+func (s *ImageService) GetCategoryDescription(category string) string {
+	return s.descriptions[category]
+}
+
 func (s *ImageService) GetImageCount(category string) int {
 	if images, exists := s.categories[category]; exists {
 		return len(images)
@@ -2,8 +2,11 @@ package services
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 
 	"wooper-bot/internal/logger"
@@ -20,6 +23,9 @@ func setupTestLogger(t *testing.T) {
 	})
 }
 
+// pngHeader is enough of a PNG for the content check of GetImageFile.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n")
+
 // setupTestImages creates a temporary directory structure with test images
 func setupTestImages(t *testing.T) string {
 	setupTestLogger(t)
@@ -39,11 +45,9 @@ func setupTestImages(t *testing.T) string {
 		// Create test image files
 		for i := 1; i <= 3; i++ {
 			filename := filepath.Join(categoryDir, category+"_"+string(rune('0'+i))+".jpg")
-			file, err := os.Create(filename)
-			if err != nil {
+			if err := os.WriteFile(filename, pngHeader, 0644); err != nil {
 				t.Fatalf("Failed to create test file: %v", err)
 			}
-			file.Close()
 		}
 	}
 
@@ -359,3 +363,242 @@ func TestImageService_FileExtensions(t *testing.T) {
 		t.Errorf("Expected %d image files, got %d", expectedCount, count)
 	}
 }
+
+// TestImageService_GetRandomImageExcluding tests that excluded images are skipped until none are left.
+func TestImageService_GetRandomImageExcluding(t *testing.T) {
+	testDir := setupTestImages(t)
+	service, err := NewImageService(testDir)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	all := service.categories["wooper"]
+
+	t.Run("skips excluded images", func(t *testing.T) {
+		exclude := all[:len(all)-1]
+		for i := 0; i < 20; i++ {
+			result := service.GetRandomImageExcluding("wooper", exclude)
+			if result != all[len(all)-1] {
+				t.Fatalf("Expected %s but got %s", all[len(all)-1], result)
+			}
+		}
+	})
+
+	t.Run("falls back when everything is excluded", func(t *testing.T) {
+		result := service.GetRandomImageExcluding("wooper", all)
+		if result == "" {
+			t.Errorf("Expected an image but got empty string")
+		}
+	})
+
+	t.Run("unknown category", func(t *testing.T) {
+		result := service.GetRandomImageExcluding("nonexistent", nil)
+		if result != "" {
+			t.Errorf("Expected empty result but got: %s", result)
+		}
+	})
+}
+
+// TestImageService_GetImageFile_Unusable tests that oversized and non-image files are rejected.
+func TestImageService_GetImageFile_Unusable(t *testing.T) {
+	testDir := setupTestImages(t)
+	service, err := NewImageService(testDir)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	notImage := filepath.Join(testDir, "wooper", "notes.png")
+	if err := os.WriteFile(notImage, []byte("not an image"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	tooLarge := filepath.Join(testDir, "wooper", "huge.png")
+	if err := os.WriteFile(tooLarge, pngHeader, 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := os.Truncate(tooLarge, MaxUploadSize+1); err != nil {
+		t.Fatalf("Failed to grow test file: %v", err)
+	}
+
+	for _, path := range []string{notImage, tooLarge} {
+		reader, _, err := service.GetImageFile(context.Background(), path)
+		if !errors.Is(err, ErrUnusableImage) {
+			t.Errorf("Expected ErrUnusableImage for %s, got %v", filepath.Base(path), err)
+		}
+		if reader != nil {
+			t.Errorf("Expected nil reader for %s", filepath.Base(path))
+		}
+	}
+}
+
+// TestImageService_Quarantine tests that images are quarantined after repeated failures only.
+func TestImageService_Quarantine(t *testing.T) {
+	testDir := setupTestImages(t)
+	service, err := NewImageService(testDir)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	var hooked []string
+	service.OnQuarantine(func(path string, reason error) {
+		hooked = append(hooked, path)
+	})
+
+	bad := filepath.Join(testDir, "cats", "cats_1.jpg")
+	boom := errors.New("boom")
+
+	// A success resets the count
+	for i := 0; i < QuarantineThreshold-1; i++ {
+		service.ReportFailure(bad, boom)
+	}
+	service.ReportSuccess(bad)
+	if service.ReportFailure(bad, boom) {
+		t.Fatalf("Expected the count to restart after a success")
+	}
+
+	for i := 1; i < QuarantineThreshold-1; i++ {
+		service.ReportFailure(bad, boom)
+	}
+	if !service.ReportFailure(bad, boom) {
+		t.Fatalf("Expected image to be quarantined after %d failures", QuarantineThreshold)
+	}
+	if service.ReportFailure(bad, boom) {
+		t.Errorf("Expected a quarantined image not to be quarantined again")
+	}
+	if len(hooked) != 1 || hooked[0] != bad {
+		t.Errorf("Expected the hook to be called once for %s, got %v", bad, hooked)
+	}
+	if quarantined := service.Quarantined(); len(quarantined) != 1 || quarantined[0] != bad {
+		t.Errorf("Expected %s quarantined, got %v", bad, quarantined)
+	}
+	if !service.IsQuarantined(bad) || service.IsQuarantined(filepath.Join(testDir, "cats", "cats_2.jpg")) {
+		t.Errorf("Expected only %s reported as quarantined", bad)
+	}
+
+	for i := 0; i < 50; i++ {
+		if image := service.GetRandomImage("cats"); image == bad {
+			t.Fatalf("Expected quarantined image never to be picked")
+		}
+		if image := service.GetRandomImageExcluding("cats", nil); image == bad {
+			t.Fatalf("Expected quarantined image never to be picked")
+		}
+	}
+}
+
+// TestImageService_ImageID tests that image IDs are short, stable across scans and resolve back.
+func TestImageService_ImageID(t *testing.T) {
+	testDir := setupTestImages(t)
+	service, err := NewImageService(testDir)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+	rescanned, err := NewImageService(testDir)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	path := service.categories["cats"][0]
+	id := service.ImageID(path)
+	if len(id) != 10 {
+		t.Fatalf("Expected a 10 character ID, got %q", id)
+	}
+	if rescanned.ImageID(path) != id {
+		t.Errorf("Expected the same ID after a rescan, got %q and %q", id, rescanned.ImageID(path))
+	}
+
+	gotPath, category, ok := service.ImageByID(id)
+	if !ok || gotPath != path || category != "cats" {
+		t.Errorf("Expected %s in cats, got %s in %s (%t)", path, gotPath, category, ok)
+	}
+	if _, _, ok := service.ImageByID("unknown"); ok {
+		t.Errorf("Expected unknown IDs not to resolve")
+	}
+	if service.ImageID("/no/such/image.png") != "" {
+		t.Errorf("Expected no ID for unknown paths")
+	}
+}
+
+// TestImageService_GetCategoryDescription tests reading and trimming category descriptions.
+func TestImageService_GetCategoryDescription(t *testing.T) {
+	testDir := setupTestImages(t)
+	files := map[string]string{
+		"wooper": "  The best water fish Pokémon.\n",
+		"cats":   strings.Repeat("meow ", 100),
+	}
+	for category, content := range files {
+		if err := os.WriteFile(filepath.Join(testDir, category, DescriptionFile), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write description: %v", err)
+		}
+	}
+
+	service, err := NewImageService(testDir)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	if got := service.GetCategoryDescription("wooper"); got != "The best water fish Pokémon." {
+		t.Errorf("Expected the trimmed description, got %q", got)
+	}
+	if got := []rune(service.GetCategoryDescription("cats")); len(got) != maxDescriptionLength || got[len(got)-1] != '…' {
+		t.Errorf("Expected a description cut to %d characters, got %q", maxDescriptionLength, string(got))
+	}
+	if got := service.GetCategoryDescription("dogs"); got != "" {
+		t.Errorf("Expected no description, got %q", got)
+	}
+	if service.GetImageCount("wooper") != 3 {
+		t.Errorf("Expected the description not to count as an image")
+	}
+}
+
+// TestImageService_GetCategoryImages tests the ordered image list used for browsing.
+func TestImageService_GetCategoryImages(t *testing.T) {
+	testDir := setupTestImages(t)
+	service, err := NewImageService(testDir)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	images := service.GetCategoryImages("dogs")
+	if len(images) != 3 || !sort.StringsAreSorted(images) {
+		t.Fatalf("Expected 3 sorted images, got %v", images)
+	}
+
+	for i := 0; i < QuarantineThreshold; i++ {
+		service.ReportFailure(images[1], errors.New("too large"))
+	}
+	if got := service.GetCategoryImages("dogs"); len(got) != 2 || got[0] != images[0] || got[1] != images[2] {
+		t.Errorf("Expected the quarantined image left out, got %v", got)
+	}
+
+	images[0] = "changed"
+	if service.GetCategoryImages("dogs")[0] == "changed" {
+		t.Errorf("Expected a copy of the image list")
+	}
+}
+
+// TestImageService_GetWeightedRandomImage tests that better rated images are picked more often.
+func TestImageService_GetWeightedRandomImage(t *testing.T) {
+	testDir := setupTestImages(t)
+	service, err := NewImageService(testDir)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	images := service.GetCategoryImages("cats")
+	liked, disliked := images[0], images[2]
+	scores := map[string]int{
+		service.ImageID(liked):    100, // capped at maxWeight
+		service.ImageID(disliked): -100,
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 3100; i++ {
+		counts[service.GetWeightedRandomImage("cats", scores)]++
+	}
+	// Expected shares are 25, 5 and 1 out of 31
+	if counts[liked] < 2000 || counts[disliked] == 0 || counts[disliked] > 300 {
+		t.Errorf("Unexpected distribution %v", counts)
+	}
+	if service.GetWeightedRandomImage("nonexistent", scores) != "" {
+		t.Errorf("Expected no image for an unknown category")
+	}
+}
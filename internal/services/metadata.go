@@ -0,0 +1,90 @@
+package services
+
+import (
+	"encoding/json"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"wooper-bot/internal/logger"
+
+	"go.uber.org/zap"
+)
+
+// ImageMetadata describes an image in its category's MetadataFile.
+type ImageMetadata struct {
+	// Answers are the names accepted as quiz answers, the first one is shown on reveal
+	Answers []string `json:"answers"`
+}
+
+// loadMetadata reads a category's MetadataFile. Entries for unknown files are skipped.
+func (s *ImageService) loadMetadata(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		logger.Logger.Warn("Failed to read image metadata", zap.String("path", path), zap.Error(err))
+		return
+	}
+	var entries map[string]ImageMetadata
+	if err := json.Unmarshal(data, &entries); err != nil {
+		logger.Logger.Warn("Invalid image metadata", zap.String("path", path), zap.Error(err))
+		return
+	}
+
+	dir := filepath.Dir(path)
+	for name, metadata := range entries {
+		imagePath := filepath.Join(dir, filepath.FromSlash(name))
+		if _, ok := s.paths[imagePath]; !ok {
+			logger.Logger.Warn("Metadata for an unknown image", zap.String("path", path), zap.String("image", name))
+			continue
+		}
+		s.metadata[imagePath] = metadata
+	}
+}
+
+// GetImageMetadata returns the metadata of an image and whether it has any.
+func (s *ImageService) GetImageMetadata(imagePath string) (ImageMetadata, bool) {
+	metadata, ok := s.metadata[imagePath]
+	return metadata, ok
+}
+
+// quizExtensions are the formats silhouettes can be rendered from.
+var quizExtensions = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true}
+
+// GetRandomQuizImage returns a random image of category with quiz answers that a silhouette
+// can be rendered from, or "" when the category has none.
+func (s *ImageService) GetRandomQuizImage(category string) string {
+	var candidates []string
+	for _, image := range s.available(category) {
+		if len(s.metadata[image].Answers) > 0 && quizExtensions[strings.ToLower(filepath.Ext(image))] {
+			candidates = append(candidates, image)
+		}
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	return candidates[rand.Intn(len(candidates))]
+}
+
+// accents maps accented letters to the letters guesses may use instead.
+var accents = strings.NewReplacer(
+	"à", "a", "â", "a", "ä", "a", "á", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"î", "i", "ï", "i", "í", "i",
+	"ô", "o", "ö", "o", "ó", "o",
+	"ù", "u", "û", "u", "ü", "u", "ú", "u",
+	"ç", "c", "ñ", "n", "♀", "f", "♂", "m",
+)
+
+// NormalizeAnswer reduces a quiz answer or guess to lowercase letters and digits, so case,
+// accents, spaces and punctuation don't matter.
+func NormalizeAnswer(answer string) string {
+	answer = accents.Replace(strings.ToLower(answer))
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, answer)
+}
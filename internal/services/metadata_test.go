@@ -0,0 +1,60 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestImageService_Metadata tests loading quiz answers from a category's metadata file.
+func TestImageService_Metadata(t *testing.T) {
+	testDir := setupTestImages(t)
+	metadata := `{
+		"wooper_1.jpg": {"answers": ["Wooper", "Upah"]},
+		"wooper_2.jpg": {"answers": []},
+		"missing.jpg": {"answers": ["Ghost"]}
+	}`
+	if err := os.WriteFile(filepath.Join(testDir, "wooper", MetadataFile), []byte(metadata), 0644); err != nil {
+		t.Fatalf("Failed to write metadata: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(testDir, "cats", MetadataFile), []byte("{broken"), 0644); err != nil {
+		t.Fatalf("Failed to write metadata: %v", err)
+	}
+
+	service, err := NewImageService(testDir)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	wooperOne := filepath.Join(testDir, "wooper", "wooper_1.jpg")
+	if got, ok := service.GetImageMetadata(wooperOne); !ok || len(got.Answers) != 2 || got.Answers[0] != "Wooper" {
+		t.Errorf("Expected the answers of wooper_1.jpg, got %+v", got)
+	}
+	for i := 0; i < 10; i++ {
+		if got := service.GetRandomQuizImage("wooper"); got != wooperOne {
+			t.Fatalf("Expected only wooper_1.jpg to have answers, got %q", got)
+		}
+	}
+	if got := service.GetRandomQuizImage("cats"); got != "" {
+		t.Errorf("Expected no quiz image with broken metadata, got %q", got)
+	}
+	if service.GetImageCount("wooper") != 3 {
+		t.Errorf("Expected the metadata file not to count as an image, got %d images", service.GetImageCount("wooper"))
+	}
+}
+
+// TestNormalizeAnswer tests that guesses match answers regardless of case, accents and punctuation.
+func TestNormalizeAnswer(t *testing.T) {
+	tests := map[string]string{
+		"Wooper":       "wooper",
+		"  Mr. Mime! ": "mrmime",
+		"Pokémon":      "pokemon",
+		"Nidoran♀":     "nidoranf",
+		"Porygon-Z":    "porygonz",
+	}
+	for answer, expected := range tests {
+		if got := NormalizeAnswer(answer); got != expected {
+			t.Errorf("Expected %q for %q, got %q", expected, answer, got)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"wooper-bot/internal/storage"
+)
+
+// MaxPermissionRules is how many permission rules a guild can have.
+const MaxPermissionRules = 50
+
+// PermissionRules are a guild's role and channel restrictions of commands and categories.
+type PermissionRules []storage.PermissionRule
+
+// Allows reports whether a member with roles may use the named command or category in
+// channelID. Targets without rules are open to everyone; otherwise one of their rules must
+// match both its role, if any, and its channel, if any.
+func (rules PermissionRules) Allows(target storage.PermissionTarget, name string, roles []string, channelID string) bool {
+	restricted := false
+	for _, rule := range rules {
+		if rule.Target != target || rule.Name != name {
+			continue
+		}
+		restricted = true
+		if ruleMatches(rule, roles, channelID) {
+			return true
+		}
+	}
+	return !restricted
+}
+
+// DeniedCategories returns the restricted categories a member with roles may not use in channelID.
+func (rules PermissionRules) DeniedCategories(roles []string, channelID string) []string {
+	var denied []string
+	for _, rule := range rules {
+		if rule.Target == storage.PermissionCategory && !slices.Contains(denied, rule.Name) &&
+			!rules.Allows(storage.PermissionCategory, rule.Name, roles, channelID) {
+			denied = append(denied, rule.Name)
+		}
+	}
+	sort.Strings(denied)
+	return denied
+}
+
+// ruleMatches reports whether rule allows a member with roles in channelID. The @everyone
+// role has the guild's ID and isn't listed in member roles.
+func ruleMatches(rule storage.PermissionRule, roles []string, channelID string) bool {
+	if rule.ChannelID != "" && rule.ChannelID != channelID {
+		return false
+	}
+	return rule.RoleID == "" || rule.RoleID == rule.GuildID || slices.Contains(roles, rule.RoleID)
+}
+
+// PermissionService reads guild permission rules stored in the database.
+type PermissionService struct {
+	store *storage.Store
+}
+
+func NewPermissionService(store *storage.Store) *PermissionService {
+	return &PermissionService{store: store}
+}
+
+// Rules returns a guild's permission rules. DMs (empty guild ID) have none.
+func (s *PermissionService) Rules(ctx context.Context, guildID string) (PermissionRules, error) {
+	if guildID == "" {
+		return nil, nil
+	}
+	rules, err := s.store.PermissionRules(ctx, guildID)
+	if err != nil {
+		return nil, fmt.Errorf("load permission rules: %w", err)
+	}
+	return rules, nil
+}
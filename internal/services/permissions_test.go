@@ -0,0 +1,68 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"wooper-bot/internal/storage"
+)
+
+// TestPermissionRules_Allows tests that restricted targets need a rule matching role and channel.
+func TestPermissionRules_Allows(t *testing.T) {
+	rules := PermissionRules{
+		{GuildID: "g1", Target: storage.PermissionCommand, Name: "image", RoleID: "members", ChannelID: "memes"},
+		{GuildID: "g1", Target: storage.PermissionCommand, Name: "image", RoleID: "mods"},
+		{GuildID: "g1", Target: storage.PermissionCommand, Name: "quiz", RoleID: "g1", ChannelID: "games"},
+		{GuildID: "g1", Target: storage.PermissionCategory, Name: "image", ChannelID: "art"},
+	}
+
+	tests := []struct {
+		name      string
+		target    storage.PermissionTarget
+		command   string
+		roles     []string
+		channelID string
+		want      bool
+	}{
+		{"role in channel", storage.PermissionCommand, "image", []string{"members"}, "memes", true},
+		{"role in another channel", storage.PermissionCommand, "image", []string{"members"}, "general", false},
+		{"channel without the role", storage.PermissionCommand, "image", nil, "memes", false},
+		{"role allowed anywhere", storage.PermissionCommand, "image", []string{"members", "mods"}, "general", true},
+		{"everyone in channel", storage.PermissionCommand, "quiz", nil, "games", true},
+		{"everyone elsewhere", storage.PermissionCommand, "quiz", nil, "general", false},
+		{"unrestricted command", storage.PermissionCommand, "dex", nil, "general", true},
+		{"category of the same name", storage.PermissionCategory, "image", []string{"mods"}, "general", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rules.Allows(tt.target, tt.command, tt.roles, tt.channelID); got != tt.want {
+				t.Errorf("Allows(%s %s) = %v, want %v", tt.target, tt.command, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPermissionRules_DeniedCategories tests listing the categories a member can't use.
+func TestPermissionRules_DeniedCategories(t *testing.T) {
+	rules := PermissionRules{
+		{GuildID: "g1", Target: storage.PermissionCategory, Name: "wooper", RoleID: "fans"},
+		{GuildID: "g1", Target: storage.PermissionCategory, Name: "cats", ChannelID: "pets"},
+		{GuildID: "g1", Target: storage.PermissionCategory, Name: "cats", RoleID: "fans"},
+		{GuildID: "g1", Target: storage.PermissionCommand, Name: "dogs", RoleID: "fans"},
+	}
+
+	tests := []struct {
+		roles     []string
+		channelID string
+		want      []string
+	}{
+		{nil, "general", []string{"cats", "wooper"}},
+		{nil, "pets", []string{"wooper"}},
+		{[]string{"fans"}, "general", nil},
+	}
+	for _, tt := range tests {
+		if got := rules.DeniedCategories(tt.roles, tt.channelID); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("DeniedCategories(%v, %s) = %v, want %v", tt.roles, tt.channelID, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"math/rand"
+	"strconv"
+)
+
+// Rarity ranks images in the catch game. Higher rarities are less common.
+type Rarity int
+
+const (
+	RarityCommon Rarity = iota
+	RarityUncommon
+	RarityRare
+	RarityLegendary
+)
+
+// rarityShares are the percentages of images of each rarity, which are also the odds of
+// rolling each rarity when catching.
+var rarityShares = [...]int{60, 25, 12, 3}
+
+// String returns the name of the rarity, as used in message keys.
+func (r Rarity) String() string {
+	switch r {
+	case RarityCommon:
+		return "common"
+	case RarityUncommon:
+		return "uncommon"
+	case RarityRare:
+		return "rare"
+	case RarityLegendary:
+		return "legendary"
+	}
+	return "rarity(" + strconv.Itoa(int(r)) + ")"
+}
+
+// rarityAt maps a roll in [0, 100) to the rarity whose share covers it.
+func rarityAt(roll int) Rarity {
+	for i, share := range rarityShares {
+		if roll < share {
+			return Rarity(i)
+		}
+		roll -= share
+	}
+	return RarityLegendary
+}
+
+// RarityOf returns the rarity of an image. It is derived from the image ID, so an image keeps
+// its rarity as long as its ID doesn't change.
+func RarityOf(imageID string) Rarity {
+	n, err := strconv.ParseUint(imageID[:min(len(imageID), 8)], 16, 32)
+	if err != nil {
+		return RarityCommon
+	}
+	return rarityAt(int(n % 100))
+}
+
+// RollRarity returns a random rarity with the odds of rarityShares.
+func RollRarity() Rarity {
+	return rarityAt(rand.Intn(100))
+}
+
+// GetRandomImageOfRarity returns a random image of category with the given rarity, with its
+// rarity. When the category has no image of that rarity, the closest lower rarity is used,
+// then any image.
+func (s *ImageService) GetRandomImageOfRarity(category string, rarity Rarity) (string, Rarity) {
+	byRarity := make(map[Rarity][]string)
+	images := s.available(category)
+	for _, image := range images {
+		r := RarityOf(s.paths[image])
+		byRarity[r] = append(byRarity[r], image)
+	}
+
+	for r := rarity; r >= RarityCommon; r-- {
+		if candidates := byRarity[r]; len(candidates) > 0 {
+			return candidates[rand.Intn(len(candidates))], r
+		}
+	}
+	if len(images) == 0 {
+		return "", rarity
+	}
+	image := images[rand.Intn(len(images))]
+	return image, RarityOf(s.paths[image])
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"strconv"
+	"testing"
+)
+
+// TestRarityOf tests that rarities are stable and spread over images with their shares.
+func TestRarityOf(t *testing.T) {
+	const total = 10000
+	counts := make(map[Rarity]int)
+	for i := 0; i < total; i++ {
+		id := imageID("cats/" + strconv.Itoa(i) + ".jpg")
+		if RarityOf(id) != RarityOf(id) {
+			t.Fatalf("Expected a stable rarity for %s", id)
+		}
+		counts[RarityOf(id)]++
+	}
+	for i, share := range rarityShares {
+		if got := counts[Rarity(i)] * 100 / total; got < share-2 || got > share+2 {
+			t.Errorf("Expected about %d%% %s images, got %d%%", share, Rarity(i), got)
+		}
+	}
+
+	if RarityOf("not hex") != RarityCommon || RarityOf("") != RarityCommon {
+		t.Errorf("Expected invalid IDs to be common")
+	}
+	if RarityLegendary.String() != "legendary" || Rarity(9).String() != "rarity(9)" {
+		t.Errorf("Unexpected rarity names %s and %s", RarityLegendary, Rarity(9))
+	}
+}
+
+// TestImageService_GetRandomImageOfRarity tests picking images by rarity, falling back to lower ones.
+func TestImageService_GetRandomImageOfRarity(t *testing.T) {
+	testDir := setupTestImages(t)
+	service, err := NewImageService(testDir)
+	if err != nil {
+		t.Fatalf("Failed to create service: %v", err)
+	}
+
+	present := make(map[Rarity]bool)
+	for _, image := range service.GetCategoryImages("cats") {
+		present[RarityOf(service.ImageID(image))] = true
+	}
+
+	for requested := RarityCommon; requested <= RarityLegendary; requested++ {
+		expected := Rarity(-1)
+		for r := requested; r >= RarityCommon; r-- {
+			if present[r] {
+				expected = r
+				break
+			}
+		}
+
+		image, rarity := service.GetRandomImageOfRarity("cats", requested)
+		if image == "" || rarity != RarityOf(service.ImageID(image)) {
+			t.Fatalf("Expected an image with its rarity, got %q (%s)", image, rarity)
+		}
+		if expected >= RarityCommon && rarity != expected {
+			t.Errorf("Expected a %s image for %s, got %s", expected, requested, rarity)
+		}
+	}
+
+	if image, _ := service.GetRandomImageOfRarity("nonexistent", RarityRare); image != "" {
+		t.Errorf("Expected no image for an unknown category, got %q", image)
+	}
+}
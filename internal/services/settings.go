@@ -0,0 +1,271 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"wooper-bot/internal/i18n"
+	"wooper-bot/internal/logger"
+	"wooper-bot/internal/storage"
+
+	"go.uber.org/zap"
+)
+
+// ReplyStyle controls how text command responses are sent.
+type ReplyStyle string
+
+const (
+	// ReplyStylePlain posts responses as regular channel messages.
+	ReplyStylePlain ReplyStyle = "plain"
+	// ReplyStyleReply posts responses as replies to the invoking message.
+	ReplyStyleReply ReplyStyle = "reply"
+)
+
+// Guild setting keys as stored in the database.
+const (
+	SettingPrefix            = "prefix"
+	SettingEnabledCategories = "enabled_categories"
+	SettingDefaultCategory   = "default_category"
+	SettingReplyStyle        = "reply_style"
+	SettingEphemeralErrors   = "ephemeral_errors"
+	SettingLanguage          = "language"
+	SettingWeightedSelection = "weighted_selection"
+)
+
+// LanguageAuto is the language setting value that follows each user's Discord language.
+const LanguageAuto = "auto"
+
+// DefaultPrefix is the text command prefix used when a guild hasn't set one.
+const DefaultPrefix = "!"
+
+const (
+	maxPrefixes     = 5
+	maxPrefixLength = 5
+)
+
+// GuildSettings is the per-guild behavior configured through /config.
+type GuildSettings struct {
+	Prefixes          []string // text command prefixes; mentioning the bot always works too
+	EnabledCategories []string // empty means every category is enabled
+	DefaultCategory   string   // used when no category is given, empty for none
+	ReplyStyle        ReplyStyle
+	EphemeralErrors   bool
+	Language          string // forced answer language, empty to follow each user's Discord language
+	WeightedSelection bool   // pick well rated images more often
+
+	// DeniedCategories are the categories the invoking member may not use in the invoking
+	// channel. They come from permission rules rather than /config, and are set per command.
+	DeniedCategories []string
+}
+
+// DefaultGuildSettings returns the settings of a guild that never ran /config, and of DMs.
+func DefaultGuildSettings() GuildSettings {
+	return GuildSettings{
+		Prefixes:   []string{DefaultPrefix},
+		ReplyStyle: ReplyStylePlain,
+	}
+}
+
+// PrimaryPrefix returns the prefix shown in help and usage messages.
+func (g GuildSettings) PrimaryPrefix() string {
+	if len(g.Prefixes) == 0 {
+		return DefaultPrefix
+	}
+	return g.Prefixes[0]
+}
+
+// CategoryEnabled reports whether a category may be used in the guild, and by the invoking
+// member there when permission rules restrict it.
+func (g GuildSettings) CategoryEnabled(category string) bool {
+	if slices.Contains(g.DeniedCategories, category) {
+		return false
+	}
+	if len(g.EnabledCategories) == 0 {
+		return true
+	}
+	for _, enabled := range g.EnabledCategories {
+		if enabled == category {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterCategories returns the categories enabled in the guild, sorted.
+func (g GuildSettings) FilterCategories(categories []string) []string {
+	var enabled []string
+	for _, category := range categories {
+		if g.CategoryEnabled(category) {
+			enabled = append(enabled, category)
+		}
+	}
+	sort.Strings(enabled)
+	return enabled
+}
+
+// SettingsService reads and validates guild settings stored in the database.
+type SettingsService struct {
+	store  *storage.Store
+	images *ImageService
+}
+
+func NewSettingsService(store *storage.Store, images *ImageService) *SettingsService {
+	return &SettingsService{store: store, images: images}
+}
+
+// Get returns a guild's settings with defaults for anything unset. DMs (empty guild ID) use defaults.
+func (s *SettingsService) Get(ctx context.Context, guildID string) (GuildSettings, error) {
+	settings := DefaultGuildSettings()
+	if guildID == "" {
+		return settings, nil
+	}
+
+	values, err := s.store.GuildSettings(ctx, guildID)
+	if err != nil {
+		return settings, fmt.Errorf("load guild settings: %w", err)
+	}
+
+	if v, ok := values[SettingPrefix]; ok {
+		settings.Prefixes = strings.Fields(v)
+	}
+	if v, ok := values[SettingEnabledCategories]; ok {
+		settings.EnabledCategories = splitList(v)
+	}
+	if v, ok := values[SettingDefaultCategory]; ok {
+		settings.DefaultCategory = v
+	}
+	if v, ok := values[SettingReplyStyle]; ok {
+		settings.ReplyStyle = ReplyStyle(v)
+	}
+	if v, ok := values[SettingEphemeralErrors]; ok {
+		settings.EphemeralErrors, _ = strconv.ParseBool(v)
+	}
+	if v, ok := values[SettingLanguage]; ok {
+		settings.Language = v
+	}
+	if v, ok := values[SettingWeightedSelection]; ok {
+		settings.WeightedSelection, _ = strconv.ParseBool(v)
+	}
+	return settings, nil
+}
+
+// Set validates and stores a single guild setting.
+func (s *SettingsService) Set(ctx context.Context, guildID, key, value string) error {
+	normalized, err := s.normalize(key, value)
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.SetGuildSetting(ctx, guildID, key, normalized); err != nil {
+		return NewError(ErrCodeStorage, err)
+	}
+
+	logger.Logger.Info("Guild setting updated",
+		zap.String("guild_id", guildID),
+		zap.String("key", key),
+		zap.String("value", normalized))
+	return nil
+}
+
+// Reset removes every setting of a guild so the defaults apply again.
+func (s *SettingsService) Reset(ctx context.Context, guildID string) error {
+	for _, key := range []string{SettingPrefix, SettingEnabledCategories, SettingDefaultCategory, SettingReplyStyle, SettingEphemeralErrors, SettingLanguage, SettingWeightedSelection} {
+		if err := s.store.DeleteGuildSetting(ctx, guildID, key); err != nil {
+			return NewError(ErrCodeStorage, err)
+		}
+	}
+
+	logger.Logger.Info("Guild settings reset", zap.String("guild_id", guildID))
+	return nil
+}
+
+// normalize checks a setting value and returns the form it is stored in. Invalid values
+// get an i18n.Error, so users see why in their language.
+func (s *SettingsService) normalize(key, value string) (string, error) {
+	value = strings.TrimSpace(value)
+
+	switch key {
+	case SettingPrefix:
+		// Several prefixes may be given separated by spaces, e.g. "! w!"
+		prefixes := strings.Fields(value)
+		if len(prefixes) == 0 || len(prefixes) > maxPrefixes {
+			return "", i18n.Errorf("config.error.prefix_count", maxPrefixes)
+		}
+		for _, prefix := range prefixes {
+			if len(prefix) > maxPrefixLength {
+				return "", i18n.Errorf("config.error.prefix_length", prefix, maxPrefixLength)
+			}
+		}
+		return strings.Join(prefixes, " "), nil
+
+	case SettingEnabledCategories:
+		if value == "" || strings.EqualFold(value, "all") {
+			return "", nil
+		}
+		categories := splitList(value)
+		for _, category := range categories {
+			if !s.images.HasCategory(category) {
+				return "", i18n.Errorf("config.error.category", category)
+			}
+		}
+		return strings.Join(categories, ","), nil
+
+	case SettingDefaultCategory:
+		if value != "" && !s.images.HasCategory(value) {
+			return "", i18n.Errorf("config.error.category", value)
+		}
+		return value, nil
+
+	case SettingReplyStyle:
+		switch ReplyStyle(value) {
+		case ReplyStylePlain, ReplyStyleReply:
+			return value, nil
+		}
+		return "", i18n.Errorf("config.error.reply_style", ReplyStylePlain, ReplyStyleReply)
+
+	case SettingEphemeralErrors:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", i18n.Errorf("config.error.ephemeral_errors")
+		}
+		return strconv.FormatBool(b), nil
+
+	case SettingWeightedSelection:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", i18n.Errorf("config.error.weighted_selection")
+		}
+		return strconv.FormatBool(b), nil
+
+	case SettingLanguage:
+		value = strings.ToLower(value)
+		if value == "" || value == LanguageAuto {
+			return "", nil
+		}
+		if !i18n.Supported(value) {
+			return "", i18n.Errorf("config.error.language", LanguageAuto, strings.Join(i18n.Languages(), ", "))
+		}
+		return value, nil
+	}
+
+	return "", i18n.Errorf("config.error.unknown", key)
+}
+
+// splitList parses a comma separated list, dropping blanks and duplicates.
+func splitList(value string) []string {
+	seen := make(map[string]bool)
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" || seen[item] {
+			continue
+		}
+		seen[item] = true
+		items = append(items, item)
+	}
+	return items
+}
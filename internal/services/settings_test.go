@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"wooper-bot/internal/i18n"
+	"wooper-bot/internal/storage"
+)
+
+// setupTestSettings creates a settings service over test images and a temporary database.
+func setupTestSettings(t *testing.T) *SettingsService {
+	testDir := setupTestImages(t)
+	images, err := NewImageService(testDir)
+	if err != nil {
+		t.Fatalf("Failed to create image service: %v", err)
+	}
+
+	store, err := storage.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	t.Cleanup(func() {
+		store.Close()
+	})
+
+	return NewSettingsService(store, images)
+}
+
+// TestSettingsService_Defaults tests that unset guilds and DMs get the default settings.
+func TestSettingsService_Defaults(t *testing.T) {
+	settings := setupTestSettings(t)
+
+	for _, guildID := range []string{"", "unknown-guild"} {
+		got, err := settings.Get(context.Background(), guildID)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(got, DefaultGuildSettings()) {
+			t.Errorf("Expected defaults for %q, got %+v", guildID, got)
+		}
+	}
+}
+
+// TestSettingsService_Set tests validation and normalization of each setting.
+func TestSettingsService_Set(t *testing.T) {
+	settings := setupTestSettings(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		key         string
+		value       string
+		expectError bool
+	}{
+		{name: "prefixes", key: SettingPrefix, value: " w!  ? "},
+		{name: "empty prefix", key: SettingPrefix, value: "  ", expectError: true},
+		{name: "prefix too long", key: SettingPrefix, value: "wooper!", expectError: true},
+		{name: "too many prefixes", key: SettingPrefix, value: "a b c d e f", expectError: true},
+		{name: "categories", key: SettingEnabledCategories, value: "wooper, cats, wooper"},
+		{name: "unknown category", key: SettingEnabledCategories, value: "wooper,birds", expectError: true},
+		{name: "default category", key: SettingDefaultCategory, value: "dogs"},
+		{name: "unknown default category", key: SettingDefaultCategory, value: "birds", expectError: true},
+		{name: "reply style", key: SettingReplyStyle, value: "reply"},
+		{name: "bad reply style", key: SettingReplyStyle, value: "thread", expectError: true},
+		{name: "ephemeral errors", key: SettingEphemeralErrors, value: "true"},
+		{name: "bad ephemeral errors", key: SettingEphemeralErrors, value: "maybe", expectError: true},
+		{name: "language", key: SettingLanguage, value: "FR"},
+		{name: "unsupported language", key: SettingLanguage, value: "klingon", expectError: true},
+		{name: "weighted selection", key: SettingWeightedSelection, value: "TRUE"},
+		{name: "bad weighted selection", key: SettingWeightedSelection, value: "often", expectError: true},
+		{name: "unknown key", key: "color", value: "blue", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := settings.Set(ctx, "g1", tt.key, tt.value)
+			if tt.expectError && err == nil {
+				t.Errorf("Expected error but got none")
+			}
+			// Users see why in their language
+			var localized *i18n.Error
+			if tt.expectError && !errors.As(err, &localized) {
+				t.Errorf("Expected an i18n error, got %v", err)
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		})
+	}
+
+	got, err := settings.Get(ctx, "g1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := GuildSettings{
+		Prefixes:          []string{"w!", "?"},
+		EnabledCategories: []string{"wooper", "cats"},
+		DefaultCategory:   "dogs",
+		ReplyStyle:        ReplyStyleReply,
+		EphemeralErrors:   true,
+		Language:          "fr",
+		WeightedSelection: true,
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, got)
+	}
+
+	// "all" clears the category filter
+	if err := settings.Set(ctx, "g1", SettingEnabledCategories, "all"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got, _ = settings.Get(ctx, "g1")
+	if len(got.EnabledCategories) != 0 {
+		t.Errorf("Expected every category enabled, got %v", got.EnabledCategories)
+	}
+
+	if err := settings.Reset(ctx, "g1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got, _ = settings.Get(ctx, "g1")
+	if !reflect.DeepEqual(got, DefaultGuildSettings()) {
+		t.Errorf("Expected defaults after reset, got %+v", got)
+	}
+}
+
+// TestGuildSettings_CategoryEnabled tests category filtering.
+func TestGuildSettings_CategoryEnabled(t *testing.T) {
+	all := GuildSettings{}
+	if !all.CategoryEnabled("cats") {
+		t.Errorf("Expected every category enabled without a filter")
+	}
+
+	filtered := GuildSettings{EnabledCategories: []string{"wooper"}}
+	if !filtered.CategoryEnabled("wooper") || filtered.CategoryEnabled("cats") {
+		t.Errorf("Expected only wooper enabled")
+	}
+
+	got := filtered.FilterCategories([]string{"cats", "wooper", "dogs"})
+	if !reflect.DeepEqual(got, []string{"wooper"}) {
+		t.Errorf("Expected [wooper], got %v", got)
+	}
+
+	denied := GuildSettings{DeniedCategories: []string{"cats"}}
+	if denied.CategoryEnabled("cats") || !denied.CategoryEnabled("wooper") {
+		t.Errorf("Expected denied categories to be disabled")
+	}
+}
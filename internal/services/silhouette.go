@@ -0,0 +1,123 @@
+package services
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // decoders for image.Decode
+	_ "image/jpeg"
+	"image/png"
+	"io"
+)
+
+const (
+	// maxSilhouetteSize is the longest side of rendered silhouettes, in pixels.
+	maxSilhouetteSize = 512
+	// backgroundThreshold is how far, summed over the color channels, a pixel must be from the
+	// background color to belong to the subject of an opaque image.
+	backgroundThreshold = 3 * 32
+	// alphaThreshold is the opacity from which a pixel of a transparent image is the subject.
+	alphaThreshold = 0x8000
+)
+
+var (
+	silhouetteColor  = color.NRGBA{R: 0x10, G: 0x10, B: 0x10, A: 0xff}
+	silhouetteBehind = color.NRGBA{R: 0xf5, G: 0xf5, B: 0xf5, A: 0xff}
+)
+
+// RenderSilhouette decodes a PNG, JPEG or GIF image and writes its silhouette as a PNG.
+func RenderSilhouette(r io.Reader, w io.Writer) error {
+	src, _, err := image.Decode(r)
+	if err != nil {
+		return fmt.Errorf("decode image: %w", err)
+	}
+	if err := png.Encode(w, Silhouette(src)); err != nil {
+		return fmt.Errorf("encode silhouette: %w", err)
+	}
+	return nil
+}
+
+// Silhouette masks the subject of an image in a dark color over a light background, scaled
+// down to maxSilhouetteSize. Images with transparency are masked by opacity. Opaque images are
+// masked by distance to the background color, taken as the average color of their border.
+func Silhouette(src image.Image) *image.NRGBA {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	scale := 1.0
+	if longest := max(width, height); longest > maxSilhouetteSize {
+		scale = float64(longest) / maxSilhouetteSize
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, max(int(float64(width)/scale), 1), max(int(float64(height)/scale), 1)))
+
+	transparent := hasTransparency(src)
+	background := borderColor(src)
+	for y := 0; y < dst.Rect.Dy(); y++ {
+		for x := 0; x < dst.Rect.Dx(); x++ {
+			// Nearest neighbour scaling is enough for a two-color image
+			c := src.At(bounds.Min.X+int(float64(x)*scale), bounds.Min.Y+int(float64(y)*scale))
+			subject := false
+			if transparent {
+				_, _, _, a := c.RGBA()
+				subject = a >= alphaThreshold
+			} else {
+				subject = colorDistance(c, background) > backgroundThreshold
+			}
+			if subject {
+				dst.SetNRGBA(x, y, silhouetteColor)
+			} else {
+				dst.SetNRGBA(x, y, silhouetteBehind)
+			}
+		}
+	}
+	return dst
+}
+
+// hasTransparency reports whether any border pixel is mostly transparent, as with cut out images.
+func hasTransparency(src image.Image) bool {
+	transparent := false
+	eachBorderPixel(src, func(c color.Color) {
+		if _, _, _, a := c.RGBA(); a < alphaThreshold {
+			transparent = true
+		}
+	})
+	return transparent
+}
+
+// borderColor averages the colors of the border pixels of an image, 8 bits per channel.
+func borderColor(src image.Image) color.RGBA {
+	var r, g, b, n uint64
+	eachBorderPixel(src, func(c color.Color) {
+		cr, cg, cb, _ := c.RGBA()
+		r, g, b, n = r+uint64(cr>>8), g+uint64(cg>>8), b+uint64(cb>>8), n+1
+	})
+	if n == 0 {
+		return color.RGBA{A: 0xff}
+	}
+	return color.RGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(b / n), A: 0xff}
+}
+
+// eachBorderPixel calls fn with every pixel on the edges of an image.
+func eachBorderPixel(src image.Image, fn func(color.Color)) {
+	b := src.Bounds()
+	for x := b.Min.X; x < b.Max.X; x++ {
+		fn(src.At(x, b.Min.Y))
+		fn(src.At(x, b.Max.Y-1))
+	}
+	for y := b.Min.Y + 1; y < b.Max.Y-1; y++ {
+		fn(src.At(b.Min.X, y))
+		fn(src.At(b.Max.X-1, y))
+	}
+}
+
+// colorDistance sums the differences of the 8 bit channels of two colors.
+func colorDistance(c color.Color, background color.RGBA) int {
+	r, g, b, _ := c.RGBA()
+	return abs(int(r>>8)-int(background.R)) + abs(int(g>>8)-int(background.G)) + abs(int(b>>8)-int(background.B))
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
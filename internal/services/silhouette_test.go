@@ -0,0 +1,72 @@
+package services
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+// square draws a w×h image of background with a foreground square in the middle.
+func square(w, h int, background, foreground color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := background
+			if x >= w/4 && x < 3*w/4 && y >= h/4 && y < 3*h/4 {
+				c = foreground
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// TestSilhouette tests masking opaque and transparent images.
+func TestSilhouette(t *testing.T) {
+	tests := []struct {
+		name string
+		src  image.Image
+	}{
+		{"opaque", square(40, 20, color.NRGBA{R: 0x30, G: 0x90, B: 0xe0, A: 0xff}, color.NRGBA{R: 0xf0, G: 0xd0, B: 0x20, A: 0xff})},
+		{"transparent", square(40, 20, color.NRGBA{}, color.NRGBA{R: 0x30, G: 0x90, B: 0xe0, A: 0xff})},
+		{"subject close to the background", square(40, 20, color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}, color.NRGBA{R: 0xd0, G: 0xd0, B: 0xd0, A: 0xff})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			silhouette := Silhouette(tt.src)
+			if silhouette.Bounds().Dx() != 40 || silhouette.Bounds().Dy() != 20 {
+				t.Fatalf("Expected the size to be kept, got %v", silhouette.Bounds())
+			}
+			if got := silhouette.NRGBAAt(20, 10); got != silhouetteColor {
+				t.Errorf("Expected the subject in the silhouette color, got %v", got)
+			}
+			if got := silhouette.NRGBAAt(1, 1); got != silhouetteBehind {
+				t.Errorf("Expected the background in the background color, got %v", got)
+			}
+		})
+	}
+}
+
+// TestRenderSilhouette tests scaling large images down and rejecting non-images.
+func TestRenderSilhouette(t *testing.T) {
+	var src, dst bytes.Buffer
+	if err := png.Encode(&src, square(2*maxSilhouetteSize, maxSilhouetteSize, color.White, color.Black)); err != nil {
+		t.Fatalf("Failed to encode test image: %v", err)
+	}
+	if err := RenderSilhouette(&src, &dst); err != nil {
+		t.Fatalf("Failed to render silhouette: %v", err)
+	}
+	rendered, err := png.Decode(&dst)
+	if err != nil {
+		t.Fatalf("Expected a PNG: %v", err)
+	}
+	if b := rendered.Bounds(); b.Dx() != maxSilhouetteSize || b.Dy() != maxSilhouetteSize/2 {
+		t.Errorf("Expected the image to be scaled to %d pixels wide, got %v", maxSilhouetteSize, b)
+	}
+
+	if err := RenderSilhouette(bytes.NewReader([]byte("not an image")), &dst); err == nil {
+		t.Errorf("Expected an error for data that isn't an image")
+	}
+}
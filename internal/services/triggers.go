@@ -0,0 +1,80 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+
+	"wooper-bot/internal/i18n"
+	"wooper-bot/internal/storage"
+)
+
+// MaxTriggerPatternLength bounds trigger patterns, so matching every message stays cheap.
+const MaxTriggerPatternLength = 100
+
+// TriggerMatcher reports whether a chat message sets a trigger off.
+type TriggerMatcher func(content string) bool
+
+// CompileTrigger builds the matcher for a trigger pattern. Matching ignores case; regular
+// expressions use RE2 syntax, which runs in linear time whatever the pattern.
+func CompileTrigger(match storage.TriggerMatch, pattern string) (TriggerMatcher, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" || len(pattern) > MaxTriggerPatternLength {
+		return nil, i18n.Errorf("trigger.error.length", MaxTriggerPatternLength)
+	}
+
+	switch match {
+	case storage.TriggerKeyword:
+		keyword := strings.ToLower(pattern)
+		return func(content string) bool {
+			return strings.Contains(strings.ToLower(content), keyword)
+		}, nil
+	case storage.TriggerWord:
+		phrase := words(pattern)
+		if len(phrase) == 0 {
+			return nil, i18n.Errorf("trigger.error.no_words")
+		}
+		return func(content string) bool {
+			return containsPhrase(words(content), phrase)
+		}, nil
+	case storage.TriggerRegex:
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			// Point at the offending part, the parser's own message is English only
+			near := pattern
+			var syntaxErr *syntax.Error
+			if errors.As(err, &syntaxErr) && strings.TrimPrefix(syntaxErr.Expr, "(?i)") != "" {
+				near = strings.TrimPrefix(syntaxErr.Expr, "(?i)")
+			}
+			return nil, i18n.Errorf("trigger.error.regex", near)
+		}
+		return re.MatchString, nil
+	}
+	return nil, i18n.Errorf("trigger.error.match", match)
+}
+
+// words splits s into lowercase words of letters and digits.
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// containsPhrase reports whether phrase appears as consecutive words of text.
+func containsPhrase(text, phrase []string) bool {
+	for i := 0; i+len(phrase) <= len(text); i++ {
+		match := true
+		for j, word := range phrase {
+			if text[i+j] != word {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
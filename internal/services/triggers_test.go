@@ -0,0 +1,73 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"wooper-bot/internal/i18n"
+	"wooper-bot/internal/storage"
+)
+
+// TestCompileTrigger tests the keyword, whole word and regex matchers.
+func TestCompileTrigger(t *testing.T) {
+	tests := []struct {
+		name    string
+		match   storage.TriggerMatch
+		pattern string
+		content string
+		want    bool
+	}{
+		{"keyword", storage.TriggerKeyword, "wooper", "I love WOOPERS", true},
+		{"keyword missing", storage.TriggerKeyword, "wooper", "I love quagsire", false},
+		{"word", storage.TriggerWord, "wooper", "look, a Wooper!", true},
+		{"word inside another", storage.TriggerWord, "wooper", "I love woopers", false},
+		{"phrase", storage.TriggerWord, "good night", "Good  night, everyone", true},
+		{"phrase apart", storage.TriggerWord, "good night", "good evening and night", false},
+		{"accented word", storage.TriggerWord, "pokémon", "mon Pokémon préféré", true},
+		{"regex", storage.TriggerRegex, `^qu+a+g`, "Quuuaaag!", true},
+		{"regex no match", storage.TriggerRegex, `^qu+a+g`, "a quag", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := CompileTrigger(tt.match, tt.pattern)
+			if err != nil {
+				t.Fatalf("Failed to compile %q: %v", tt.pattern, err)
+			}
+			if got := matcher(tt.content); got != tt.want {
+				t.Errorf("%q matching %q = %v, want %v", tt.pattern, tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCompileTrigger_Invalid tests that unusable patterns are rejected.
+func TestCompileTrigger_Invalid(t *testing.T) {
+	tests := []struct {
+		match   storage.TriggerMatch
+		pattern string
+	}{
+		{storage.TriggerKeyword, "  "},
+		{storage.TriggerKeyword, strings.Repeat("a", MaxTriggerPatternLength+1)},
+		{storage.TriggerWord, "!!!"},
+		{storage.TriggerRegex, "(unclosed"},
+		{"other", "wooper"},
+	}
+	for _, tt := range tests {
+		_, err := CompileTrigger(tt.match, tt.pattern)
+		if err == nil {
+			t.Errorf("Expected %s pattern %q to be rejected", tt.match, tt.pattern)
+			continue
+		}
+		// Users see why in their language
+		var localized *i18n.Error
+		if !errors.As(err, &localized) {
+			t.Errorf("Expected an i18n error for %s pattern %q, got %v", tt.match, tt.pattern, err)
+		}
+	}
+
+	_, err := CompileTrigger(storage.TriggerRegex, "(unclosed")
+	if got := i18n.T("fr", "trigger.invalid_pattern", err); got != "Ce motif ne peut pas être utilisé : expression régulière invalide près de `(unclosed`" {
+		t.Errorf("Unexpected French message %q", got)
+	}
+}
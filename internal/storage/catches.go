@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Catch is an image in a user's catch game inventory.
+type Catch struct {
+	ImageID       string
+	Category      string
+	Rarity        int
+	Count         int // how many times the user caught it
+	FirstCaughtAt time.Time
+	LastCaughtAt  time.Time // zero for images only received in trades
+}
+
+// AddCatch adds an image to a user's inventory, restarts the user's catch cooldown and returns
+// how many times the user caught the image.
+func (s *Store) AddCatch(ctx context.Context, userID, imageID, category string, rarity int) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin catch: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	var count int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO catches (user_id, image_id, category, rarity, count, first_caught_at, last_caught_at)
+		VALUES (?, ?, ?, ?, 1, ?, ?)
+		ON CONFLICT (user_id, image_id) DO UPDATE SET count = count + 1, last_caught_at = excluded.last_caught_at
+		RETURNING count`,
+		userID, imageID, category, rarity, now, now).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("add catch: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO catch_cooldowns (user_id, last_caught_at) VALUES (?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET last_caught_at = excluded.last_caught_at`,
+		userID, now)
+	if err != nil {
+		return 0, fmt.Errorf("record catch time: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit catch: %w", err)
+	}
+	return count, nil
+}
+
+// LastCatch returns when a user last caught an image, or the zero time if never. Trading the
+// image away doesn't change it.
+func (s *Store) LastCatch(ctx context.Context, userID string) (time.Time, error) {
+	var last int64
+	err := s.db.QueryRowContext(ctx,
+		`SELECT last_caught_at FROM catch_cooldowns WHERE user_id = ?`, userID).Scan(&last)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("get last catch: %w", err)
+	}
+	return time.Unix(last, 0), nil
+}
+
+// Catches returns a page of a user's inventory, rarest first, then by category and image ID.
+func (s *Store) Catches(ctx context.Context, userID string, limit, offset int) ([]Catch, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT image_id, category, rarity, count, first_caught_at, last_caught_at FROM catches
+		WHERE user_id = ? ORDER BY rarity DESC, category, image_id LIMIT ? OFFSET ?`,
+		userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("list catches: %w", err)
+	}
+	defer rows.Close()
+
+	var catches []Catch
+	for rows.Next() {
+		var c Catch
+		var first, last int64
+		if err := rows.Scan(&c.ImageID, &c.Category, &c.Rarity, &c.Count, &first, &last); err != nil {
+			return nil, fmt.Errorf("scan catch: %w", err)
+		}
+		c.FirstCaughtAt = time.Unix(first, 0)
+		if last != 0 {
+			c.LastCaughtAt = time.Unix(last, 0)
+		}
+		catches = append(catches, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list catches: %w", err)
+	}
+	return catches, nil
+}
+
+// CatchOf returns an image of a user's inventory and whether the user has it.
+func (s *Store) CatchOf(ctx context.Context, userID, imageID string) (Catch, bool, error) {
+	c := Catch{ImageID: imageID}
+	var first, last int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT category, rarity, count, first_caught_at, last_caught_at FROM catches
+		WHERE user_id = ? AND image_id = ?`, userID, imageID).Scan(&c.Category, &c.Rarity, &c.Count, &first, &last)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Catch{}, false, nil
+	}
+	if err != nil {
+		return Catch{}, false, fmt.Errorf("get catch: %w", err)
+	}
+	c.FirstCaughtAt = time.Unix(first, 0)
+	if last != 0 {
+		c.LastCaughtAt = time.Unix(last, 0)
+	}
+	return c, true, nil
+}
+
+// CatchCounts returns how many different images a user caught in each category.
+func (s *Store) CatchCounts(ctx context.Context, userID string) (map[string]int, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT category, COUNT(*) FROM catches WHERE user_id = ? GROUP BY category`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("count catches: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var category string
+		var count int
+		if err := rows.Scan(&category, &count); err != nil {
+			return nil, fmt.Errorf("scan catch count: %w", err)
+		}
+		counts[category] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("count catches: %w", err)
+	}
+	return counts, nil
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestStore_AddCatch tests that catching an image again counts a duplicate.
+func TestStore_AddCatch(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		userID   string
+		imageID  string
+		expected int
+	}{
+		{name: "new catch", userID: "u1", imageID: "a1", expected: 1},
+		{name: "duplicate", userID: "u1", imageID: "a1", expected: 2},
+		{name: "another user", userID: "u2", imageID: "a1", expected: 1},
+		{name: "third time", userID: "u1", imageID: "a1", expected: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, err := store.AddCatch(ctx, tt.userID, tt.imageID, "wooper", 0)
+			if err != nil {
+				t.Fatalf("Failed to add catch: %v", err)
+			}
+			if count != tt.expected {
+				t.Errorf("Expected count %d, got %d", tt.expected, count)
+			}
+		})
+	}
+}
+
+// TestStore_Catches tests the inventory order, the counts per category and the last catch time.
+func TestStore_Catches(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	last, err := store.LastCatch(ctx, "u1")
+	if err != nil || !last.IsZero() {
+		t.Fatalf("Expected no last catch, got %v (%v)", last, err)
+	}
+
+	catches := []struct {
+		imageID, category string
+		rarity            int
+	}{
+		{"c1", "wooper", 0},
+		{"c2", "cats", 3},
+		{"c3", "cats", 0},
+		{"c4", "wooper", 1},
+		{"c1", "wooper", 0},
+	}
+	for _, c := range catches {
+		if _, err := store.AddCatch(ctx, "u1", c.imageID, c.category, c.rarity); err != nil {
+			t.Fatalf("Failed to add catch: %v", err)
+		}
+	}
+
+	inventory, err := store.Catches(ctx, "u1", 3, 0)
+	if err != nil {
+		t.Fatalf("Failed to list catches: %v", err)
+	}
+	var ids []string
+	for _, c := range inventory {
+		ids = append(ids, c.ImageID)
+	}
+	if len(ids) != 3 || ids[0] != "c2" || ids[1] != "c4" || ids[2] != "c3" {
+		t.Errorf("Expected the rarest catches first, got %v", ids)
+	}
+	if page, _ := store.Catches(ctx, "u1", 3, 3); len(page) != 1 || page[0].ImageID != "c1" || page[0].Count != 2 {
+		t.Errorf("Expected c1 caught twice on the second page, got %+v", page)
+	}
+
+	counts, err := store.CatchCounts(ctx, "u1")
+	if err != nil {
+		t.Fatalf("Failed to count catches: %v", err)
+	}
+	if counts["wooper"] != 2 || counts["cats"] != 2 || len(counts) != 2 {
+		t.Errorf("Unexpected counts %v", counts)
+	}
+
+	if last, _ = store.LastCatch(ctx, "u1"); time.Since(last) > time.Minute {
+		t.Errorf("Expected a recent last catch, got %v", last)
+	}
+}
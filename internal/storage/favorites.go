@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Favorite is an image a user saved.
+type Favorite struct {
+	ImageID   string
+	Category  string
+	CreatedAt time.Time
+}
+
+// AddFavorite adds an image to a user's favorites. It reports false when it was already one.
+func (s *Store) AddFavorite(ctx context.Context, userID, imageID, category string) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO favorites (user_id, image_id, category, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id, image_id) DO NOTHING`,
+		userID, imageID, category, time.Now().Unix())
+	if err != nil {
+		return false, fmt.Errorf("add favorite: %w", err)
+	}
+	added, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("add favorite: %w", err)
+	}
+	return added > 0, nil
+}
+
+// RemoveFavorite removes an image from a user's favorites. It reports false when it wasn't one.
+func (s *Store) RemoveFavorite(ctx context.Context, userID, imageID string) (bool, error) {
+	result, err := s.db.ExecContext(ctx,
+		`DELETE FROM favorites WHERE user_id = ? AND image_id = ?`, userID, imageID)
+	if err != nil {
+		return false, fmt.Errorf("remove favorite: %w", err)
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("remove favorite: %w", err)
+	}
+	return removed > 0, nil
+}
+
+// Favorites returns up to limit of a user's favorites, newest first, skipping the first offset.
+func (s *Store) Favorites(ctx context.Context, userID string, limit, offset int) ([]Favorite, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT image_id, category, created_at FROM favorites
+		WHERE user_id = ?
+		ORDER BY created_at DESC, image_id
+		LIMIT ? OFFSET ?`,
+		userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("list favorites: %w", err)
+	}
+	defer rows.Close()
+
+	var favorites []Favorite
+	for rows.Next() {
+		var f Favorite
+		var createdAt int64
+		if err := rows.Scan(&f.ImageID, &f.Category, &createdAt); err != nil {
+			return nil, fmt.Errorf("scan favorite: %w", err)
+		}
+		f.CreatedAt = time.Unix(createdAt, 0)
+		favorites = append(favorites, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list favorites: %w", err)
+	}
+	return favorites, nil
+}
+
+// FavoriteCount returns how many favorites a user has.
+func (s *Store) FavoriteCount(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM favorites WHERE user_id = ?`, userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count favorites: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"testing"
+)
+
+// TestStore_AddFavorite tests that favorites are kept once per user and image.
+func TestStore_AddFavorite(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		userID   string
+		imageID  string
+		expected bool
+	}{
+		{name: "new favorite", userID: "u1", imageID: "a1", expected: true},
+		{name: "same image again", userID: "u1", imageID: "a1", expected: false},
+		{name: "another user", userID: "u2", imageID: "a1", expected: true},
+		{name: "another image", userID: "u1", imageID: "b2", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, err := store.AddFavorite(ctx, tt.userID, tt.imageID, "wooper")
+			if err != nil {
+				t.Fatalf("Failed to add favorite: %v", err)
+			}
+			if added != tt.expected {
+				t.Errorf("Expected added=%t, got %t", tt.expected, added)
+			}
+		})
+	}
+}
+
+// TestStore_Favorites tests listing, counting and removing favorites.
+func TestStore_Favorites(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	for _, id := range []string{"c3", "a1", "b2"} {
+		if _, err := store.AddFavorite(ctx, "u1", id, "wooper"); err != nil {
+			t.Fatalf("Failed to add favorite: %v", err)
+		}
+	}
+	if _, err := store.AddFavorite(ctx, "u2", "d4", "cats"); err != nil {
+		t.Fatalf("Failed to add favorite: %v", err)
+	}
+
+	page, err := store.Favorites(ctx, "u1", 2, 1)
+	if err != nil {
+		t.Fatalf("Failed to list favorites: %v", err)
+	}
+	if len(page) != 2 || page[0].ImageID != "b2" || page[1].ImageID != "c3" || page[0].Category != "wooper" {
+		t.Errorf("Expected b2 and c3 on the second page, got %+v", page)
+	}
+
+	removed, err := store.RemoveFavorite(ctx, "u1", "a1")
+	if err != nil || !removed {
+		t.Fatalf("Expected a1 removed, got %t and %v", removed, err)
+	}
+	if removed, _ := store.RemoveFavorite(ctx, "u1", "d4"); removed {
+		t.Errorf("Expected another user's favorite to stay")
+	}
+
+	count, err := store.FavoriteCount(ctx, "u1")
+	if err != nil {
+		t.Fatalf("Failed to count favorites: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 favorites left, got %d", count)
+	}
+}
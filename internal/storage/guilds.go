@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// GuildSetting returns a single setting of a guild and whether it is set.
+func (s *Store) GuildSetting(ctx context.Context, guildID, key string) (string, bool, error) {
+	var value string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT value FROM guild_settings WHERE guild_id = ? AND key = ?`, guildID, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("get guild setting %s: %w", key, err)
+	}
+	return value, true, nil
+}
+
+// GuildSettings returns every setting stored for a guild.
+func (s *Store) GuildSettings(ctx context.Context, guildID string) (map[string]string, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT key, value FROM guild_settings WHERE guild_id = ?`, guildID)
+	if err != nil {
+		return nil, fmt.Errorf("list guild settings: %w", err)
+	}
+	defer rows.Close()
+
+	settings := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("scan guild setting: %w", err)
+		}
+		settings[key] = value
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list guild settings: %w", err)
+	}
+	return settings, nil
+}
+
+// SetGuildSetting creates or replaces a guild setting.
+func (s *Store) SetGuildSetting(ctx context.Context, guildID, key, value string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO guild_settings (guild_id, key, value, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (guild_id, key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+		guildID, key, value, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("set guild setting %s: %w", key, err)
+	}
+	return nil
+}
+
+// DeleteGuildSetting removes a guild setting so its default applies again.
+func (s *Store) DeleteGuildSetting(ctx context.Context, guildID, key string) error {
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM guild_settings WHERE guild_id = ? AND key = ?`, guildID, key)
+	if err != nil {
+		return fmt.Errorf("delete guild setting %s: %w", key, err)
+	}
+	return nil
+}
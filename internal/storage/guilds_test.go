@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"testing"
+)
+
+// TestStore_GuildSettings tests setting, reading, overwriting and deleting guild settings.
+func TestStore_GuildSettings(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	if _, ok, err := store.GuildSetting(ctx, "g1", "prefix"); err != nil || ok {
+		t.Fatalf("Expected unset setting, got ok=%v err=%v", ok, err)
+	}
+
+	if err := store.SetGuildSetting(ctx, "g1", "prefix", "?"); err != nil {
+		t.Fatalf("Failed to set setting: %v", err)
+	}
+	if err := store.SetGuildSetting(ctx, "g1", "prefix", "$"); err != nil {
+		t.Fatalf("Failed to overwrite setting: %v", err)
+	}
+	if err := store.SetGuildSetting(ctx, "g2", "prefix", "%"); err != nil {
+		t.Fatalf("Failed to set setting: %v", err)
+	}
+
+	value, ok, err := store.GuildSetting(ctx, "g1", "prefix")
+	if err != nil || !ok || value != "$" {
+		t.Errorf("Expected $, got %q ok=%v err=%v", value, ok, err)
+	}
+
+	settings, err := store.GuildSettings(ctx, "g1")
+	if err != nil {
+		t.Fatalf("Failed to list settings: %v", err)
+	}
+	if len(settings) != 1 || settings["prefix"] != "$" {
+		t.Errorf("Unexpected settings: %v", settings)
+	}
+
+	if err := store.DeleteGuildSetting(ctx, "g1", "prefix"); err != nil {
+		t.Fatalf("Failed to delete setting: %v", err)
+	}
+	if _, ok, _ := store.GuildSetting(ctx, "g1", "prefix"); ok {
+		t.Errorf("Expected setting to be deleted")
+	}
+}
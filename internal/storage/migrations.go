@@ -0,0 +1,245 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"wooper-bot/internal/logger"
+
+	"go.uber.org/zap"
+)
+
+// migrations are applied in order and recorded in schema_migrations. Never edit a
+// released migration; append a new one instead.
+var migrations = []string{
+	// 1: guild settings, usage history and per-user data
+	`
+	CREATE TABLE guild_settings (
+		guild_id   TEXT NOT NULL,
+		key        TEXT NOT NULL,
+		value      TEXT NOT NULL,
+		updated_at INTEGER NOT NULL,
+		PRIMARY KEY (guild_id, key)
+	);
+
+	CREATE TABLE usage_history (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id   TEXT NOT NULL,
+		channel_id TEXT NOT NULL,
+		user_id    TEXT NOT NULL,
+		command    TEXT NOT NULL,
+		category   TEXT NOT NULL,
+		image      TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX usage_history_guild ON usage_history (guild_id, created_at);
+	CREATE INDEX usage_history_user ON usage_history (user_id, created_at);
+
+	CREATE TABLE user_data (
+		user_id    TEXT NOT NULL,
+		key        TEXT NOT NULL,
+		value      TEXT NOT NULL,
+		updated_at INTEGER NOT NULL,
+		PRIMARY KEY (user_id, key)
+	);
+	`,
+	// 2: favorite images
+	`
+	CREATE TABLE favorites (
+		user_id    TEXT NOT NULL,
+		image_id   TEXT NOT NULL,
+		category   TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		PRIMARY KEY (user_id, image_id)
+	);
+	`,
+	// 3: image ratings from reactions
+	`
+	CREATE TABLE image_votes (
+		image_id   TEXT NOT NULL,
+		user_id    TEXT NOT NULL,
+		category   TEXT NOT NULL,
+		message_id TEXT NOT NULL,
+		vote       INTEGER NOT NULL,
+		created_at INTEGER NOT NULL,
+		PRIMARY KEY (image_id, user_id)
+	);
+	CREATE INDEX image_votes_category ON image_votes (category);
+	CREATE INDEX image_votes_message ON image_votes (message_id, user_id);
+	`,
+	// 4: usage statistics
+	`
+	ALTER TABLE usage_history ADD COLUMN image_id TEXT NOT NULL DEFAULT '';
+	ALTER TABLE usage_history ADD COLUMN transport TEXT NOT NULL DEFAULT '';
+	ALTER TABLE usage_history ADD COLUMN latency_ms INTEGER NOT NULL DEFAULT 0;
+	CREATE INDEX usage_history_category ON usage_history (category, created_at);
+	`,
+	// 5: catch game inventories
+	`
+	CREATE TABLE catches (
+		user_id         TEXT NOT NULL,
+		image_id        TEXT NOT NULL,
+		category        TEXT NOT NULL,
+		rarity          INTEGER NOT NULL,
+		count           INTEGER NOT NULL,
+		first_caught_at INTEGER NOT NULL,
+		last_caught_at  INTEGER NOT NULL,
+		PRIMARY KEY (user_id, image_id)
+	);
+	CREATE INDEX catches_last_caught ON catches (user_id, last_caught_at);
+	`,
+	// 6: trades of caught images, kept after they close as an audit trail
+	`
+	CREATE TABLE trades (
+		id           INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id     TEXT NOT NULL,
+		from_user_id TEXT NOT NULL,
+		to_user_id   TEXT NOT NULL,
+		give_id      TEXT NOT NULL,
+		want_id      TEXT NOT NULL,
+		status       TEXT NOT NULL,
+		created_at   INTEGER NOT NULL,
+		expires_at   INTEGER NOT NULL,
+		resolved_at  INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX trades_status ON trades (status, expires_at);
+	CREATE INDEX trades_from_user ON trades (from_user_id, created_at);
+	CREATE INDEX trades_to_user ON trades (to_user_id, created_at);
+	`,
+	// 7: silhouette quiz scores
+	`
+	CREATE TABLE quiz_scores (
+		guild_id   TEXT NOT NULL,
+		user_id    TEXT NOT NULL,
+		points     INTEGER NOT NULL,
+		wins       INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL,
+		PRIMARY KEY (guild_id, user_id)
+	);
+	CREATE INDEX quiz_scores_points ON quiz_scores (guild_id, points);
+	`,
+	// 8: keyword triggers
+	`
+	CREATE TABLE triggers (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id   TEXT NOT NULL,
+		match      TEXT NOT NULL,
+		pattern    TEXT NOT NULL,
+		action     TEXT NOT NULL,
+		value      TEXT NOT NULL,
+		cooldown   INTEGER NOT NULL,
+		created_by TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX triggers_guild ON triggers (guild_id);
+	`,
+	// 9: role and channel restrictions of commands and categories
+	`
+	CREATE TABLE permission_rules (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id   TEXT NOT NULL,
+		target     TEXT NOT NULL,
+		name       TEXT NOT NULL,
+		role_id    TEXT NOT NULL,
+		channel_id TEXT NOT NULL,
+		created_by TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX permission_rules_guild ON permission_rules (guild_id);
+	`,
+	// 10: catch cooldowns, kept apart from inventories so trading an image away keeps them
+	`
+	CREATE TABLE catch_cooldowns (
+		user_id        TEXT PRIMARY KEY,
+		last_caught_at INTEGER NOT NULL
+	);
+	INSERT INTO catch_cooldowns (user_id, last_caught_at)
+		SELECT user_id, MAX(last_caught_at) FROM catches WHERE last_caught_at > 0 GROUP BY user_id;
+	`,
+	// 11: daily posts and cron jobs, previously kept in a JSON file by the scheduler
+	`
+	CREATE TABLE daily_posts (
+		guild_id      TEXT PRIMARY KEY,
+		channel_id    TEXT NOT NULL,
+		category      TEXT NOT NULL,
+		post_time     TEXT NOT NULL,
+		timezone      TEXT NOT NULL,
+		repeat_window INTEGER NOT NULL,
+		recent        TEXT NOT NULL,
+		next_run      INTEGER NOT NULL,
+		last_run      INTEGER NOT NULL
+	);
+
+	CREATE TABLE scheduled_jobs (
+		id         INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id   TEXT NOT NULL,
+		channel_id TEXT NOT NULL,
+		category   TEXT NOT NULL,
+		spec       TEXT NOT NULL,
+		timezone   TEXT NOT NULL,
+		catch_up   TEXT NOT NULL,
+		created_by TEXT NOT NULL,
+		next_run   INTEGER NOT NULL,
+		last_run   INTEGER NOT NULL
+	);
+	CREATE INDEX scheduled_jobs_guild ON scheduled_jobs (guild_id);
+	`,
+	// 12: the image each of the bot's image messages shows, so votes need no message fetch
+	`
+	CREATE TABLE image_posts (
+		message_id TEXT PRIMARY KEY,
+		image_id   TEXT NOT NULL,
+		category   TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+	`,
+}
+
+// migrate applies every migration newer than the database's current version.
+func (s *Store) migrate(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			applied_at INTEGER NOT NULL
+		)`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	var current int
+	err = s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current)
+	if err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+
+	for i := current; i < len(migrations); i++ {
+		version := i + 1
+		if err := s.applyMigration(ctx, version, migrations[i]); err != nil {
+			return err
+		}
+		logger.Logger.Info("Applied database migration", zap.Int("version", version))
+	}
+	return nil
+}
+
+func (s *Store) applyMigration(ctx context.Context, version int, stmt string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin migration %d: %w", version, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, stmt); err != nil {
+		return fmt.Errorf("apply migration %d: %w", version, err)
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`,
+		version, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("record migration %d: %w", version, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit migration %d: %w", version, err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// PermissionTarget is what a permission rule restricts.
+type PermissionTarget string
+
+const (
+	PermissionCommand  PermissionTarget = "command"
+	PermissionCategory PermissionTarget = "category"
+)
+
+// PermissionRule allows a command or an image category for members with a role, in a
+// channel, or both. Once a command or category has rules, only they allow it.
+type PermissionRule struct {
+	ID        int64
+	GuildID   string
+	Target    PermissionTarget
+	Name      string // command or category name
+	RoleID    string // empty for any role
+	ChannelID string // empty for any channel
+	CreatedBy string
+	CreatedAt time.Time
+}
+
+// AddPermissionRule saves a rule and returns it with its ID.
+func (s *Store) AddPermissionRule(ctx context.Context, rule PermissionRule) (PermissionRule, error) {
+	rule.CreatedAt = time.Now()
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO permission_rules (guild_id, target, name, role_id, channel_id, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		rule.GuildID, rule.Target, rule.Name, rule.RoleID, rule.ChannelID, rule.CreatedBy, rule.CreatedAt.Unix())
+	if err != nil {
+		return PermissionRule{}, fmt.Errorf("add permission rule: %w", err)
+	}
+	if rule.ID, err = result.LastInsertId(); err != nil {
+		return PermissionRule{}, fmt.Errorf("add permission rule: %w", err)
+	}
+	return rule, nil
+}
+
+// RemovePermissionRule deletes a guild's rule. It reports false when the guild has no such rule.
+func (s *Store) RemovePermissionRule(ctx context.Context, guildID string, id int64) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM permission_rules WHERE guild_id = ? AND id = ?`, guildID, id)
+	if err != nil {
+		return false, fmt.Errorf("remove permission rule: %w", err)
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("remove permission rule: %w", err)
+	}
+	return removed > 0, nil
+}
+
+// PermissionRules returns a guild's rules, oldest first.
+func (s *Store) PermissionRules(ctx context.Context, guildID string) ([]PermissionRule, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, guild_id, target, name, role_id, channel_id, created_by, created_at
+		FROM permission_rules WHERE guild_id = ? ORDER BY id`, guildID)
+	if err != nil {
+		return nil, fmt.Errorf("list permission rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []PermissionRule
+	for rows.Next() {
+		var rule PermissionRule
+		var createdAt int64
+		err := rows.Scan(&rule.ID, &rule.GuildID, &rule.Target, &rule.Name,
+			&rule.RoleID, &rule.ChannelID, &rule.CreatedBy, &createdAt)
+		if err != nil {
+			return nil, fmt.Errorf("scan permission rule: %w", err)
+		}
+		rule.CreatedAt = time.Unix(createdAt, 0)
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list permission rules: %w", err)
+	}
+	return rules, nil
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"testing"
+)
+
+// TestStore_PermissionRules tests adding, listing and removing a guild's permission rules.
+func TestStore_PermissionRules(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	added := []PermissionRule{
+		{GuildID: "g1", Target: PermissionCommand, Name: "image", RoleID: "r1", ChannelID: "c1", CreatedBy: "u1"},
+		{GuildID: "g1", Target: PermissionCategory, Name: "cats", RoleID: "r2", CreatedBy: "u1"},
+		{GuildID: "g2", Target: PermissionCommand, Name: "quiz", ChannelID: "c2", CreatedBy: "u2"},
+	}
+	for i, rule := range added {
+		saved, err := store.AddPermissionRule(ctx, rule)
+		if err != nil {
+			t.Fatalf("Failed to add rule: %v", err)
+		}
+		if saved.ID == 0 || saved.CreatedAt.IsZero() {
+			t.Errorf("Expected an ID and creation time, got %+v", saved)
+		}
+		added[i] = saved
+	}
+
+	rules, err := store.PermissionRules(ctx, "g1")
+	if err != nil {
+		t.Fatalf("Failed to list rules: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("Expected 2 rules in g1, got %+v", rules)
+	}
+	for i, rule := range rules {
+		expected := added[i]
+		if rule.ID != expected.ID || rule.Target != expected.Target || rule.Name != expected.Name ||
+			rule.RoleID != expected.RoleID || rule.ChannelID != expected.ChannelID || rule.CreatedBy != expected.CreatedBy {
+			t.Errorf("Expected %+v, got %+v", expected, rule)
+		}
+	}
+
+	// A guild can't remove another guild's rule
+	if removed, err := store.RemovePermissionRule(ctx, "g1", added[2].ID); err != nil || removed {
+		t.Errorf("Expected g2's rule to stay, got %v, %v", removed, err)
+	}
+	if removed, err := store.RemovePermissionRule(ctx, "g1", added[0].ID); err != nil || !removed {
+		t.Errorf("Expected the rule to be removed, got %v, %v", removed, err)
+	}
+	if rules, _ := store.PermissionRules(ctx, "g1"); len(rules) != 1 || rules[0].ID != added[1].ID {
+		t.Errorf("Expected one rule left, got %+v", rules)
+	}
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// QuizScore is a user's quiz score in a guild.
+type QuizScore struct {
+	UserID string
+	Points int
+	Wins   int
+}
+
+// AddQuizWin adds a won quiz worth points to a user's score in a guild and returns the new total.
+func (s *Store) AddQuizWin(ctx context.Context, guildID, userID string, points int) (int, error) {
+	var total int
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO quiz_scores (guild_id, user_id, points, wins, updated_at) VALUES (?, ?, ?, 1, ?)
+		ON CONFLICT (guild_id, user_id) DO UPDATE SET
+			points = points + excluded.points, wins = wins + 1, updated_at = excluded.updated_at
+		RETURNING points`,
+		guildID, userID, points, time.Now().Unix()).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("add quiz win: %w", err)
+	}
+	return total, nil
+}
+
+// QuizLeaderboard returns the best quiz scores of a guild, most points first.
+func (s *Store) QuizLeaderboard(ctx context.Context, guildID string, limit int) ([]QuizScore, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT user_id, points, wins FROM quiz_scores WHERE guild_id = ?
+		ORDER BY points DESC, wins DESC, user_id LIMIT ?`, guildID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("load quiz leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	var scores []QuizScore
+	for rows.Next() {
+		var score QuizScore
+		if err := rows.Scan(&score.UserID, &score.Points, &score.Wins); err != nil {
+			return nil, fmt.Errorf("scan quiz score: %w", err)
+		}
+		scores = append(scores, score)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("load quiz leaderboard: %w", err)
+	}
+	return scores, nil
+}
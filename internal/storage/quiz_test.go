@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// TestStore_QuizScores tests that quiz wins add up per guild and rank the leaderboard.
+func TestStore_QuizScores(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	wins := []struct {
+		guildID, userID string
+		points, total   int
+	}{
+		{"g1", "u1", 3, 3},
+		{"g1", "u2", 2, 2},
+		{"g1", "u2", 2, 4},
+		{"g2", "u1", 1, 1},
+	}
+	for _, w := range wins {
+		total, err := store.AddQuizWin(ctx, w.guildID, w.userID, w.points)
+		if err != nil {
+			t.Fatalf("Failed to add quiz win: %v", err)
+		}
+		if total != w.total {
+			t.Errorf("Expected %d points for %s in %s, got %d", w.total, w.userID, w.guildID, total)
+		}
+	}
+
+	scores, err := store.QuizLeaderboard(ctx, "g1", 10)
+	if err != nil {
+		t.Fatalf("Failed to load leaderboard: %v", err)
+	}
+	if fmt.Sprint(scores) != "[{u2 4 2} {u1 3 1}]" {
+		t.Errorf("Unexpected leaderboard %v", scores)
+	}
+}
@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// DailyPost is a guild's image of the day, as the scheduler persists it.
+type DailyPost struct {
+	GuildID   string
+	ChannelID string
+	Category  string
+	Time      string // local posting time, "HH:MM"
+	Timezone  string
+	Window    int      // number of recent posts that may not repeat
+	Recent    []string // last posted images, oldest first
+	NextRun   time.Time
+	LastRun   time.Time // zero before the first post
+}
+
+// ScheduledJob is a cron job posting images of a category, as the scheduler persists it.
+type ScheduledJob struct {
+	ID        int64
+	GuildID   string
+	ChannelID string
+	Category  string
+	Spec      string // cron expression
+	Timezone  string
+	CatchUp   string
+	CreatedBy string
+	NextRun   time.Time
+	LastRun   time.Time // zero before the first run
+}
+
+// SetDailyPost creates or replaces the daily post of a guild.
+func (s *Store) SetDailyPost(ctx context.Context, post DailyPost) error {
+	recent, err := json.Marshal(post.Recent)
+	if err != nil {
+		return fmt.Errorf("encode recent daily images: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO daily_posts (guild_id, channel_id, category, post_time, timezone, repeat_window, recent, next_run, last_run)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (guild_id) DO UPDATE SET
+			channel_id = excluded.channel_id, category = excluded.category, post_time = excluded.post_time,
+			timezone = excluded.timezone, repeat_window = excluded.repeat_window, recent = excluded.recent,
+			next_run = excluded.next_run, last_run = excluded.last_run`,
+		post.GuildID, post.ChannelID, post.Category, post.Time, post.Timezone, post.Window,
+		string(recent), unixTime(post.NextRun), unixTime(post.LastRun))
+	if err != nil {
+		return fmt.Errorf("set daily post: %w", err)
+	}
+	return nil
+}
+
+// RemoveDailyPost deletes the daily post of a guild, if any.
+func (s *Store) RemoveDailyPost(ctx context.Context, guildID string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM daily_posts WHERE guild_id = ?`, guildID); err != nil {
+		return fmt.Errorf("remove daily post: %w", err)
+	}
+	return nil
+}
+
+// DailyPosts returns the daily posts of every guild, ordered by guild ID.
+func (s *Store) DailyPosts(ctx context.Context) ([]DailyPost, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT guild_id, channel_id, category, post_time, timezone, repeat_window, recent, next_run, last_run
+		FROM daily_posts ORDER BY guild_id`)
+	if err != nil {
+		return nil, fmt.Errorf("list daily posts: %w", err)
+	}
+	defer rows.Close()
+
+	var posts []DailyPost
+	for rows.Next() {
+		var post DailyPost
+		var recent string
+		var next, last int64
+		err := rows.Scan(&post.GuildID, &post.ChannelID, &post.Category, &post.Time, &post.Timezone,
+			&post.Window, &recent, &next, &last)
+		if err != nil {
+			return nil, fmt.Errorf("scan daily post: %w", err)
+		}
+		if err := json.Unmarshal([]byte(recent), &post.Recent); err != nil {
+			return nil, fmt.Errorf("decode recent daily images: %w", err)
+		}
+		post.NextRun, post.LastRun = fromUnix(next), fromUnix(last)
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list daily posts: %w", err)
+	}
+	return posts, nil
+}
+
+// AddScheduledJob saves a job and returns it with its ID. IDs are never reused, even once
+// jobs are removed.
+func (s *Store) AddScheduledJob(ctx context.Context, job ScheduledJob) (ScheduledJob, error) {
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO scheduled_jobs (guild_id, channel_id, category, spec, timezone, catch_up, created_by, next_run, last_run)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		job.GuildID, job.ChannelID, job.Category, job.Spec, job.Timezone, job.CatchUp, job.CreatedBy,
+		unixTime(job.NextRun), unixTime(job.LastRun))
+	if err != nil {
+		return ScheduledJob{}, fmt.Errorf("add scheduled job: %w", err)
+	}
+	if job.ID, err = result.LastInsertId(); err != nil {
+		return ScheduledJob{}, fmt.Errorf("add scheduled job: %w", err)
+	}
+	return job, nil
+}
+
+// SetScheduledJobRuns records a job's next and last runs.
+func (s *Store) SetScheduledJobRuns(ctx context.Context, id int64, next, last time.Time) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE scheduled_jobs SET next_run = ?, last_run = ? WHERE id = ?`,
+		unixTime(next), unixTime(last), id)
+	if err != nil {
+		return fmt.Errorf("update scheduled job: %w", err)
+	}
+	return nil
+}
+
+// RemoveScheduledJob deletes a job, if it exists.
+func (s *Store) RemoveScheduledJob(ctx context.Context, id int64) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM scheduled_jobs WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("remove scheduled job: %w", err)
+	}
+	return nil
+}
+
+// ScheduledJobs returns the jobs of every guild, oldest first.
+func (s *Store) ScheduledJobs(ctx context.Context) ([]ScheduledJob, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, guild_id, channel_id, category, spec, timezone, catch_up, created_by, next_run, last_run
+		FROM scheduled_jobs ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("list scheduled jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []ScheduledJob
+	for rows.Next() {
+		var job ScheduledJob
+		var next, last int64
+		err := rows.Scan(&job.ID, &job.GuildID, &job.ChannelID, &job.Category, &job.Spec, &job.Timezone,
+			&job.CatchUp, &job.CreatedBy, &next, &last)
+		if err != nil {
+			return nil, fmt.Errorf("scan scheduled job: %w", err)
+		}
+		job.NextRun, job.LastRun = fromUnix(next), fromUnix(last)
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list scheduled jobs: %w", err)
+	}
+	return jobs, nil
+}
+
+// unixTime stores t as Unix seconds, with 0 for the zero time.
+func unixTime(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// fromUnix reads a time stored by unixTime.
+func fromUnix(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestStore_DailyPosts tests setting, replacing, listing and removing daily posts.
+func TestStore_DailyPosts(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	next := time.Date(2024, 1, 20, 9, 0, 0, 0, time.UTC)
+	post := DailyPost{GuildID: "g1", ChannelID: "c1", Category: "wooper", Time: "09:00", Timezone: "UTC", Window: 2, NextRun: next}
+	if err := store.SetDailyPost(ctx, post); err != nil {
+		t.Fatalf("Failed to set daily post: %v", err)
+	}
+	post.Recent = []string{"img/wooper/a.jpg", "img/wooper/b, c.jpg"}
+	post.LastRun = next
+	post.NextRun = next.AddDate(0, 0, 1)
+	if err := store.SetDailyPost(ctx, post); err != nil {
+		t.Fatalf("Failed to replace daily post: %v", err)
+	}
+	if err := store.SetDailyPost(ctx, DailyPost{GuildID: "g2", ChannelID: "c2", Category: "cats", Time: "18:30", NextRun: next}); err != nil {
+		t.Fatalf("Failed to set daily post: %v", err)
+	}
+
+	posts, err := store.DailyPosts(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list daily posts: %v", err)
+	}
+	if len(posts) != 2 {
+		t.Fatalf("Expected 2 daily posts, got %+v", posts)
+	}
+	got := posts[0]
+	if got.GuildID != "g1" || got.Window != 2 || !slices.Equal(got.Recent, post.Recent) ||
+		!got.NextRun.Equal(post.NextRun) || !got.LastRun.Equal(post.LastRun) {
+		t.Errorf("Expected %+v, got %+v", post, got)
+	}
+	if !posts[1].LastRun.IsZero() || posts[1].Recent != nil {
+		t.Errorf("Expected a post that never ran, got %+v", posts[1])
+	}
+
+	if err := store.RemoveDailyPost(ctx, "g1"); err != nil {
+		t.Fatalf("Failed to remove daily post: %v", err)
+	}
+	if posts, _ := store.DailyPosts(ctx); len(posts) != 1 || posts[0].GuildID != "g2" {
+		t.Errorf("Expected only g2's post left, got %+v", posts)
+	}
+}
+
+// TestStore_ScheduledJobs tests adding, updating, listing and removing scheduled jobs.
+func TestStore_ScheduledJobs(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	next := time.Date(2024, 1, 20, 12, 0, 0, 0, time.UTC)
+	first, err := store.AddScheduledJob(ctx, ScheduledJob{GuildID: "g1", ChannelID: "c1", Category: "wooper", Spec: "@hourly", Timezone: "UTC", CatchUp: "once", CreatedBy: "u1", NextRun: next})
+	if err != nil {
+		t.Fatalf("Failed to add job: %v", err)
+	}
+	second, err := store.AddScheduledJob(ctx, ScheduledJob{GuildID: "g2", ChannelID: "c2", Category: "cats", Spec: "@daily", CatchUp: "skip", NextRun: next})
+	if err != nil {
+		t.Fatalf("Failed to add job: %v", err)
+	}
+	if first.ID == 0 || second.ID <= first.ID {
+		t.Errorf("Expected increasing IDs, got %d and %d", first.ID, second.ID)
+	}
+
+	if err := store.SetScheduledJobRuns(ctx, first.ID, next.Add(time.Hour), next); err != nil {
+		t.Fatalf("Failed to update job: %v", err)
+	}
+	jobs, err := store.ScheduledJobs(ctx)
+	if err != nil {
+		t.Fatalf("Failed to list jobs: %v", err)
+	}
+	if len(jobs) != 2 {
+		t.Fatalf("Expected 2 jobs, got %+v", jobs)
+	}
+	if got := jobs[0]; got.ID != first.ID || got.Spec != "@hourly" || got.CreatedBy != "u1" ||
+		!got.NextRun.Equal(next.Add(time.Hour)) || !got.LastRun.Equal(next) {
+		t.Errorf("Unexpected job %+v", got)
+	}
+	if !jobs[1].LastRun.IsZero() {
+		t.Errorf("Expected a job that never ran, got %+v", jobs[1])
+	}
+
+	// IDs aren't reused once jobs are removed
+	if err := store.RemoveScheduledJob(ctx, second.ID); err != nil {
+		t.Fatalf("Failed to remove job: %v", err)
+	}
+	third, err := store.AddScheduledJob(ctx, ScheduledJob{GuildID: "g1", ChannelID: "c1", Category: "wooper", Spec: "@daily", CatchUp: "once", NextRun: next})
+	if err != nil {
+		t.Fatalf("Failed to add job: %v", err)
+	}
+	if third.ID <= second.ID {
+		t.Errorf("Expected a new ID after %d, got %d", second.ID, third.ID)
+	}
+}
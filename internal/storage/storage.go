@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"wooper-bot/internal/logger"
+
+	"go.uber.org/zap"
+	_ "modernc.org/sqlite" // pure-Go SQLite driver, keeps CGO_ENABLED=0 builds working
+)
+
+// Store is the bot's persistent state backed by a SQLite database.
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens (creating if needed) the database at path and applies pending migrations.
+func Open(path string) (*Store, error) {
+	logger.Logger.Info("Opening database", zap.String("path", path))
+
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("create database directory: %w", err)
+		}
+	}
+
+	dsn := fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", path)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open database: %w", err)
+	}
+	// SQLite allows a single writer; one connection avoids SQLITE_BUSY between our own goroutines
+	db.SetMaxOpenConns(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("connect database: %w", err)
+	}
+
+	store := &Store{db: db}
+	if err := store.migrate(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	logger.Logger.Info("Database ready", zap.String("path", path))
+	return store, nil
+}
+
+// Close closes the underlying database.
+func (s *Store) Close() error {
+	if err := s.db.Close(); err != nil {
+		return fmt.Errorf("close database: %w", err)
+	}
+	logger.Logger.Info("Database closed")
+	return nil
+}
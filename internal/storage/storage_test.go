@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"wooper-bot/internal/logger"
+)
+
+// setupTestStore opens a fresh database in a temporary directory.
+func setupTestStore(t *testing.T) *Store {
+	err := logger.Init()
+	if err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+	t.Cleanup(func() {
+		logger.Close()
+	})
+
+	store, err := Open(filepath.Join(t.TempDir(), "data", "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	t.Cleanup(func() {
+		store.Close()
+	})
+	return store
+}
+
+// TestOpen tests that opening a database applies every migration exactly once.
+func TestOpen(t *testing.T) {
+	err := logger.Init()
+	if err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+	defer logger.Close()
+
+	path := filepath.Join(t.TempDir(), "nested", "wooper.db")
+
+	for i := 0; i < 2; i++ {
+		store, err := Open(path)
+		if err != nil {
+			t.Fatalf("Failed to open store (attempt %d): %v", i+1, err)
+		}
+
+		var count, version int
+		err = store.db.QueryRowContext(context.Background(),
+			`SELECT COUNT(*), MAX(version) FROM schema_migrations`).Scan(&count, &version)
+		if err != nil {
+			t.Fatalf("Failed to read schema_migrations: %v", err)
+		}
+		if count != len(migrations) || version != len(migrations) {
+			t.Errorf("Expected %d migrations, got count=%d version=%d", len(migrations), count, version)
+		}
+
+		if err := store.Close(); err != nil {
+			t.Errorf("Unexpected close error: %v", err)
+		}
+	}
+}
+
+// TestOpen_InvalidPath tests that an unusable path is reported.
+func TestOpen_InvalidPath(t *testing.T) {
+	err := logger.Init()
+	if err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+	defer logger.Close()
+
+	// A directory cannot be opened as a database file
+	if _, err := Open(t.TempDir()); err == nil {
+		t.Errorf("Expected error but got none")
+	}
+}
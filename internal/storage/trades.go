@@ -0,0 +1,239 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TradeStatus is the state of a trade. Only pending trades can change.
+type TradeStatus string
+
+const (
+	TradePending   TradeStatus = "pending"
+	TradeAccepted  TradeStatus = "accepted"
+	TradeDeclined  TradeStatus = "declined"  // by the user the offer was made to
+	TradeCancelled TradeStatus = "cancelled" // by the user who made the offer
+	TradeExpired   TradeStatus = "expired"
+	TradeFailed    TradeStatus = "failed" // an image left its owner's inventory before the trade was accepted
+)
+
+var (
+	// ErrTradeClosed is returned when resolving a trade that is not pending, or by a user
+	// who can't resolve it.
+	ErrTradeClosed = errors.New("trade is not pending")
+	// ErrNotOwned is returned when accepting a trade whose images are no longer owned.
+	ErrNotOwned = errors.New("image not in inventory")
+)
+
+// Trade is an offer to swap an image of one user's inventory for one of another user's.
+type Trade struct {
+	ID         int64
+	GuildID    string
+	FromUserID string
+	ToUserID   string
+	GiveID     string // image offered by FromUserID
+	WantID     string // image asked from ToUserID
+	Status     TradeStatus
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	ResolvedAt time.Time // zero while pending
+}
+
+// CreateTrade records a pending trade and returns its ID.
+func (s *Store) CreateTrade(ctx context.Context, t Trade) (int64, error) {
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = time.Now()
+	}
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO trades (guild_id, from_user_id, to_user_id, give_id, want_id, status, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		t.GuildID, t.FromUserID, t.ToUserID, t.GiveID, t.WantID, TradePending, t.CreatedAt.Unix(), t.ExpiresAt.Unix())
+	if err != nil {
+		return 0, fmt.Errorf("create trade: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("create trade: %w", err)
+	}
+	return id, nil
+}
+
+// tradeColumns are the columns scanTrade reads, in order.
+const tradeColumns = `id, guild_id, from_user_id, to_user_id, give_id, want_id, status, created_at, expires_at, resolved_at`
+
+// scanTrade reads a trade selected with tradeColumns from a *sql.Row or *sql.Rows.
+func scanTrade(row interface{ Scan(dest ...any) error }) (Trade, error) {
+	var t Trade
+	var created, expires, resolved int64
+	err := row.Scan(&t.ID, &t.GuildID, &t.FromUserID, &t.ToUserID, &t.GiveID, &t.WantID, &t.Status, &created, &expires, &resolved)
+	if err != nil {
+		return Trade{}, err
+	}
+	t.CreatedAt, t.ExpiresAt = time.Unix(created, 0), time.Unix(expires, 0)
+	if resolved != 0 {
+		t.ResolvedAt = time.Unix(resolved, 0)
+	}
+	return t, nil
+}
+
+// Trade returns a trade and whether it exists.
+func (s *Store) Trade(ctx context.Context, id int64) (Trade, bool, error) {
+	t, err := scanTrade(s.db.QueryRowContext(ctx, `SELECT `+tradeColumns+` FROM trades WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Trade{}, false, nil
+	}
+	if err != nil {
+		return Trade{}, false, fmt.Errorf("get trade: %w", err)
+	}
+	return t, true, nil
+}
+
+// Trades returns the latest trades a user made or received, newest first.
+func (s *Store) Trades(ctx context.Context, userID string, limit int) ([]Trade, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+tradeColumns+` FROM trades WHERE from_user_id = ? OR to_user_id = ?
+		ORDER BY created_at DESC, id DESC LIMIT ?`, userID, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list trades: %w", err)
+	}
+	defer rows.Close()
+
+	var trades []Trade
+	for rows.Next() {
+		t, err := scanTrade(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan trade: %w", err)
+		}
+		trades = append(trades, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list trades: %w", err)
+	}
+	return trades, nil
+}
+
+// ExpireTrades closes the pending trades past their expiry and returns how many it closed.
+func (s *Store) ExpireTrades(ctx context.Context) (int64, error) {
+	now := time.Now().Unix()
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE trades SET status = ?, resolved_at = ? WHERE status = ? AND expires_at <= ?`,
+		TradeExpired, now, TradePending, now)
+	if err != nil {
+		return 0, fmt.Errorf("expire trades: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// AcceptTrade swaps the images of a pending trade made to userID. The status changes first,
+// in the same transaction as the swap, so accepting twice fails with ErrTradeClosed instead
+// of swapping twice. When an image is no longer owned the trade fails with ErrNotOwned.
+// The trade is returned in its new state.
+func (s *Store) AcceptTrade(ctx context.Context, id int64, userID string) (Trade, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Trade{}, fmt.Errorf("begin trade: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	res, err := tx.ExecContext(ctx, `
+		UPDATE trades SET status = ?, resolved_at = ?
+		WHERE id = ? AND to_user_id = ? AND status = ? AND expires_at > ?`,
+		TradeAccepted, now, id, userID, TradePending, now)
+	if err != nil {
+		return Trade{}, fmt.Errorf("accept trade: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		tx.Rollback()
+		t, _, err := s.Trade(ctx, id)
+		if err != nil {
+			return Trade{}, err
+		}
+		return t, ErrTradeClosed
+	}
+
+	t, err := scanTrade(tx.QueryRowContext(ctx, `SELECT `+tradeColumns+` FROM trades WHERE id = ?`, id))
+	if err != nil {
+		return Trade{}, fmt.Errorf("get trade: %w", err)
+	}
+	err = moveCatch(ctx, tx, t.FromUserID, t.ToUserID, t.GiveID)
+	if err == nil {
+		err = moveCatch(ctx, tx, t.ToUserID, t.FromUserID, t.WantID)
+	}
+	if errors.Is(err, ErrNotOwned) {
+		tx.Rollback()
+		_, err := s.db.ExecContext(ctx,
+			`UPDATE trades SET status = ?, resolved_at = ? WHERE id = ? AND status = ?`,
+			TradeFailed, now, id, TradePending)
+		if err != nil {
+			return Trade{}, fmt.Errorf("fail trade: %w", err)
+		}
+		t.Status, t.ResolvedAt = TradeFailed, time.Unix(now, 0)
+		return t, ErrNotOwned
+	}
+	if err != nil {
+		return Trade{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Trade{}, fmt.Errorf("commit trade: %w", err)
+	}
+	return t, nil
+}
+
+// moveCatch moves one copy of an image from a user's inventory to another's.
+func moveCatch(ctx context.Context, tx *sql.Tx, fromUserID, toUserID, imageID string) error {
+	var category string
+	var rarity int
+	err := tx.QueryRowContext(ctx,
+		`SELECT category, rarity FROM catches WHERE user_id = ? AND image_id = ? AND count > 0`,
+		fromUserID, imageID).Scan(&category, &rarity)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotOwned
+	}
+	if err != nil {
+		return fmt.Errorf("get traded image: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE catches SET count = count - 1 WHERE user_id = ? AND image_id = ?`, fromUserID, imageID); err != nil {
+		return fmt.Errorf("take traded image: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM catches WHERE user_id = ? AND image_id = ? AND count = 0`, fromUserID, imageID); err != nil {
+		return fmt.Errorf("take traded image: %w", err)
+	}
+	// Received images have a zero last catch time, they were never caught by the user
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO catches (user_id, image_id, category, rarity, count, first_caught_at, last_caught_at)
+		VALUES (?, ?, ?, ?, 1, ?, 0)
+		ON CONFLICT (user_id, image_id) DO UPDATE SET count = count + 1`,
+		toUserID, imageID, category, rarity, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("give traded image: %w", err)
+	}
+	return nil
+}
+
+// DeclineTrade closes a pending trade, as declined when userID received it or cancelled when
+// userID made it. Other users get ErrTradeClosed. The trade is returned in its new state.
+func (s *Store) DeclineTrade(ctx context.Context, id int64, userID string) (Trade, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE trades SET status = CASE WHEN to_user_id = ? THEN ? ELSE ? END, resolved_at = ?
+		WHERE id = ? AND status = ? AND (to_user_id = ? OR from_user_id = ?)`,
+		userID, TradeDeclined, TradeCancelled, time.Now().Unix(), id, TradePending, userID, userID)
+	if err != nil {
+		return Trade{}, fmt.Errorf("decline trade: %w", err)
+	}
+	t, _, err := s.Trade(ctx, id)
+	if err != nil {
+		return Trade{}, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return t, ErrTradeClosed
+	}
+	return t, nil
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// setupTrade gives u1 image a1 and u2 image b1, and creates a pending trade of a1 for b1.
+func setupTrade(t *testing.T, store *Store, expiresIn time.Duration) int64 {
+	t.Helper()
+	ctx := context.Background()
+	if _, err := store.AddCatch(ctx, "u1", "a1", "wooper", 0); err != nil {
+		t.Fatalf("Failed to add catch: %v", err)
+	}
+	if _, err := store.AddCatch(ctx, "u2", "b1", "cats", 2); err != nil {
+		t.Fatalf("Failed to add catch: %v", err)
+	}
+	id, err := store.CreateTrade(ctx, Trade{
+		GuildID: "g1", FromUserID: "u1", ToUserID: "u2", GiveID: "a1", WantID: "b1",
+		ExpiresAt: time.Now().Add(expiresIn),
+	})
+	if err != nil {
+		t.Fatalf("Failed to create trade: %v", err)
+	}
+	return id
+}
+
+// TestStore_AcceptTrade tests that accepting swaps the images once, however often it is accepted.
+func TestStore_AcceptTrade(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+	id := setupTrade(t, store, time.Hour)
+
+	if _, err := store.AcceptTrade(ctx, id, "u1"); !errors.Is(err, ErrTradeClosed) {
+		t.Errorf("Expected the offering user to be unable to accept, got %v", err)
+	}
+
+	trade, err := store.AcceptTrade(ctx, id, "u2")
+	if err != nil {
+		t.Fatalf("Failed to accept trade: %v", err)
+	}
+	if trade.Status != TradeAccepted || trade.ResolvedAt.IsZero() {
+		t.Errorf("Expected an accepted trade, got %+v", trade)
+	}
+	if _, err := store.AcceptTrade(ctx, id, "u2"); !errors.Is(err, ErrTradeClosed) {
+		t.Errorf("Expected a second accept to fail, got %v", err)
+	}
+
+	owned := map[string]string{"u1": "b1", "u2": "a1"}
+	for userID, imageID := range owned {
+		c, ok, err := store.CatchOf(ctx, userID, imageID)
+		if err != nil || !ok || c.Count != 1 || !c.LastCaughtAt.IsZero() {
+			t.Errorf("Expected %s to own one %s received in a trade, got %+v %t (%v)", userID, imageID, c, ok, err)
+		}
+	}
+	for userID, imageID := range map[string]string{"u1": "a1", "u2": "b1"} {
+		if _, ok, _ := store.CatchOf(ctx, userID, imageID); ok {
+			t.Errorf("Expected %s to no longer own %s", userID, imageID)
+		}
+	}
+	if c, _, _ := store.CatchOf(ctx, "u1", "b1"); c.Category != "cats" || c.Rarity != 2 {
+		t.Errorf("Expected the image to keep its category and rarity, got %+v", c)
+	}
+	// Trading away the only image caught doesn't reset the catch cooldown
+	if last, err := store.LastCatch(ctx, "u1"); err != nil || time.Since(last) > time.Minute {
+		t.Errorf("Expected u1 to keep their last catch time, got %v (%v)", last, err)
+	}
+}
+
+// TestStore_AcceptTrade_NotOwned tests that a trade fails when an image left its owner's inventory.
+func TestStore_AcceptTrade_NotOwned(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+	id := setupTrade(t, store, time.Hour)
+
+	// u1 gives a1 away in another trade first
+	other, err := store.CreateTrade(ctx, Trade{FromUserID: "u1", ToUserID: "u3", GiveID: "a1", WantID: "c1", ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("Failed to create trade: %v", err)
+	}
+	if _, err := store.AddCatch(ctx, "u3", "c1", "wooper", 0); err != nil {
+		t.Fatalf("Failed to add catch: %v", err)
+	}
+	if _, err := store.AcceptTrade(ctx, other, "u3"); err != nil {
+		t.Fatalf("Failed to accept trade: %v", err)
+	}
+
+	trade, err := store.AcceptTrade(ctx, id, "u2")
+	if !errors.Is(err, ErrNotOwned) || trade.Status != TradeFailed {
+		t.Errorf("Expected a failed trade, got %+v (%v)", trade, err)
+	}
+	if c, ok, _ := store.CatchOf(ctx, "u2", "b1"); !ok || c.Count != 1 {
+		t.Errorf("Expected u2 to keep b1, got %+v", c)
+	}
+}
+
+// TestStore_DeclineTrade tests declining, cancelling and expiring trades.
+func TestStore_DeclineTrade(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	id := setupTrade(t, store, time.Hour)
+	if _, err := store.DeclineTrade(ctx, id, "u3"); !errors.Is(err, ErrTradeClosed) {
+		t.Errorf("Expected other users to be unable to decline, got %v", err)
+	}
+	if trade, err := store.DeclineTrade(ctx, id, "u2"); err != nil || trade.Status != TradeDeclined {
+		t.Errorf("Expected a declined trade, got %+v (%v)", trade, err)
+	}
+	if _, err := store.AcceptTrade(ctx, id, "u2"); !errors.Is(err, ErrTradeClosed) {
+		t.Errorf("Expected a declined trade to stay closed, got %v", err)
+	}
+
+	id = setupTrade(t, store, time.Hour)
+	if trade, err := store.DeclineTrade(ctx, id, "u1"); err != nil || trade.Status != TradeCancelled {
+		t.Errorf("Expected a cancelled trade, got %+v (%v)", trade, err)
+	}
+
+	id = setupTrade(t, store, -time.Minute)
+	if _, err := store.AcceptTrade(ctx, id, "u2"); !errors.Is(err, ErrTradeClosed) {
+		t.Errorf("Expected an expired trade to be closed, got %v", err)
+	}
+	if n, err := store.ExpireTrades(ctx); err != nil || n != 1 {
+		t.Errorf("Expected 1 expired trade, got %d (%v)", n, err)
+	}
+	if trade, _, _ := store.Trade(ctx, id); trade.Status != TradeExpired {
+		t.Errorf("Expected an expired trade, got %s", trade.Status)
+	}
+
+	trades, err := store.Trades(ctx, "u2", 10)
+	if err != nil || len(trades) != 3 || trades[0].ID != id {
+		t.Errorf("Expected the 3 trades of u2 newest first, got %+v (%v)", trades, err)
+	}
+}
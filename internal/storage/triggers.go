@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TriggerMatch is how a trigger's pattern is looked for in chat messages.
+type TriggerMatch string
+
+const (
+	TriggerKeyword TriggerMatch = "keyword" // anywhere in the message, ignoring case
+	TriggerWord    TriggerMatch = "word"    // as whole words, ignoring case
+	TriggerRegex   TriggerMatch = "regex"   // a regular expression, case-insensitive
+)
+
+// TriggerAction is what the bot does when a trigger matches.
+type TriggerAction string
+
+const (
+	TriggerReact TriggerAction = "react" // react with the emoji in Value
+	TriggerImage TriggerAction = "image" // send an image of the category in Value
+)
+
+// Trigger is a guild's automatic response to chat messages matching a pattern.
+type Trigger struct {
+	ID        int64
+	GuildID   string
+	Match     TriggerMatch
+	Pattern   string
+	Action    TriggerAction
+	Value     string
+	Cooldown  time.Duration // per channel
+	CreatedBy string
+	CreatedAt time.Time
+}
+
+// AddTrigger saves a trigger and returns it with its ID.
+func (s *Store) AddTrigger(ctx context.Context, trigger Trigger) (Trigger, error) {
+	trigger.CreatedAt = time.Now()
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO triggers (guild_id, match, pattern, action, value, cooldown, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		trigger.GuildID, trigger.Match, trigger.Pattern, trigger.Action, trigger.Value,
+		int64(trigger.Cooldown/time.Second), trigger.CreatedBy, trigger.CreatedAt.Unix())
+	if err != nil {
+		return Trigger{}, fmt.Errorf("add trigger: %w", err)
+	}
+	if trigger.ID, err = result.LastInsertId(); err != nil {
+		return Trigger{}, fmt.Errorf("add trigger: %w", err)
+	}
+	return trigger, nil
+}
+
+// RemoveTrigger deletes a guild's trigger. It reports false when the guild has no such trigger.
+func (s *Store) RemoveTrigger(ctx context.Context, guildID string, id int64) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM triggers WHERE guild_id = ? AND id = ?`, guildID, id)
+	if err != nil {
+		return false, fmt.Errorf("remove trigger: %w", err)
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("remove trigger: %w", err)
+	}
+	return removed > 0, nil
+}
+
+// Triggers returns a guild's triggers, oldest first.
+func (s *Store) Triggers(ctx context.Context, guildID string) ([]Trigger, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, guild_id, match, pattern, action, value, cooldown, created_by, created_at
+		FROM triggers WHERE guild_id = ? ORDER BY id`, guildID)
+	if err != nil {
+		return nil, fmt.Errorf("list triggers: %w", err)
+	}
+	defer rows.Close()
+
+	var triggers []Trigger
+	for rows.Next() {
+		var trigger Trigger
+		var cooldown, createdAt int64
+		err := rows.Scan(&trigger.ID, &trigger.GuildID, &trigger.Match, &trigger.Pattern,
+			&trigger.Action, &trigger.Value, &cooldown, &trigger.CreatedBy, &createdAt)
+		if err != nil {
+			return nil, fmt.Errorf("scan trigger: %w", err)
+		}
+		trigger.Cooldown = time.Duration(cooldown) * time.Second
+		trigger.CreatedAt = time.Unix(createdAt, 0)
+		triggers = append(triggers, trigger)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list triggers: %w", err)
+	}
+	return triggers, nil
+}
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestStore_Triggers tests adding, listing and removing a guild's triggers.
+func TestStore_Triggers(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	added := []Trigger{
+		{GuildID: "g1", Match: TriggerWord, Pattern: "wooper", Action: TriggerImage, Value: "wooper", Cooldown: time.Minute, CreatedBy: "u1"},
+		{GuildID: "g1", Match: TriggerRegex, Pattern: "qu+a+g", Action: TriggerReact, Value: "🐸", Cooldown: 5 * time.Second, CreatedBy: "u1"},
+		{GuildID: "g2", Match: TriggerKeyword, Pattern: "cat", Action: TriggerReact, Value: "<:cat:123>", Cooldown: time.Minute, CreatedBy: "u2"},
+	}
+	for i, trigger := range added {
+		saved, err := store.AddTrigger(ctx, trigger)
+		if err != nil {
+			t.Fatalf("Failed to add trigger: %v", err)
+		}
+		if saved.ID == 0 || saved.CreatedAt.IsZero() {
+			t.Errorf("Expected an ID and creation time, got %+v", saved)
+		}
+		added[i] = saved
+	}
+
+	triggers, err := store.Triggers(ctx, "g1")
+	if err != nil {
+		t.Fatalf("Failed to list triggers: %v", err)
+	}
+	if len(triggers) != 2 {
+		t.Fatalf("Expected 2 triggers in g1, got %+v", triggers)
+	}
+	for i, trigger := range triggers {
+		expected := added[i]
+		if trigger.ID != expected.ID || trigger.Match != expected.Match || trigger.Pattern != expected.Pattern ||
+			trigger.Action != expected.Action || trigger.Value != expected.Value || trigger.Cooldown != expected.Cooldown {
+			t.Errorf("Expected %+v, got %+v", expected, trigger)
+		}
+	}
+
+	// A guild can't remove another guild's trigger
+	if removed, err := store.RemoveTrigger(ctx, "g1", added[2].ID); err != nil || removed {
+		t.Errorf("Expected g2's trigger to stay, got %v, %v", removed, err)
+	}
+	if removed, err := store.RemoveTrigger(ctx, "g1", added[0].ID); err != nil || !removed {
+		t.Errorf("Expected the trigger to be removed, got %v, %v", removed, err)
+	}
+	if triggers, _ := store.Triggers(ctx, "g1"); len(triggers) != 1 || triggers[0].ID != added[1].ID {
+		t.Errorf("Expected one trigger left, got %+v", triggers)
+	}
+}
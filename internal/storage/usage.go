@@ -0,0 +1,190 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// statsOptOutKey is the user_data key set for users who don't want their requests tied to them.
+const statsOptOutKey = "stats_opt_out"
+
+// Usage is one served image request.
+type Usage struct {
+	GuildID   string // empty for direct messages
+	ChannelID string
+	UserID    string
+	Command   string
+	Category  string
+	Image     string
+	ImageID   string
+	Transport string // text, slash or component
+	Latency   time.Duration
+	CreatedAt time.Time
+}
+
+// UsageCount is how many requests share a category, image or user.
+type UsageCount struct {
+	Key   string
+	Count int
+}
+
+// UsageFilter restricts statistics to a guild and a category. Empty fields match everything.
+type UsageFilter struct {
+	GuildID  string
+	Category string
+}
+
+// UsageStats summarizes the requests matching a UsageFilter.
+type UsageStats struct {
+	Requests       int
+	AverageLatency time.Duration
+	Transports     map[string]int
+	Categories     []UsageCount
+	Images         []UsageCount
+	Users          []UsageCount // users who opted out are left out
+}
+
+// RecordUsage appends a served request to the usage history. Requests of users who opted out
+// of statistics are recorded without their user ID.
+func (s *Store) RecordUsage(ctx context.Context, u Usage) error {
+	if u.CreatedAt.IsZero() {
+		u.CreatedAt = time.Now()
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO usage_history (guild_id, channel_id, user_id, command, category, image, image_id, transport, latency_ms, created_at)
+		SELECT ?, ?, CASE WHEN EXISTS (SELECT 1 FROM user_data WHERE user_id = ? AND key = ?) THEN '' ELSE ? END, ?, ?, ?, ?, ?, ?, ?`,
+		u.GuildID, u.ChannelID, u.UserID, statsOptOutKey, u.UserID, u.Command, u.Category, u.Image, u.ImageID,
+		u.Transport, u.Latency.Milliseconds(), u.CreatedAt.Unix())
+	if err != nil {
+		return fmt.Errorf("record usage: %w", err)
+	}
+	return nil
+}
+
+// UsageCount returns how many requests a guild made since the given time.
+func (s *Store) UsageCount(ctx context.Context, guildID string, since time.Time) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM usage_history WHERE guild_id = ? AND created_at >= ?`,
+		guildID, since.Unix()).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count usage: %w", err)
+	}
+	return count, nil
+}
+
+// SetStatsOptOut records whether a user opted out of statistics. Opting out also removes the
+// user ID from the requests already recorded, so they only count in the totals.
+func (s *Store) SetStatsOptOut(ctx context.Context, userID string, optOut bool) error {
+	if !optOut {
+		return s.DeleteUserValue(ctx, userID, statsOptOutKey)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin stats opt-out: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO user_data (user_id, key, value, updated_at) VALUES (?, ?, 'true', ?)
+		ON CONFLICT (user_id, key) DO UPDATE SET updated_at = excluded.updated_at`,
+		userID, statsOptOutKey, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("set stats opt-out: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE usage_history SET user_id = '' WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("anonymize usage: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit stats opt-out: %w", err)
+	}
+	return nil
+}
+
+// StatsOptedOut reports whether a user opted out of statistics.
+func (s *Store) StatsOptedOut(ctx context.Context, userID string) (bool, error) {
+	_, ok, err := s.UserValue(ctx, userID, statsOptOutKey)
+	return ok, err
+}
+
+// UsageStats returns the number of requests matching filter, their average latency and
+// transports, and the limit most requested categories, images and users.
+func (s *Store) UsageStats(ctx context.Context, filter UsageFilter, limit int) (*UsageStats, error) {
+	var conditions []string
+	var args []any
+	if filter.GuildID != "" {
+		conditions = append(conditions, "guild_id = ?")
+		args = append(args, filter.GuildID)
+	}
+	if filter.Category != "" {
+		conditions = append(conditions, "category = ?")
+		args = append(args, filter.Category)
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	stats := &UsageStats{}
+	// Requests recorded before latencies were measured have none and don't count in the average
+	var latency float64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(AVG(NULLIF(latency_ms, 0)), 0) FROM usage_history`+where,
+		args...).Scan(&stats.Requests, &latency)
+	if err != nil {
+		return nil, fmt.Errorf("load usage stats: %w", err)
+	}
+	stats.AverageLatency = time.Duration(latency * float64(time.Millisecond))
+
+	transports, err := s.usageCounts(ctx, "transport", where, args, -1)
+	if err != nil {
+		return nil, err
+	}
+	stats.Transports = make(map[string]int, len(transports))
+	for _, t := range transports {
+		stats.Transports[t.Key] = t.Count
+	}
+	if stats.Categories, err = s.usageCounts(ctx, "category", where, args, limit); err != nil {
+		return nil, err
+	}
+	if stats.Images, err = s.usageCounts(ctx, "image_id", where, args, limit); err != nil {
+		return nil, err
+	}
+	if stats.Users, err = s.usageCounts(ctx, "user_id", where, args, limit); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// usageCounts counts the requests matching where by column, most requested first, leaving out
+// empty values. A negative limit returns every value.
+func (s *Store) usageCounts(ctx context.Context, column, where string, args []any, limit int) ([]UsageCount, error) {
+	filter := " WHERE "
+	if where != "" {
+		filter = where + " AND "
+	}
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+column+`, COUNT(*) AS requests FROM usage_history`+filter+column+` != ''
+		GROUP BY `+column+` ORDER BY requests DESC, `+column+` LIMIT ?`,
+		append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("count usage by %s: %w", column, err)
+	}
+	defer rows.Close()
+
+	var counts []UsageCount
+	for rows.Next() {
+		var c UsageCount
+		if err := rows.Scan(&c.Key, &c.Count); err != nil {
+			return nil, fmt.Errorf("scan usage by %s: %w", column, err)
+		}
+		counts = append(counts, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("count usage by %s: %w", column, err)
+	}
+	return counts, nil
+}
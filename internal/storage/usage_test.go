@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestStore_RecordUsage tests that recorded usage is counted per guild and time range.
+func TestStore_RecordUsage(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	records := []Usage{
+		{GuildID: "g1", ChannelID: "c1", UserID: "u1", Command: "image", Category: "wooper", Image: "a.jpg", CreatedAt: now.Add(-48 * time.Hour)},
+		{GuildID: "g1", ChannelID: "c1", UserID: "u2", Command: "image", Category: "wooper", Image: "b.jpg", CreatedAt: now},
+		{GuildID: "g2", ChannelID: "c2", UserID: "u1", Command: "image", Category: "cats", Image: "c.jpg"},
+	}
+	for _, u := range records {
+		if err := store.RecordUsage(ctx, u); err != nil {
+			t.Fatalf("Failed to record usage: %v", err)
+		}
+	}
+
+	count, err := store.UsageCount(ctx, "g1", now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("Failed to count usage: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 recent request for g1, got %d", count)
+	}
+
+	count, err = store.UsageCount(ctx, "g1", time.Time{})
+	if err != nil {
+		t.Fatalf("Failed to count usage: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("Expected 2 requests for g1, got %d", count)
+	}
+}
+
+// TestStore_UsageStats tests the breakdowns of recorded usage by guild and category.
+func TestStore_UsageStats(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	records := []Usage{
+		{GuildID: "g1", UserID: "u1", Category: "wooper", ImageID: "a", Transport: "slash", Latency: 100 * time.Millisecond},
+		{GuildID: "g1", UserID: "u1", Category: "wooper", ImageID: "a", Transport: "text", Latency: 300 * time.Millisecond},
+		{GuildID: "g1", UserID: "u2", Category: "cats", ImageID: "c", Transport: "slash", Latency: 200 * time.Millisecond},
+		{GuildID: "g1", UserID: "u2", Category: "wooper", Image: "old.jpg"}, // recorded before image IDs and latencies
+		{GuildID: "g2", UserID: "u3", Category: "wooper", ImageID: "b", Transport: "slash", Latency: time.Second},
+	}
+	for _, u := range records {
+		if err := store.RecordUsage(ctx, u); err != nil {
+			t.Fatalf("Failed to record usage: %v", err)
+		}
+	}
+
+	stats, err := store.UsageStats(ctx, UsageFilter{GuildID: "g1"}, 10)
+	if err != nil {
+		t.Fatalf("Failed to load stats: %v", err)
+	}
+	if stats.Requests != 4 || stats.AverageLatency != 200*time.Millisecond {
+		t.Errorf("Expected 4 requests averaging 200ms, got %d averaging %v", stats.Requests, stats.AverageLatency)
+	}
+	if stats.Transports["slash"] != 2 || stats.Transports["text"] != 1 || len(stats.Transports) != 2 {
+		t.Errorf("Unexpected transports %v", stats.Transports)
+	}
+	if fmt.Sprint(stats.Categories) != "[{wooper 3} {cats 1}]" {
+		t.Errorf("Unexpected categories %v", stats.Categories)
+	}
+	if fmt.Sprint(stats.Images) != "[{a 2} {c 1}]" {
+		t.Errorf("Unexpected images %v", stats.Images)
+	}
+	if fmt.Sprint(stats.Users) != "[{u1 2} {u2 2}]" {
+		t.Errorf("Unexpected users %v", stats.Users)
+	}
+
+	stats, err = store.UsageStats(ctx, UsageFilter{Category: "wooper"}, 1)
+	if err != nil {
+		t.Fatalf("Failed to load stats: %v", err)
+	}
+	if stats.Requests != 4 || fmt.Sprint(stats.Users) != "[{u1 2}]" {
+		t.Errorf("Expected 4 wooper requests led by u1, got %d and %v", stats.Requests, stats.Users)
+	}
+}
+
+// TestStore_StatsOptOut tests that users who opted out are no longer tied to their requests.
+func TestStore_StatsOptOut(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	record := func(userID string) {
+		t.Helper()
+		if err := store.RecordUsage(ctx, Usage{GuildID: "g1", UserID: userID, Category: "wooper"}); err != nil {
+			t.Fatalf("Failed to record usage: %v", err)
+		}
+	}
+	record("u1")
+	record("u2")
+
+	if err := store.SetStatsOptOut(ctx, "u1", true); err != nil {
+		t.Fatalf("Failed to opt out: %v", err)
+	}
+	record("u1")
+	if optedOut, err := store.StatsOptedOut(ctx, "u1"); err != nil || !optedOut {
+		t.Errorf("Expected u1 to be opted out, got %v (%v)", optedOut, err)
+	}
+
+	stats, err := store.UsageStats(ctx, UsageFilter{GuildID: "g1"}, 10)
+	if err != nil {
+		t.Fatalf("Failed to load stats: %v", err)
+	}
+	if stats.Requests != 3 || fmt.Sprint(stats.Users) != "[{u2 1}]" {
+		t.Errorf("Expected 3 requests and only u2 listed, got %d and %v", stats.Requests, stats.Users)
+	}
+
+	if err := store.SetStatsOptOut(ctx, "u1", false); err != nil {
+		t.Fatalf("Failed to opt in: %v", err)
+	}
+	record("u1")
+	if stats, _ = store.UsageStats(ctx, UsageFilter{GuildID: "g1"}, 10); fmt.Sprint(stats.Users) != "[{u1 1} {u2 1}]" {
+		t.Errorf("Expected u1 to be listed again after opting in, got %v", stats.Users)
+	}
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// UserValue returns a per-user value and whether it is set.
+func (s *Store) UserValue(ctx context.Context, userID, key string) (string, bool, error) {
+	var value string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT value FROM user_data WHERE user_id = ? AND key = ?`, userID, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("get user value %s: %w", key, err)
+	}
+	return value, true, nil
+}
+
+// SetUserValue creates or replaces a per-user value.
+func (s *Store) SetUserValue(ctx context.Context, userID, key, value string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO user_data (user_id, key, value, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id, key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`,
+		userID, key, value, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("set user value %s: %w", key, err)
+	}
+	return nil
+}
+
+// DeleteUserValue removes a per-user value.
+func (s *Store) DeleteUserValue(ctx context.Context, userID, key string) error {
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM user_data WHERE user_id = ? AND key = ?`, userID, key)
+	if err != nil {
+		return fmt.Errorf("delete user value %s: %w", key, err)
+	}
+	return nil
+}
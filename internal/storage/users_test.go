@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"context"
+	"testing"
+)
+
+// TestStore_UserValues tests per-user values are isolated by user and key.
+func TestStore_UserValues(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	if err := store.SetUserValue(ctx, "u1", "locale", "fr"); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+	if err := store.SetUserValue(ctx, "u2", "locale", "de"); err != nil {
+		t.Fatalf("Failed to set value: %v", err)
+	}
+
+	value, ok, err := store.UserValue(ctx, "u1", "locale")
+	if err != nil || !ok || value != "fr" {
+		t.Errorf("Expected fr, got %q ok=%v err=%v", value, ok, err)
+	}
+
+	if err := store.DeleteUserValue(ctx, "u1", "locale"); err != nil {
+		t.Fatalf("Failed to delete value: %v", err)
+	}
+	if _, ok, _ := store.UserValue(ctx, "u1", "locale"); ok {
+		t.Errorf("Expected value to be deleted")
+	}
+	if _, ok, _ := store.UserValue(ctx, "u2", "locale"); !ok {
+		t.Errorf("Expected other user's value to remain")
+	}
+}
@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Vote is a user's thumbs up (+1) or down (-1) on an image, given by reacting to a message showing it.
+type Vote struct {
+	ImageID   string
+	UserID    string
+	Category  string
+	MessageID string
+	Value     int
+}
+
+// ImageScore is the sum of the votes on an image.
+type ImageScore struct {
+	ImageID  string
+	Category string
+	Score    int
+	Votes    int
+}
+
+// RecordImagePost remembers that the bot's message messageID shows an image, so reactions to
+// it count as votes.
+func (s *Store) RecordImagePost(ctx context.Context, messageID, imageID, category string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO image_posts (message_id, image_id, category, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (message_id) DO UPDATE SET image_id = excluded.image_id, category = excluded.category`,
+		messageID, imageID, category, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("record image post: %w", err)
+	}
+	return nil
+}
+
+// PostedImage returns the image and category shown by a message recorded with
+// RecordImagePost. It reports false for any other message.
+func (s *Store) PostedImage(ctx context.Context, messageID string) (imageID, category string, ok bool, err error) {
+	err = s.db.QueryRowContext(ctx,
+		`SELECT image_id, category FROM image_posts WHERE message_id = ?`, messageID).Scan(&imageID, &category)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", false, nil
+	}
+	if err != nil {
+		return "", "", false, fmt.Errorf("get image post: %w", err)
+	}
+	return imageID, category, true, nil
+}
+
+// SetVote records a vote. A user has one vote per image, so a newer vote replaces an older one
+// even when it was given on another message.
+func (s *Store) SetVote(ctx context.Context, v Vote) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO image_votes (image_id, user_id, category, message_id, vote, created_at) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (image_id, user_id) DO UPDATE SET
+			message_id = excluded.message_id, vote = excluded.vote, created_at = excluded.created_at`,
+		v.ImageID, v.UserID, v.Category, v.MessageID, v.Value, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("set vote: %w", err)
+	}
+	return nil
+}
+
+// RemoveVote withdraws a user's vote given on a message, when it is still the vote of that value.
+func (s *Store) RemoveVote(ctx context.Context, messageID, userID string, value int) error {
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM image_votes WHERE message_id = ? AND user_id = ? AND vote = ?`,
+		messageID, userID, value)
+	if err != nil {
+		return fmt.Errorf("remove vote: %w", err)
+	}
+	return nil
+}
+
+// ImageScores returns the score of every voted image of a category by image ID.
+func (s *Store) ImageScores(ctx context.Context, category string) (map[string]int, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT image_id, SUM(vote) FROM image_votes WHERE category = ? GROUP BY image_id`, category)
+	if err != nil {
+		return nil, fmt.Errorf("load image scores: %w", err)
+	}
+	defer rows.Close()
+
+	scores := make(map[string]int)
+	for rows.Next() {
+		var id string
+		var score int
+		if err := rows.Scan(&id, &score); err != nil {
+			return nil, fmt.Errorf("scan image score: %w", err)
+		}
+		scores[id] = score
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("load image scores: %w", err)
+	}
+	return scores, nil
+}
+
+// TopImages returns the highest scored images, best first, of a category or of every
+// category when category is empty. Images without a positive score are left out.
+func (s *Store) TopImages(ctx context.Context, category string, limit int) ([]ImageScore, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT image_id, category, SUM(vote) AS score, COUNT(*) FROM image_votes
+		WHERE ? = '' OR category = ?
+		GROUP BY image_id
+		HAVING score > 0
+		ORDER BY score DESC, COUNT(*) DESC, image_id
+		LIMIT ?`,
+		category, category, limit)
+	if err != nil {
+		return nil, fmt.Errorf("load top images: %w", err)
+	}
+	defer rows.Close()
+
+	var top []ImageScore
+	for rows.Next() {
+		var score ImageScore
+		if err := rows.Scan(&score.ImageID, &score.Category, &score.Score, &score.Votes); err != nil {
+			return nil, fmt.Errorf("scan image score: %w", err)
+		}
+		top = append(top, score)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("load top images: %w", err)
+	}
+	return top, nil
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// TestStore_Votes tests that votes add up per image, once per user.
+func TestStore_Votes(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	votes := []Vote{
+		{ImageID: "a1", UserID: "u1", Category: "wooper", MessageID: "m1", Value: 1},
+		{ImageID: "a1", UserID: "u2", Category: "wooper", MessageID: "m1", Value: 1},
+		{ImageID: "a1", UserID: "u3", Category: "wooper", MessageID: "m1", Value: -1},
+		// u1 voting the same image on another message replaces the first vote
+		{ImageID: "a1", UserID: "u1", Category: "wooper", MessageID: "m2", Value: 1},
+		{ImageID: "b2", UserID: "u1", Category: "wooper", MessageID: "m3", Value: 1},
+		{ImageID: "b2", UserID: "u2", Category: "wooper", MessageID: "m3", Value: 1},
+		{ImageID: "c3", UserID: "u1", Category: "wooper", MessageID: "m4", Value: -1},
+		{ImageID: "d4", UserID: "u1", Category: "cats", MessageID: "m5", Value: 1},
+	}
+	for _, v := range votes {
+		if err := store.SetVote(ctx, v); err != nil {
+			t.Fatalf("Failed to set vote: %v", err)
+		}
+	}
+
+	scores, err := store.ImageScores(ctx, "wooper")
+	if err != nil {
+		t.Fatalf("Failed to load scores: %v", err)
+	}
+	if expected := map[string]int{"a1": 1, "b2": 2, "c3": -1}; !reflect.DeepEqual(scores, expected) {
+		t.Errorf("Expected %v, got %v", expected, scores)
+	}
+
+	// Removing a reaction only withdraws the vote of the same value
+	if err := store.RemoveVote(ctx, "m1", "u3", 1); err != nil {
+		t.Fatalf("Failed to remove vote: %v", err)
+	}
+	if err := store.RemoveVote(ctx, "m1", "u3", -1); err != nil {
+		t.Fatalf("Failed to remove vote: %v", err)
+	}
+	// Removing the reaction a vote was replaced from does nothing
+	if err := store.RemoveVote(ctx, "m1", "u1", 1); err != nil {
+		t.Fatalf("Failed to remove vote: %v", err)
+	}
+
+	top, err := store.TopImages(ctx, "wooper", 10)
+	if err != nil {
+		t.Fatalf("Failed to load top images: %v", err)
+	}
+	expected := []ImageScore{
+		{ImageID: "a1", Category: "wooper", Score: 2, Votes: 2},
+		{ImageID: "b2", Category: "wooper", Score: 2, Votes: 2},
+	}
+	if !reflect.DeepEqual(top, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, top)
+	}
+
+	all, err := store.TopImages(ctx, "", 1)
+	if err != nil {
+		t.Fatalf("Failed to load top images: %v", err)
+	}
+	if len(all) != 1 || all[0].ImageID != "a1" {
+		t.Errorf("Expected a1 on top of every category, got %+v", all)
+	}
+}
+
+// TestStore_ImagePosts tests recording which image the bot's messages show.
+func TestStore_ImagePosts(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	if err := store.RecordImagePost(ctx, "m1", "a1", "wooper"); err != nil {
+		t.Fatalf("Failed to record post: %v", err)
+	}
+	imageID, category, ok, err := store.PostedImage(ctx, "m1")
+	if err != nil || !ok || imageID != "a1" || category != "wooper" {
+		t.Errorf("Expected a1 in wooper, got %q %q %v %v", imageID, category, ok, err)
+	}
+	if _, _, ok, err := store.PostedImage(ctx, "m2"); err != nil || ok {
+		t.Errorf("Expected unknown messages not to be found, got %v %v", ok, err)
+	}
+}
@@ -2,34 +2,29 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os/signal"
+	"path/filepath"
 	"syscall"
+	"time"
+	_ "time/tzdata" // daily posts need zone data even on images without it
 
+	"wooper-bot/internal/alerts"
 	"wooper-bot/internal/bot"
+	"wooper-bot/internal/commands"
 	"wooper-bot/internal/config"
 	"wooper-bot/internal/handlers"
 	"wooper-bot/internal/logger"
+	"wooper-bot/internal/metrics"
+	"wooper-bot/internal/outbound"
+	"wooper-bot/internal/scheduler"
 	"wooper-bot/internal/services"
+	"wooper-bot/internal/storage"
 
-	"github.com/bwmarrin/discordgo"
 	"go.uber.org/zap"
 )
 
-func buildCategoryChoices(imageService *services.ImageService) []*discordgo.ApplicationCommandOptionChoice {
-	categories := imageService.GetAvailableCategories()
-	choices := make([]*discordgo.ApplicationCommandOptionChoice, len(categories))
-
-	for i, category := range categories {
-		choices[i] = &discordgo.ApplicationCommandOptionChoice{
-			Name:  category,
-			Value: category,
-		}
-	}
-
-	return choices
-}
-
 func main() {
 	// Initialize logging
 	if err := logger.Init(); err != nil {
@@ -49,8 +44,68 @@ func main() {
 		logger.Logger.Fatal("image service error", zap.Error(err))
 	}
 
-	messageHandler := handlers.NewMessageHandler(imageService)
-	interactionHandler := handlers.NewInteractionHandler(imageService)
+	dex, err := services.NewDex()
+	if err != nil {
+		logger.Logger.Fatal("pokedex error", zap.Error(err))
+	}
+
+	store, err := storage.Open(cfg.DatabasePath)
+	if err != nil {
+		logger.Logger.Fatal("storage error", zap.Error(err))
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			logger.Logger.Error("storage close error", zap.Error(err))
+		}
+	}()
+
+	dispatcher := outbound.New(outbound.DefaultConcurrency)
+
+	notifier := alerts.New(cfg.AdminChannelID, dispatcher)
+	imageService.OnQuarantine(func(path string, reason error) {
+		notifier.Notify(fmt.Sprintf("Image `%s` was taken out of rotation after %d failed uploads: %v",
+			filepath.Join(filepath.Base(filepath.Dir(path)), filepath.Base(path)), services.QuarantineThreshold, reason))
+	})
+
+	sched, err := scheduler.New(imageService, store, dispatcher)
+	if err != nil {
+		logger.Logger.Fatal("scheduler error", zap.Error(err))
+	}
+
+	settingsService := services.NewSettingsService(store, imageService)
+	permissionService := services.NewPermissionService(store)
+
+	router := commands.NewRouter(settingsService, dispatcher)
+	router.SetPermissionRules(permissionService.Rules)
+	sched.SetLocale(func(guildID string) string { return router.Settings(guildID).Language })
+	router.Use(
+		commands.Recover(),
+		commands.Logging(),
+		commands.Timing(5*time.Second),
+		commands.RequirePermissions(),
+		commands.RequireAccess(permissionService.Rules),
+		commands.RateLimits(commands.NewLimiter(), "⏳"),
+	)
+	imageHandler := handlers.NewImageHandler(imageService, store, notifier)
+	imageHandler.Register(router)
+	handlers.NewFavoritesHandler(imageHandler, store).Register(router)
+	ratingHandler := handlers.NewRatingHandler(imageService, store)
+	ratingHandler.Register(router)
+	handlers.NewStatsHandler(imageService, store).Register(router)
+	handlers.NewCatchHandler(imageService, store).Register(router)
+	handlers.NewTradeHandler(imageService, store).Register(router)
+	quizHandler := handlers.NewQuizHandler(imageService, store)
+	quizHandler.Register(router)
+	handlers.NewDexHandler(imageService, dex).Register(router)
+	handlers.NewDailyHandler(imageService, sched).Register(router)
+	handlers.NewScheduleHandler(imageService, sched).Register(router)
+	handlers.NewConfigHandler(settingsService, imageService).Register(router)
+	triggerHandler := handlers.NewTriggerHandler(router, imageService, store, dispatcher)
+	triggerHandler.Register(router)
+	handlers.NewPermissionHandler(router, imageService, store).Register(router)
+
+	messageHandler := handlers.NewMessageHandler(router, triggerHandler)
+	interactionHandler := handlers.NewInteractionHandler(router)
 
 	b, err := bot.New(cfg.DiscordBotToken)
 	if err != nil {
@@ -58,30 +113,19 @@ func main() {
 	}
 	b.AddHandler(messageHandler.OnMessageCreate)
 	b.AddHandler(interactionHandler.OnInteractionCreate)
-
-	// Register slash commands
-	commands := []*discordgo.ApplicationCommand{
-		{
-			Name:        "image",
-			Description: "Get a random image from a category",
-			Options: []*discordgo.ApplicationCommandOption{
-				{
-					Type:        discordgo.ApplicationCommandOptionString,
-					Name:        "category",
-					Description: "Image category to get a random image from",
-					Required:    true,
-					Choices:     buildCategoryChoices(imageService),
-				},
-			},
-		},
-	}
+	b.AddHandler(ratingHandler.OnMessageReactionAdd)
+	b.AddHandler(ratingHandler.OnMessageReactionRemove)
+	b.AddHandler(quizHandler.OnMessageCreate)
+	b.AddService(sched)
+	b.AddService(notifier)
+	b.AddService(metrics.NewReporter(metrics.Default, 5*time.Minute))
 
 	logger.Logger.Info("Bot initialized successfully")
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	if err := b.StartWithCommands(ctx, commands); err != nil {
+	if err := b.StartWithCommands(ctx, router.ApplicationCommands()); err != nil {
 		logger.Logger.Fatal("run error", zap.Error(err))
 	}
 }
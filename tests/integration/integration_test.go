@@ -2,6 +2,7 @@ package integration
 
 import (
 	"context"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
@@ -69,6 +70,35 @@ func TestIntegration(t *testing.T) {
 	}
 }
 
+// TestBundledQuizImages tests that the images shipped with the bot can be used in quizzes.
+func TestBundledQuizImages(t *testing.T) {
+	if err := logger.Init(); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+	defer logger.Close()
+
+	imageService, err := services.NewImageService(filepath.Join("..", "..", "img"))
+	if err != nil {
+		t.Fatalf("Failed to load the bundled images: %v", err)
+	}
+	for _, category := range imageService.GetAvailableCategories() {
+		imagePath := imageService.GetRandomQuizImage(category)
+		if imagePath == "" {
+			t.Errorf("Expected quiz images with answers in %s", category)
+			continue
+		}
+		file, err := os.Open(imagePath)
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", imagePath, err)
+		}
+		err = services.RenderSilhouette(file, io.Discard)
+		file.Close()
+		if err != nil {
+			t.Errorf("Failed to render the silhouette of %s: %v", imagePath, err)
+		}
+	}
+}
+
 // setupTestImages creates test image files in the given directory
 func setupTestImages(t *testing.T, baseDir string) {
 	categories := []string{"wooper", "cats", "dogs"}